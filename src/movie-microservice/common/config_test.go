@@ -0,0 +1,198 @@
+/*
+ * @File: common.config_test.go
+ * @Description: Tests the layers of the configuration: defaults, config files and environment
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// testConfig is a config file with the settings having no default
+const testConfig = `{
+	"mgAddrs": "127.0.0.1:27017",
+	"mgDbName": "go-microservices",
+	"authAddr": "http://127.0.0.1:8808",
+	"issuer": "seedotech"
+}`
+
+// writeTestFile writes a file in a temporary directory and returns its name
+func writeTestFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	name = filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(name, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestReadConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		env   map[string]string
+		check func(t *testing.T, config *Configuration)
+		err   []string // parts of the error, the configuration is valid when empty
+	}{
+		{
+			name:  "defaults",
+			files: []string{testConfig},
+			check: func(t *testing.T, config *Configuration) {
+				if config.Port != ":8809" || config.JwksCacheTTL != 300 || config.CoverStorage != StorageLocal || config.CoverMaxSize != 5<<20 {
+					t.Errorf("config = %+v", config)
+				}
+			},
+		},
+		{
+			name:  "later files override the earlier ones",
+			files: []string{testConfig, `{"port": ":9000", "coverDir": "/var/covers"}`, `{"coverDir": "/data/covers"}`},
+			check: func(t *testing.T, config *Configuration) {
+				if config.Port != ":9000" || config.CoverDir != "/data/covers" || config.AuthAddr != "http://127.0.0.1:8808" {
+					t.Errorf("config = %+v", config)
+				}
+			},
+		},
+		{
+			name:  "environment overrides the files",
+			files: []string{testConfig, `{"port": ":9000", "enableGinFileLog": true}`},
+			env: map[string]string{
+				"MOVIESVC_PORT":             ":9100",
+				"MOVIESVC_ENABLEGINFILELOG": "false",
+				"MOVIESVC_COVERMAXSIZE":     "1048576",
+				"MOVIESVC_MGMAXPOOLSIZE":    "20",
+				"MOVIESVC_AUTHADDR":         "http://users:8808",
+			},
+			check: func(t *testing.T, config *Configuration) {
+				if config.Port != ":9100" || config.EnableGinFileLog || config.CoverMaxSize != 1<<20 || config.MgMaxPoolSize != 20 || config.AuthAddr != "http://users:8808" {
+					t.Errorf("config = %+v", config)
+				}
+			},
+		},
+		{
+			name:  "secret read from a file",
+			files: []string{testConfig, `{"mgDbPassword": "in the file"}`},
+			env:   map[string]string{"MOVIESVC_MGDBPASSWORD_FILE": "secret\n"},
+			check: func(t *testing.T, config *Configuration) {
+				if config.MgDbPassword != "secret" {
+					t.Errorf("mgDbPassword = %q", config.MgDbPassword)
+				}
+			},
+		},
+		{
+			name:  "unknown setting",
+			files: []string{testConfig, `{"coverSize": 1024}`},
+			err:   []string{"override.json", `unknown field "coverSize"`},
+		},
+		{
+			name:  "invalid variable",
+			files: []string{testConfig},
+			env:   map[string]string{"MOVIESVC_COVERMAXSIZE": "5MB"},
+			err:   []string{"MOVIESVC_COVERMAXSIZE"},
+		},
+		{
+			name:  "missing secret file",
+			files: []string{testConfig},
+			env:   map[string]string{"MOVIESVC_MGDBPASSWORD_FILE": ""},
+			err:   []string{"MOVIESVC_MGDBPASSWORD_FILE"},
+		},
+		{
+			name:  "every problem reported",
+			files: []string{`{"mgDbName": "go-microservices", "jwksCacheTTL": 0, "coverStorage": "s3", "coverMaxSize": -1}`},
+			err: []string{
+				ErrConfigInvalid,
+				"mgAddrs is required, set it in a config file or with MOVIESVC_MGADDRS",
+				"authAddr is required",
+				"issuer is required",
+				"jwksCacheTTL must be positive",
+				"coverStorage: " + ErrStorageUnsupported,
+				"coverMaxSize must be positive",
+			},
+		},
+		{
+			name:  "GridFS without MongoDB",
+			files: []string{testConfig, `{"storage": "sqlite", "sqlDataSource": "movies.db", "coverStorage": "gridfs"}`},
+			err:   []string{"coverStorage: " + ErrStorageGridFSSQL},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The first file is named config.json, the next ones override.json
+			var files []string
+			for i, content := range test.files {
+				name := "override.json"
+				if i == 0 {
+					name = "config.json"
+				}
+				files = append(files, writeTestFile(t, name, content))
+			}
+			for key, value := range test.env {
+				if strings.HasSuffix(key, "_FILE") {
+					// An empty content names a missing file
+					name := filepath.Join(t.TempDir(), "missing")
+					if len(value) > 0 {
+						name = writeTestFile(t, "secret", value)
+					}
+					value = name
+				}
+				t.Setenv(key, value)
+			}
+
+			config, err := readConfig(files)
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				test.check(t, config)
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("config = %+v, want an error", config)
+			}
+			for _, part := range test.err {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("error = %v, want %q", err, part)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	first := writeTestFile(t, "config.json", testConfig)
+	second := writeTestFile(t, "override.json", `{"port": ":9000", "logFilename": "`+filepath.ToSlash(filepath.Join(dir, "server.log"))+`"}`)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		current, configFiles = atomic.Value{}, nil
+	})
+
+	// The files of the environment are read when no file is given
+	t.Setenv("MOVIESVC_CONFIG", first+","+second)
+	if err := LoadConfig(nil); err != nil {
+		t.Fatal(err)
+	}
+	if Config().Port != ":9000" || Config().MgDbName != "go-microservices" {
+		t.Errorf("config = %+v", Config())
+	}
+	if len(configFiles) != 2 || configFiles[0] != first || configFiles[1] != second {
+		t.Errorf("config files = %v, they are read again by ReloadConfig", configFiles)
+	}
+
+	// The given files are preferred, an invalid configuration is not stored
+	if err := LoadConfig([]string{first, writeTestFile(t, "invalid.json", `{"coverStorage": "s3"}`)}); err == nil {
+		t.Fatal("invalid configuration loaded")
+	}
+	if Config().Port != ":9000" {
+		t.Errorf("config = %+v, want the previous one", Config())
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * @File: common.reload.go
+ * @Description: Reloads the configuration of the service without restarting it
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package common
+
+import (
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ConfigWatchInterval is the interval between the checks of the config files
+const ConfigWatchInterval = 5 * time.Second
+
+// restartSettings are read once when the service starts, their changes are ignored by ReloadConfig
+var restartSettings = []string{
+	"port", "enableGinConsoleLog", "enableGinFileLog", "readTimeout", "writeTimeout", "idleTimeout",
+	"tracingExporter", "tracingFile", "tracingEndpoint", "tracingSampleRatio",
+	"mgAddrs", "mgDbName", "mgDbUsername", "mgDbPassword",
+	"mgMaxPoolSize", "mgMinPoolSize", "mgMaxConnIdleTime", "mgReadConcern", "mgWriteConcern", "mgReadPreference",
+	"mgStartupTimeout",
+	"storage", "sqlDataSource", "sqlTimeout",
+	"coverStorage", "coverDir",
+}
+
+// reloadMutex serializes the reloads
+var reloadMutex sync.Mutex
+
+// ReloadConfig reads the config files and the environment variables again and swaps in the new configuration.
+// The changes of the settings read when the service starts are ignored with a warning. The current configuration
+// is kept when the new one is not valid.
+func ReloadConfig() error {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	config, err := readConfig(configFiles)
+	if err != nil {
+		log.Warn("Configuration is not reloaded: ", err)
+		return err
+	}
+
+	previous := reflect.ValueOf(Config()).Elem()
+	next := reflect.ValueOf(config).Elem()
+	for i := 0; i < next.NumField(); i++ {
+		name := strings.Split(next.Type().Field(i).Tag.Get("json"), ",")[0]
+		if !isRestartSetting(name) || reflect.DeepEqual(previous.Field(i).Interface(), next.Field(i).Interface()) {
+			continue
+		}
+
+		log.Warn(name, " can't be changed without restarting the service, the change is ignored")
+		next.Field(i).Set(previous.Field(i))
+	}
+
+	current.Store(config)
+	setupLogger(config)
+	log.Info("Configuration is reloaded")
+
+	return nil
+}
+
+// isRestartSetting tells if a setting is only read when the service starts
+func isRestartSetting(name string) bool {
+	for _, setting := range restartSettings {
+		if setting == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WatchConfig reloads the configuration when the service receives SIGHUP and when a config file
+// is modified, the config files are checked every interval. The returned function stops watching.
+func WatchConfig(interval time.Duration) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	modTimes := configModTimes()
+	ticker := time.NewTicker(interval)
+	done, stopped := make(chan struct{}), make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case <-signals:
+				log.Info("SIGHUP received, reloading the configuration")
+			case <-ticker.C:
+				times := configModTimes()
+				if reflect.DeepEqual(times, modTimes) {
+					continue
+				}
+				modTimes = times
+				log.Info("Config file modified, reloading the configuration")
+			}
+
+			ReloadConfig()
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		ticker.Stop()
+		close(done)
+		<-stopped
+	}
+}
+
+// configModTimes returns the modification times of the config files, the missing files have the zero time
+func configModTimes() []time.Time {
+	times := make([]time.Time, len(configFiles))
+	for i, file := range configFiles {
+		if info, err := os.Stat(file); err == nil {
+			times[i] = info.ModTime()
+		}
+	}
+
+	return times
+}
@@ -0,0 +1,61 @@
+/*
+ * @File: controllers.controllers.go
+ * @Description: Implements the checks and responses shared by the API logic functions
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"../common"
+	"../daos"
+	"../middlewares"
+	"../models"
+	"../utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// userClaims returns the claims of the authenticated user, it responds with 401
+// and returns nil when the token doesn't identify the user
+func userClaims(ctx *gin.Context) *utils.SdtClaims {
+	claims := middlewares.Claims(ctx)
+	if claims == nil || len(claims.Subject) == 0 {
+		ctx.Error(models.NewError(models.KindUnauthorized, common.ErrTokenInvalid))
+		return nil
+	}
+
+	return claims
+}
+
+// checkMovie responds with 404 and returns false when the movie doesn't exist
+func checkMovie(ctx *gin.Context, movieDAO daos.MovieRepository, id string) bool {
+	_, err := movieDAO.GetByID(ctx.Request.Context(), id)
+	if err == mongo.ErrNoDocuments {
+		ctx.Error(models.NewError(models.KindNotFound, common.ErrMovieNotFound))
+		return false
+	}
+	if err != nil {
+		ctx.Error(err)
+		return false
+	}
+
+	return true
+}
+
+// bindPageQuery binds and validates the page query of the request, it responds
+// with 400 and returns false when the query is not valid
+func bindPageQuery(ctx *gin.Context, defaultSort string) (models.PageQuery, bool) {
+	var query models.PageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return query, false
+	}
+
+	query.SetDefaults()
+
+	if len(query.Sort) == 0 {
+		query.Sort = defaultSort
+	}
+
+	return query, true
+}
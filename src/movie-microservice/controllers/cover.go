@@ -0,0 +1,208 @@
+/*
+ * @File: controllers.cover.go
+ * @Description: Implements Movie Cover API logic functions
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"../common"
+	"../daos"
+	"../models"
+	"../storage"
+	"../utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// maxMultipartOverhead is the room for the multipart headers around the cover file
+	maxMultipartOverhead = 1 << 20
+
+	// coverMaxAge is how long the clients cache a cover before checking its ETag again
+	coverMaxAge = 3600 // seconds
+)
+
+// Cover manages the cover images of the Movies
+type Cover struct {
+	utils    utils.Utils
+	movieDAO daos.MovieRepository
+	config   func() *common.Configuration
+}
+
+// NewCover creates the Cover APIs, the Movies are stored in the given repository and the
+// covers are limited to the coverMaxSize of the given configuration, which may be reloaded
+func NewCover(movieDAO daos.MovieRepository, config func() *common.Configuration) *Cover {
+	return &Cover{movieDAO: movieDAO, config: config}
+}
+
+// UploadCover godoc
+// @Summary Upload the cover image of a movie
+// @Description Upload a JPEG, PNG or GIF cover image, it replaces the previous one. The small, medium and large thumbnails are generated.
+// @Tags movie
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param id path string true "Movie ID"
+// @Param cover formData file true "Cover image"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 413 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.MovieCover
+// @Router /movies/{id}/cover [post]
+func (c *Cover) UploadCover(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	if err := c.utils.ValidateObjectID(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	maxSize := c.config().CoverMaxSize
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize+maxMultipartOverhead)
+	fileHeader, err := ctx.FormFile("cover")
+	if err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+	if fileHeader.Size > maxSize {
+		ctx.Error(models.NewError(models.KindPayloadTooLarge, common.ErrImageTooLarge))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+	data, err := ioutil.ReadAll(file)
+	file.Close()
+	if err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	img, contentType, err := c.utils.DecodeImage(data)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if !checkMovie(ctx, c.movieDAO, id) {
+		return
+	}
+
+	// The thumbnails are stored first, so that a stored cover always has its thumbnails
+	for size, width := range common.CoverSizes {
+		var thumbnail []byte
+		thumbnail, err = c.utils.Thumbnail(img, width)
+		if err == nil {
+			err = storage.Covers.Save(ctx.Request.Context(), coverName(id, size), thumbnail)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = storage.Covers.Save(ctx.Request.Context(), coverName(id, common.CoverOriginal), data)
+	}
+
+	cover := models.MovieCover{contentType, time.Now()}
+	if err == nil {
+		err = c.movieDAO.SetCover(ctx.Request.Context(), id, cover)
+	}
+
+	if err == nil {
+		ctx.JSON(http.StatusOK, cover)
+	} else if err == mongo.ErrNoDocuments {
+		ctx.Error(models.NewError(models.KindNotFound, common.ErrMovieNotFound))
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// GetCover godoc
+// @Summary Get the cover image of a movie
+// @Description Get the uploaded cover image of a movie or one of its thumbnails. The image can be cached, its ETag changes with every upload.
+// @Tags movie
+// @Produce  jpeg,png,gif
+// @Param id path string true "Movie ID"
+// @Param size query string false "small, medium, large or original (default)"
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {file} file
+// @Router /movies/{id}/cover [get]
+func (c *Cover) GetCover(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	if err := c.utils.ValidateObjectID(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	size := ctx.DefaultQuery("size", common.CoverOriginal)
+	if _, ok := common.CoverSizes[size]; !ok && size != common.CoverOriginal {
+		ctx.Error(models.NewError(models.KindValidationFailed, common.ErrCoverSizeInvalid))
+		return
+	}
+
+	movie, err := c.movieDAO.GetByID(ctx.Request.Context(), id)
+	if err == mongo.ErrNoDocuments {
+		ctx.Error(models.NewError(models.KindNotFound, common.ErrMovieNotFound))
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if movie.Cover == nil {
+		ctx.Error(models.NewError(models.KindNotFound, common.ErrCoverNotFound))
+		return
+	}
+
+	data, err := storage.Covers.Load(ctx.Request.Context(), coverName(id, size))
+	if err == storage.ErrNotExist {
+		ctx.Error(models.NewError(models.KindNotFound, common.ErrCoverNotFound))
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	contentType := "image/jpeg"
+	if size == common.CoverOriginal {
+		contentType = movie.Cover.ContentType
+	}
+
+	// ServeContent answers the conditional requests with 304 Not Modified
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", coverMaxAge))
+	ctx.Header("ETag", fmt.Sprintf(`"%s-%s-%d"`, id, size, movie.Cover.UpdatedAt.UnixNano()/int64(time.Millisecond)))
+	http.ServeContent(ctx.Writer, ctx.Request, "", movie.Cover.UpdatedAt, bytes.NewReader(data))
+}
+
+// coverName returns the storage name of a size of the cover of a movie
+func coverName(id string, size string) string {
+	return id + "/" + size
+}
+
+// deleteCoverFiles removes the cover of a movie and its thumbnails
+func deleteCoverFiles(ctx context.Context, id string) error {
+	for size := range common.CoverSizes {
+		if err := storage.Covers.Delete(ctx, coverName(id, size)); err != nil {
+			return err
+		}
+	}
+
+	return storage.Covers.Delete(ctx, coverName(id, common.CoverOriginal))
+}
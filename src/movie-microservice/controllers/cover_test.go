@@ -0,0 +1,200 @@
+/*
+ * @File: controllers.cover_test.go
+ * @Description: Tests the Movie Cover API logic functions
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"../common"
+	"../models"
+	"github.com/gin-gonic/gin"
+)
+
+// testCoverMaxSize is the coverMaxSize of the tests
+const testCoverMaxSize = 64 << 10
+
+// newCoverRouter routes the Cover APIs like main does, next to the Movie APIs
+func newCoverRouter(t *testing.T, movies ...models.Movie) *gin.Engine {
+	router, repository := newMovieRouter(t, movies...)
+
+	cv := NewCover(repository, func() *common.Configuration {
+		return &common.Configuration{CoverMaxSize: testCoverMaxSize}
+	})
+	router.GET("/movies/:id/cover", cv.GetCover)
+	router.POST("/movies/:id/cover", cv.UploadCover)
+
+	return router
+}
+
+// uploadCover uploads a cover file
+func uploadCover(router *gin.Engine, path string, data []byte) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("cover", "cover")
+	part.Write(data)
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// testImage encodes an image of the given size to JPEG or PNG
+func testImage(t *testing.T, format string, width int, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(x), uint8(y), 0x80, 0xff})
+		}
+	}
+
+	var buffer bytes.Buffer
+	var err error
+	if format == "jpeg" {
+		err = jpeg.Encode(&buffer, img, nil)
+	} else {
+		err = png.Encode(&buffer, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+// resizePNG changes the size in the header of a PNG image, its pixels are not decoded before checking its size
+func resizePNG(data []byte, width int, height int) []byte {
+	data = append([]byte{}, data...)
+
+	// The IHDR chunk follows the 8 bytes signature, its data starts with the width and the height
+	ihdr := data[8+4 : 8+4+4+13]
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[8:], uint32(height))
+	binary.BigEndian.PutUint32(data[8+4+4+13:], crc32.ChecksumIEEE(ihdr))
+	return data
+}
+
+func TestUploadCover(t *testing.T) {
+	movie := models.Movie{ID: models.NewObjectID(), Name: "Heat", Year: 1995}
+	router := newCoverRouter(t, movie)
+	path := "/movies/" + movie.ID.Hex() + "/cover"
+
+	small := testImage(t, "png", 40, 30)
+	tests := []struct {
+		name   string
+		path   string
+		data   []byte
+		status int
+		code   int
+	}{
+		{"text", path, []byte("not an image"), http.StatusBadRequest, common.StatusValidationFailed},
+		{"truncated", path, small[:len(small)/2], http.StatusBadRequest, common.StatusValidationFailed},
+		{"too large", path, append(testImage(t, "jpeg", 16, 16), make([]byte, testCoverMaxSize)...), http.StatusRequestEntityTooLarge, common.StatusPayloadTooLarge},
+		{"too many pixels", path, resizePNG(small, 6000, 5000), http.StatusRequestEntityTooLarge, common.StatusPayloadTooLarge},
+		{"unknown movie", "/movies/" + models.NewObjectID().Hex() + "/cover", small, http.StatusNotFound, common.StatusNotFound},
+		{"invalid id", "/movies/1/cover", small, http.StatusBadRequest, common.StatusValidationFailed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var invalid models.Error
+			decode(t, uploadCover(router, test.path, test.data), test.status, &invalid)
+			if invalid.Code != test.code {
+				t.Errorf("error = %+v, want the code %d", invalid, test.code)
+			}
+		})
+	}
+
+	// No cover is stored by the rejected uploads
+	decode(t, serve(router, http.MethodGet, path, nil), http.StatusNotFound, nil)
+
+	var cover models.MovieCover
+	decode(t, uploadCover(router, path, small), http.StatusOK, &cover)
+	if cover.ContentType != "image/png" {
+		t.Errorf("cover = %+v", cover)
+	}
+
+	var found models.Movie
+	decode(t, serve(router, http.MethodGet, "/movies/"+movie.ID.Hex(), nil), http.StatusOK, &found)
+	if found.CoverImage != "/api/v1/movies/"+movie.ID.Hex()+"/cover" || found.Cover == nil {
+		t.Errorf("movie = %+v", found)
+	}
+}
+
+func TestGetCover(t *testing.T) {
+	movie := models.Movie{ID: models.NewObjectID(), Name: "Heat", Year: 1995}
+	router := newCoverRouter(t, movie)
+	path := "/movies/" + movie.ID.Hex() + "/cover"
+
+	original := testImage(t, "png", 800, 600)
+	decode(t, uploadCover(router, path, original), http.StatusOK, nil)
+
+	tests := []struct {
+		size        string
+		contentType string
+		width       int
+	}{
+		{"", "image/png", 800},
+		{"original", "image/png", 800},
+		{"small", "image/jpeg", 160},
+		{"medium", "image/jpeg", 320},
+		{"large", "image/jpeg", 640},
+	}
+	etags := map[string]bool{}
+	for _, test := range tests {
+		query := ""
+		if len(test.size) > 0 {
+			query = "?size=" + test.size
+		}
+		t.Run("size="+test.size, func(t *testing.T) {
+			w := serve(router, http.MethodGet, path+query, nil)
+			decode(t, w, http.StatusOK, nil)
+			if contentType := w.Header().Get("Content-Type"); contentType != test.contentType {
+				t.Errorf("content type = %s, want %s", contentType, test.contentType)
+			}
+			config, _, err := image.DecodeConfig(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Width != test.width || config.Height != test.width*3/4 {
+				t.Errorf("size = %dx%d, want a width of %d", config.Width, config.Height, test.width)
+			}
+
+			// The cached cover is not sent again while its ETag is unchanged
+			etag := w.Header().Get("ETag")
+			if len(etag) == 0 {
+				t.Fatal("no ETag")
+			}
+			etags[etag] = true
+
+			req := httptest.NewRequest(http.MethodGet, path+query, nil)
+			req.Header.Set("If-None-Match", etag)
+			cached := httptest.NewRecorder()
+			router.ServeHTTP(cached, req)
+			if cached.Code != http.StatusNotModified || cached.Body.Len() != 0 {
+				t.Errorf("status = %d with %d bytes, want %d", cached.Code, cached.Body.Len(), http.StatusNotModified)
+			}
+		})
+	}
+	if len(etags) != 4 {
+		t.Errorf("etags = %v, want one per size", etags)
+	}
+
+	decode(t, serve(router, http.MethodGet, path+"?size=huge", nil), http.StatusBadRequest, nil)
+	decode(t, serve(router, http.MethodGet, "/movies/"+models.NewObjectID().Hex()+"/cover", nil), http.StatusNotFound, nil)
+}
@@ -0,0 +1,203 @@
+/*
+ * @File: controllers.genre.go
+ * @Description: Implements Movie Genre API logic functions
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"net/http"
+
+	"../common"
+	"../daos"
+	"../models"
+	"../utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Genre manages Movie Genre CRUD
+type Genre struct {
+	utils    utils.Utils
+	genreDAO daos.GenreRepository
+	movieDAO daos.MovieRepository
+}
+
+// NewGenre creates the Genre APIs, the Movies and the Genres are stored in the given repositories
+func NewGenre(movieDAO daos.MovieRepository, genreDAO daos.GenreRepository) *Genre {
+	return &Genre{genreDAO: genreDAO, movieDAO: movieDAO}
+}
+
+// AddGenre godoc
+// @Summary Add a new genre
+// @Description Add a new movie genre
+// @Tags genre
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param genre body models.AddMovieGenre true "Add Genre"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.MovieGenre
+// @Router /genres [post]
+func (g *Genre) AddGenre(ctx *gin.Context) {
+	var addGenre models.AddMovieGenre
+	if err := ctx.ShouldBindJSON(&addGenre); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	genre := models.MovieGenre{models.NewObjectID(), addGenre.Name, addGenre.Description}
+	err := g.genreDAO.Insert(ctx.Request.Context(), genre)
+	if err == nil {
+		ctx.JSON(http.StatusOK, genre)
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// ListGenres godoc
+// @Summary List all existing genres
+// @Description List all existing movie genres
+// @Tags genre
+// @Accept  json
+// @Produce  json
+// @Failure 500 {object} models.Error
+// @Success 200 {array} models.MovieGenre
+// @Router /genres/list [get]
+func (g *Genre) ListGenres(ctx *gin.Context) {
+	genres, err := g.genreDAO.GetAll(ctx.Request.Context())
+
+	if err == nil {
+		ctx.JSON(http.StatusOK, genres)
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// GetGenreByID godoc
+// @Summary Get a genre by ID
+// @Description Get a movie genre by ID
+// @Tags genre
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Genre ID"
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.MovieGenre
+// @Router /genres/{id} [get]
+func (g *Genre) GetGenreByID(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	if err := g.utils.ValidateObjectID(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	genre, err := g.genreDAO.GetByID(ctx.Request.Context(), id)
+	if err == nil {
+		ctx.JSON(http.StatusOK, genre)
+	} else {
+		g.daoError(ctx, err)
+	}
+}
+
+// UpdateGenre godoc
+// @Summary Update an existing genre
+// @Description Update the name and description of an existing movie genre
+// @Tags genre
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param id path string true "Genre ID"
+// @Param genre body models.AddMovieGenre true "Genre"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Message
+// @Router /genres/{id} [put]
+func (g *Genre) UpdateGenre(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	if err := g.utils.ValidateObjectID(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var addGenre models.AddMovieGenre
+	if err := ctx.ShouldBindJSON(&addGenre); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	err := g.genreDAO.Update(ctx.Request.Context(), models.MovieGenre{utils.ObjectIDHex(id), addGenre.Name, addGenre.Description})
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.Message{"Successfully"})
+	} else {
+		g.daoError(ctx, err)
+	}
+}
+
+// DeleteGenreByID godoc
+// @Summary Delete a genre by ID
+// @Description Delete a movie genre by ID. A genre used by movies is only deleted with cascade=true, it is then removed from the movies.
+// @Tags genre
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param id path string true "Genre ID"
+// @Param cascade query bool false "Remove the genre from the movies using it"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Message
+// @Router /genres/{id} [delete]
+func (g *Genre) DeleteGenreByID(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	if err := g.utils.ValidateObjectID(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	genreID := utils.ObjectIDHex(id)
+	count, err := g.movieDAO.CountByGenre(ctx.Request.Context(), genreID)
+	if err != nil {
+		g.daoError(ctx, err)
+		return
+	}
+
+	if count > 0 {
+		if ctx.Query("cascade") != "true" {
+			ctx.Error(models.NewError(models.KindConflict, common.ErrGenreInUse))
+			return
+		}
+
+		if err = g.movieDAO.RemoveGenre(ctx.Request.Context(), genreID); err != nil {
+			g.daoError(ctx, err)
+			return
+		}
+	}
+
+	err = g.genreDAO.DeleteByID(ctx.Request.Context(), id)
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.Message{"Successfully"})
+	} else {
+		g.daoError(ctx, err)
+	}
+}
+
+// daoError returns 404 for the missing documents and 500 for the other errors of the DAOs
+func (g *Genre) daoError(ctx *gin.Context, err error) {
+	if err == mongo.ErrNoDocuments {
+		ctx.Error(models.NewError(models.KindNotFound, common.ErrGenreNotFound))
+		return
+	}
+
+	ctx.Error(err)
+}
@@ -0,0 +1,84 @@
+/*
+ * @File: controllers.health_test.go
+ * @Description: Tests the liveness and readiness probes
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"../common"
+	"../models"
+	"github.com/gin-gonic/gin"
+)
+
+// newHealthRouter routes the probes like main does
+func newHealthRouter(checks map[string]HealthCheck) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	h := NewHealth(checks)
+	router := gin.New()
+	router.GET("/healthz", h.Live)
+	router.GET("/readyz", h.Ready)
+
+	return router
+}
+
+func TestHealth(t *testing.T) {
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer auth.Close()
+
+	available := func(ctx context.Context) error { return nil }
+	router := newHealthRouter(map[string]HealthCheck{
+		"mongodb": available,
+		"auth":    HTTPCheck(func() string { return auth.URL }),
+	})
+
+	var health models.Health
+	decode(t, serve(router, http.MethodGet, "/healthz", nil), http.StatusOK, &health)
+	if health.Status != common.HealthOK || len(health.Dependencies) != 0 {
+		t.Errorf("liveness = %+v", health)
+	}
+
+	decode(t, serve(router, http.MethodGet, "/readyz", nil), http.StatusOK, &health)
+	if health.Status != common.HealthOK || len(health.Dependencies) != 2 || health.Dependencies["auth"].Status != common.HealthOK {
+		t.Errorf("readiness = %+v", health)
+	}
+
+	router = newHealthRouter(map[string]HealthCheck{
+		"mongodb": func(ctx context.Context) error { return errors.New("server selection error") },
+		"auth":    HTTPCheck(func() string { return auth.URL }),
+	})
+
+	health = models.Health{}
+	decode(t, serve(router, http.MethodGet, "/readyz", nil), http.StatusServiceUnavailable, &health)
+	mongodb := health.Dependencies["mongodb"]
+	if health.Status != common.HealthUnavailable || mongodb.Status != common.HealthUnavailable || mongodb.Error != "server selection error" {
+		t.Errorf("readiness = %+v", health)
+	}
+	if health.Dependencies["auth"].Status != common.HealthOK {
+		t.Errorf("auth = %+v, want ok", health.Dependencies["auth"])
+	}
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	health = models.Health{}
+	router = newHealthRouter(map[string]HealthCheck{"auth": HTTPCheck(func() string { return notFound.URL })})
+	decode(t, serve(router, http.MethodGet, "/readyz", nil), http.StatusServiceUnavailable, &health)
+	if auth := health.Dependencies["auth"]; auth.Status != common.HealthUnavailable || auth.Error != "404 Not Found" {
+		t.Errorf("auth = %+v, want unavailable", auth)
+	}
+
+	auth.Close()
+	health = models.Health{}
+	router = newHealthRouter(map[string]HealthCheck{"auth": HTTPCheck(func() string { return auth.URL })})
+	decode(t, serve(router, http.MethodGet, "/readyz", nil), http.StatusServiceUnavailable, &health)
+	if health.Dependencies["auth"].Status != common.HealthUnavailable {
+		t.Errorf("auth = %+v, want unavailable", health.Dependencies["auth"])
+	}
+}
@@ -6,23 +6,47 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"../common"
 	"../daos"
 	"../models"
+	"../tracing"
+	"../utils"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // Movie manages Movie CRUD
 type Movie struct {
-	movieDAO daos.Movie
+	utils        utils.Utils
+	movieDAO     daos.MovieRepository
+	genreDAO     daos.GenreRepository
+	ratingDAO    daos.RatingRepository
+	watchlistDAO daos.WatchlistRepository
+	historyDAO   daos.HistoryRepository
+	config       func() *common.Configuration
 }
 
+// NewMovie creates the Movie APIs storing the Movies and the Genres in the given repositories,
+// the Ratings, Watchlists and Watch Histories of a deleted Movie are removed from theirs.
+// The logins are sent to the authAddr of the given configuration, which may be reloaded.
+func NewMovie(movieDAO daos.MovieRepository, genreDAO daos.GenreRepository, ratingDAO daos.RatingRepository,
+	watchlistDAO daos.WatchlistRepository, historyDAO daos.HistoryRepository, config func() *common.Configuration) *Movie {
+	return &Movie{movieDAO: movieDAO, genreDAO: genreDAO, ratingDAO: ratingDAO, watchlistDAO: watchlistDAO, historyDAO: historyDAO,
+		config: config}
+}
+
+// authClient sends the logins to the user service
+var authClient = &http.Client{Timeout: 10 * time.Second, Transport: &tracing.Transport{}}
+
 // Login godoc
 // @Summary Log in to the service
 // @Description Log in to the service
@@ -32,6 +56,7 @@ type Movie struct {
 // @Param user formData string true "Username"
 // @Param password formData string true "Password"
 // @Failure 401 {object} models.Error
+// @Failure 503 {object} models.Error
 // @Success 200 {object} models.Token
 // @Router /login [post]
 func (m *Movie) Login(ctx *gin.Context) {
@@ -43,21 +68,39 @@ func (m *Movie) Login(ctx *gin.Context) {
 		"password": {password},
 	}
 
-	var authAddr string = common.Config.AuthAddr + "/api/v1/admin/auth"
-	resp, err := http.PostForm(authAddr, formData)
+	// The request is traced, the user service continues the trace of the login
+	var authAddr string = m.config().AuthAddr + "/api/v1/admin/auth"
+	req, err := http.NewRequestWithContext(ctx.Request.Context(), http.MethodPost, authAddr, strings.NewReader(formData.Encode()))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := authClient.Do(req)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, models.Error{common.StatusCodeUnknown, err.Error()})
+		ctx.Error(models.NewError(models.KindUpstreamUnavailable, common.ErrAuthUnavailable))
 		log.Debug("[ERROR]: ", err)
 		return
 	}
+	defer resp.Body.Close()
 
+	// A response which isn't the JSON of a token or an error is not relayed
 	if resp.StatusCode == http.StatusOK {
 		var token models.Token
-		json.NewDecoder(resp.Body).Decode(&token)
+		if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			ctx.Error(models.NewError(models.KindUpstreamUnavailable, common.ErrAuthUnavailable))
+			log.Debug("[ERROR]: ", err)
+			return
+		}
 		ctx.JSON(http.StatusOK, token)
 	} else {
 		var e models.Error
-		json.NewDecoder(resp.Body).Decode(&e)
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+			ctx.Error(models.NewError(models.KindUpstreamUnavailable, common.ErrAuthUnavailable))
+			log.Debug("[ERROR]: ", err)
+			return
+		}
 		ctx.JSON(resp.StatusCode, e)
 	}
 }
@@ -70,45 +113,351 @@ func (m *Movie) Login(ctx *gin.Context) {
 // @Produce  json
 // @Param Authorization header string true "Token"
 // @Param user body models.AddMovie true "Add Movie"
+// @Failure 400 {object} models.Error
 // @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
 // @Success 200 {object} models.Message
 // @Router /movies [post]
 func (m *Movie) AddMovie(ctx *gin.Context) {
-	var movie models.Movie
-	if err := ctx.BindJSON(&movie); err != nil {
-		ctx.AbortWithStatus(http.StatusBadRequest)
+	var addMovie models.AddMovie
+	if err := ctx.ShouldBindJSON(&addMovie); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	if !m.checkGenres(ctx, addMovie.Genres) {
 		return
 	}
 
-	movie.ID = bson.NewObjectId()
-	err := m.movieDAO.Insert(movie)
+	movie := models.Movie{
+		ID:          models.NewObjectID(),
+		Name:        addMovie.Name,
+		URL:         addMovie.URL,
+		CoverImage:  addMovie.CoverImage,
+		Description: addMovie.Description,
+		Year:        addMovie.Year,
+		Genres:      addMovie.Genres,
+	}
+	err := m.movieDAO.Insert(ctx.Request.Context(), movie)
 	if err == nil {
 		ctx.JSON(http.StatusOK, models.Message{"Successfully"})
 	} else {
-		ctx.JSON(http.StatusForbidden, models.Error{common.StatusCodeUnknown, err.Error()})
-		log.Debug("[ERROR]: ", err)
+		ctx.Error(err)
 	}
 }
 
 // ListMovies godoc
-// @Summary List all existing Movies
-// @Description List all existing Movies
+// @Summary List existing Movies
+// @Description List a page of the existing Movies, the next page starts after the returned nextCursor
 // @Tags movie
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string true "Token"
-// @Failure 404 {object} models.Error
-// @Success 200 {object} models.Movie
+// @Param limit query int false "Page size, 20 by default, 100 at most"
+// @Param after query string false "Cursor of the previous page"
+// @Param offset query int false "Number of movies to skip, can't be used with after"
+// @Param sort query string false "Sort field: id or name, prefixed by - for the descending order"
+// @Param name query string false "Part of the movie name"
+// @Param genre query string false "Genre ID or name"
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.MoviePage
+// @Router /movies [get]
 // @Router /movies/list [get]
 func (m *Movie) ListMovies(ctx *gin.Context) {
-	var movies []models.Movie
+	var query models.PageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	query.SetDefaults()
+
+	filter := models.MovieFilter{Name: ctx.Query("name")}
+
 	var err error
-	movies, err = m.movieDAO.GetAll()
+	if genre := ctx.Query("genre"); len(genre) > 0 {
+		var genreID models.ObjectID
+		genreID, err = m.findGenre(ctx.Request.Context(), genre)
+		if err == mongo.ErrNoDocuments {
+			// No movie has an unknown genre
+			ctx.JSON(http.StatusOK, models.MoviePage{[]models.Movie{}, "", 0})
+			return
+		}
+		filter.Genre = genreID
+	}
 
+	var movies []models.Movie
+	var next string
+	var total int
 	if err == nil {
-		ctx.JSON(http.StatusOK, movies)
+		movies, next, total, err = m.movieDAO.GetPage(ctx.Request.Context(), filter, query)
+	}
+
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.MoviePage{movies, next, total})
 	} else {
-		ctx.JSON(http.StatusNotFound, models.Error{common.StatusCodeUnknown, "Cannot retrieve movie information"})
-		log.Debug("[ERROR]: ", err)
+		ctx.Error(err)
+	}
+}
+
+// SearchMovies godoc
+// @Summary Search movies
+// @Description Search the movies by the words of their names and descriptions, the best matches come first
+// @Tags movie
+// @Accept  json
+// @Produce  json
+// @Param q query string true "Words to search, quoted phrases and -excluded words are supported"
+// @Param genre query string false "Genre ID or name"
+// @Param year query int false "Release year"
+// @Param facets query bool false "Count the matched movies per genre and per year"
+// @Param limit query int false "Page size, 20 by default, 100 at most"
+// @Param offset query int false "Number of movies to skip"
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.MovieSearchPage
+// @Router /movies/search [get]
+func (m *Movie) SearchMovies(ctx *gin.Context) {
+	var query models.MovieSearchQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
 	}
+
+	query.SetDefaults()
+
+	filter := models.MovieFilter{Year: query.Year}
+	if len(query.Genre) > 0 {
+		genreID, err := m.findGenre(ctx.Request.Context(), query.Genre)
+		if err == mongo.ErrNoDocuments {
+			// No movie has an unknown genre
+			ctx.JSON(http.StatusOK, models.MovieSearchPage{Items: []models.MovieSearchHit{}})
+			return
+		} else if err != nil {
+			ctx.Error(err)
+			return
+		}
+		filter.Genre = genreID
+	}
+
+	hits, total, err := m.movieDAO.Search(ctx.Request.Context(), query.Q, filter, query.Limit, query.Offset)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	terms := m.utils.SearchTerms(query.Q)
+	for i := range hits {
+		hits[i].Highlights.Name = m.utils.Highlight(hits[i].Movie.Name, terms)
+		hits[i].Highlights.Description = m.utils.Highlight(hits[i].Movie.Description, terms)
+	}
+
+	page := models.MovieSearchPage{Items: hits, Total: total}
+	if query.Facets {
+		var facets models.MovieFacets
+		facets, err = m.movieDAO.SearchFacets(ctx.Request.Context(), query.Q, filter)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		page.Facets = &facets
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// GetMovieByID godoc
+// @Summary Get a movie by ID
+// @Description Get a movie by ID
+// @Tags movie
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Movie ID"
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Movie
+// @Router /movies/{id} [get]
+func (m *Movie) GetMovieByID(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	if err := m.utils.ValidateObjectID(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	movie, err := m.movieDAO.GetByID(ctx.Request.Context(), id)
+	if err == nil {
+		ctx.JSON(http.StatusOK, movie)
+	} else {
+		m.daoError(ctx, err)
+	}
+}
+
+// ReplaceMovie godoc
+// @Summary Replace an existing movie
+// @Description Replace all fields of an existing movie
+// @Tags movie
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param id path string true "Movie ID"
+// @Param movie body models.AddMovie true "Movie"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Message
+// @Router /movies/{id} [put]
+func (m *Movie) ReplaceMovie(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	if err := m.utils.ValidateObjectID(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var addMovie models.AddMovie
+	if err := ctx.ShouldBindJSON(&addMovie); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	if !m.checkGenres(ctx, addMovie.Genres) {
+		return
+	}
+
+	// The rating is maintained by the ratings, it's kept
+	err := m.movieDAO.UpdateFields(ctx.Request.Context(), id, addMovie.Update())
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.Message{"Successfully"})
+	} else {
+		m.daoError(ctx, err)
+	}
+}
+
+// UpdateMovie godoc
+// @Summary Update an existing movie
+// @Description Update the given fields of an existing movie
+// @Tags movie
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param id path string true "Movie ID"
+// @Param movie body models.UpdateMovie true "Movie fields"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Message
+// @Router /movies/{id} [patch]
+func (m *Movie) UpdateMovie(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	if err := m.utils.ValidateObjectID(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var updateMovie models.UpdateMovie
+	if err := ctx.ShouldBindJSON(&updateMovie); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	if updateMovie.Genres != nil && !m.checkGenres(ctx, *updateMovie.Genres) {
+		return
+	}
+
+	err := m.movieDAO.UpdateFields(ctx.Request.Context(), id, updateMovie)
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.Message{"Successfully"})
+	} else {
+		m.daoError(ctx, err)
+	}
+}
+
+// DeleteMovieByID godoc
+// @Summary Delete a movie by ID
+// @Description Delete a movie by ID
+// @Tags movie
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param id path string true "Movie ID"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Message
+// @Router /movies/{id} [delete]
+func (m *Movie) DeleteMovieByID(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	if err := m.utils.ValidateObjectID(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if !checkMovie(ctx, m.movieDAO, id) {
+		return
+	}
+
+	// The ratings, the watches and the cover are deleted before the movie, so that a failed
+	// deletion leaves the movie to delete again instead of leaving orphans behind
+	movieID := utils.ObjectIDHex(id)
+	err := m.ratingDAO.DeleteByMovie(ctx.Request.Context(), movieID)
+	if err == nil {
+		err = m.watchlistDAO.DeleteByMovie(ctx.Request.Context(), movieID)
+	}
+	if err == nil {
+		err = m.historyDAO.DeleteByMovie(ctx.Request.Context(), movieID)
+	}
+	if err == nil {
+		err = deleteCoverFiles(ctx.Request.Context(), id)
+	}
+	if err == nil {
+		err = m.movieDAO.DeleteByID(ctx.Request.Context(), id)
+	}
+
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.Message{"Successfully"})
+	} else {
+		m.daoError(ctx, err)
+	}
+}
+
+// checkGenres responds with 400 and returns false when some of the given genres don't exist
+func (m *Movie) checkGenres(ctx *gin.Context, genres []models.ObjectID) bool {
+	exist, err := m.genreDAO.Exist(ctx.Request.Context(), genres)
+	if err != nil {
+		ctx.Error(err)
+		return false
+	}
+	if !exist {
+		ctx.Error(models.NewError(models.KindValidationFailed, common.ErrGenreNotFound))
+		return false
+	}
+
+	return true
+}
+
+// findGenre returns the id of a genre given by its id or its name
+func (m *Movie) findGenre(ctx context.Context, genre string) (models.ObjectID, error) {
+	if primitive.IsValidObjectID(genre) {
+		return utils.ObjectIDHex(genre), nil
+	}
+
+	movieGenre, err := m.genreDAO.GetByName(ctx, genre)
+	return movieGenre.ID, err
+}
+
+// daoError returns 404 for the missing documents and 500 for the other errors of the DAOs
+func (m *Movie) daoError(ctx *gin.Context, err error) {
+	if err == mongo.ErrNoDocuments {
+		ctx.Error(models.NewError(models.KindNotFound, common.ErrMovieNotFound))
+		return
+	}
+
+	ctx.Error(err)
 }
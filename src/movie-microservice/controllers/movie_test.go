@@ -0,0 +1,549 @@
+/*
+ * @File: controllers.movie_test.go
+ * @Description: Tests the Movie API logic functions against an in-memory or a SQL repository
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"../common"
+	"../daos"
+	"../databases"
+	"../middlewares"
+	"../models"
+	"../storage"
+	"../utils"
+	"github.com/gin-gonic/gin"
+)
+
+// The tests run against the in-memory repository by default. With -storage=sqlite they run against
+// a temporary SQLite database, with -storage=postgres against the -dataSource PostgreSQL database,
+// whose movies, genres, ratings and watches are deleted.
+var (
+	testStorage    = flag.String("storage", "memory", "storage of the tested repositories: memory, sqlite or postgres")
+	testDataSource = flag.String("dataSource", "", "data source of the tested SQL database")
+)
+
+// testUserID is the id of the authenticated user of the tests, the requests having a testUserHeader
+// are authenticated as the user of its id
+const (
+	testUserID     = "5bbdadf782ebac06a695a8e7"
+	testUserHeader = "X-Test-User"
+)
+
+// newMovieRouter routes the Movie APIs like main does. The authentication is replaced by the
+// claims of the test user, whose role is not checked.
+func newMovieRouter(t *testing.T, movies ...models.Movie) (*gin.Engine, daos.MovieRepository) {
+	gin.SetMode(gin.TestMode)
+	utils.RegisterValidations()
+
+	var repository daos.MovieRepository
+	var genres daos.GenreRepository
+	var ratings daos.RatingRepository
+	var watchlists daos.WatchlistRepository
+	var histories daos.HistoryRepository
+	if *testStorage == "memory" {
+		memoryGenres, memoryRatings := daos.NewMemoryGenre(), daos.NewMemoryRating()
+		repository = daos.NewMemoryMovie(memoryGenres, memoryRatings)
+		genres = memoryGenres
+		ratings = memoryRatings
+		watchlists = daos.NewMemoryWatchlist()
+		histories = daos.NewMemoryHistory()
+	} else {
+		db := openTestDatabase(t, "movies", "genres", "movie_genres", "ratings", "watchlists", "watch_history")
+		repository = daos.NewSQLMovie(db)
+		genres = daos.NewSQLGenre(db)
+		ratings = daos.NewSQLRating(db)
+		watchlists = daos.NewSQLWatchlist(db)
+		histories = daos.NewSQLHistory(db)
+	}
+	for _, movie := range movies {
+		if err := repository.Insert(context.Background(), movie); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The covers of the deleted movies are deleted from a temporary directory
+	storage.Covers = &storage.Local{Dir: t.TempDir()}
+
+	c := NewMovie(repository, genres, ratings, watchlists, histories, func() *common.Configuration {
+		return &common.Configuration{}
+	})
+	g := NewGenre(repository, genres)
+	r := NewRating(repository, ratings)
+	w := NewWatch(repository, watchlists, histories)
+	router := gin.New()
+	router.Use(middlewares.Errors())
+	router.Use(func(ctx *gin.Context) {
+		claims := &utils.SdtClaims{Name: "viewer", Role: common.RoleViewer}
+		claims.Subject = testUserID
+		if userID := ctx.GetHeader(testUserHeader); len(userID) > 0 {
+			claims.Subject = userID
+		}
+		ctx.Set(middlewares.ClaimsKey, claims)
+	})
+	router.GET("/movies/list", c.ListMovies)
+	router.GET("/movies/search", c.SearchMovies)
+	router.GET("/movies/:id", c.GetMovieByID)
+	router.POST("/movies", c.AddMovie)
+	router.PUT("/movies/:id", c.ReplaceMovie)
+	router.PATCH("/movies/:id", c.UpdateMovie)
+	router.DELETE("/movies/:id", c.DeleteMovieByID)
+	router.POST("/movies/:id/ratings", r.RateMovie)
+	router.DELETE("/movies/:id/ratings", r.DeleteRating)
+	router.GET("/movies/:id/reviews", r.ListReviews)
+	router.PATCH("/movies/:id/reviews/:reviewId", r.ModerateReview)
+	router.GET("/me/watchlist", w.ListWatchlist)
+	router.POST("/me/watchlist", w.AddToWatchlist)
+	router.DELETE("/me/watchlist/:movieId", w.RemoveFromWatchlist)
+	router.GET("/me/history", w.ListHistory)
+	router.GET("/me/history/:movieId", w.GetProgress)
+	router.PUT("/me/history/:movieId", w.SaveProgress)
+	router.GET("/genres/list", g.ListGenres)
+	router.POST("/genres", g.AddGenre)
+	router.DELETE("/genres/:id", g.DeleteGenreByID)
+
+	return router, repository
+}
+
+// openTestDatabase opens the SQL database of the tests, the rows of the given tables are deleted
+func openTestDatabase(t *testing.T, tables ...string) *databases.SQL {
+	t.Helper()
+
+	dataSource := *testDataSource
+	if *testStorage == common.StorageSQLite && len(dataSource) == 0 {
+		dataSource = filepath.Join(t.TempDir(), "test.db")
+	}
+
+	db := &databases.SQL{}
+	if err := db.Open(*testStorage, dataSource); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+
+	for _, table := range tables {
+		if _, err := db.DB.Exec("DELETE FROM " + table); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+// serve performs a request, the body is encoded to JSON
+func serve(router *gin.Engine, method string, path string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body == nil {
+		reader = bytes.NewReader(nil)
+	} else {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decode decodes a JSON response, the test fails when the status is not the expected one
+func decode(t *testing.T, w *httptest.ResponseRecorder, status int, result interface{}) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body.String())
+	}
+	if result != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), result); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAddMovie(t *testing.T) {
+	router, repository := newMovieRouter(t)
+
+	w := serve(router, http.MethodPost, "/movies", gin.H{"name": "The Matrix", "year": 1999, "rating": gin.H{"average": 5, "count": 10}})
+	decode(t, w, http.StatusOK, nil)
+
+	movies, _, total, err := repository.GetPage(context.Background(), models.MovieFilter{}, models.PageQuery{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || movies[0].Name != "The Matrix" || movies[0].Year != 1999 || movies[0].ID.IsZero() {
+		t.Fatalf("movies = %+v", movies)
+	}
+	if movies[0].Rating.Count != 0 {
+		t.Errorf("rating = %+v, the rating is maintained by the ratings", movies[0].Rating)
+	}
+
+	decode(t, serve(router, http.MethodPost, "/movies", "not a movie"), http.StatusBadRequest, nil)
+
+	var invalid models.Error
+	w = serve(router, http.MethodPost, "/movies", gin.H{"name": " ", "url": "not a url", "year": 1700})
+	decode(t, w, http.StatusBadRequest, &invalid)
+	if invalid.Code != common.StatusValidationFailed || len(invalid.Fields) != 3 {
+		t.Fatalf("error = %+v, want the name, url and year fields", invalid)
+	}
+	for i, field := range []string{"name", "url", "year"} {
+		if invalid.Fields[i].Field != field {
+			t.Errorf("field %d = %+v, want %s", i, invalid.Fields[i], field)
+		}
+	}
+}
+
+func TestListMovies(t *testing.T) {
+	action := models.NewObjectID()
+	router, _ := newMovieRouter(t,
+		models.Movie{ID: models.NewObjectID(), Name: "Inception", Genres: []models.ObjectID{action}},
+		models.Movie{ID: models.NewObjectID(), Name: "Amelie"},
+		models.Movie{ID: models.NewObjectID(), Name: "Heat", Genres: []models.ObjectID{action}},
+	)
+
+	var page models.MoviePage
+	decode(t, serve(router, http.MethodGet, "/movies/list?limit=2&sort=name", nil), http.StatusOK, &page)
+	if page.Total != 3 || len(page.Items) != 2 || page.Items[0].Name != "Amelie" || page.Items[1].Name != "Heat" {
+		t.Fatalf("first page = %+v", page)
+	}
+
+	decode(t, serve(router, http.MethodGet, "/movies/list?limit=2&sort=name&after="+page.NextCursor, nil), http.StatusOK, &page)
+	if len(page.Items) != 1 || page.Items[0].Name != "Inception" || len(page.NextCursor) != 0 {
+		t.Fatalf("last page = %+v", page)
+	}
+
+	decode(t, serve(router, http.MethodGet, "/movies/list?sort=-name&genre="+action.Hex(), nil), http.StatusOK, &page)
+	if page.Total != 2 || page.Items[0].Name != "Inception" || page.Items[1].Name != "Heat" {
+		t.Fatalf("genre page = %+v", page)
+	}
+
+	decode(t, serve(router, http.MethodGet, "/movies/list?name=E.", nil), http.StatusOK, &page)
+	if page.Total != 0 {
+		t.Fatalf("name page = %+v, the name is not a regular expression", page)
+	}
+
+	decode(t, serve(router, http.MethodGet, "/movies/list?sort=year", nil), http.StatusBadRequest, nil)
+	decode(t, serve(router, http.MethodGet, "/movies/list?offset=1&after="+page.NextCursor+"x", nil), http.StatusBadRequest, nil)
+}
+
+func TestGetMovieByID(t *testing.T) {
+	movie := models.Movie{ID: models.NewObjectID(), Name: "Heat", Year: 1995}
+	router, _ := newMovieRouter(t, movie)
+
+	var found models.Movie
+	decode(t, serve(router, http.MethodGet, "/movies/"+movie.ID.Hex(), nil), http.StatusOK, &found)
+	if found.ID != movie.ID || found.Name != movie.Name || found.Year != movie.Year {
+		t.Errorf("movie = %+v", found)
+	}
+
+	var notFound models.Error
+	decode(t, serve(router, http.MethodGet, "/movies/"+models.NewObjectID().Hex(), nil), http.StatusNotFound, &notFound)
+	if notFound.Code != common.StatusNotFound || notFound.Kind != models.KindNotFound.Name || notFound.Message != common.ErrMovieNotFound {
+		t.Errorf("error = %+v, want code %d", notFound, common.StatusNotFound)
+	}
+
+	var invalid models.Error
+	decode(t, serve(router, http.MethodGet, "/movies/invalid", nil), http.StatusBadRequest, &invalid)
+	if invalid.Code != common.StatusValidationFailed || invalid.Message != common.ErrNotObjectIDHex {
+		t.Errorf("error = %+v, want code %d", invalid, common.StatusValidationFailed)
+	}
+}
+
+func TestReplaceAndUpdateMovie(t *testing.T) {
+	movie := models.Movie{ID: models.NewObjectID(), Name: "Heat", Year: 1995, Rating: models.MovieRating{4.5, 2}}
+	router, repository := newMovieRouter(t, movie)
+	path := "/movies/" + movie.ID.Hex()
+
+	decode(t, serve(router, http.MethodPut, path, gin.H{"name": "Heat (1995)", "description": "A heist"}), http.StatusOK, nil)
+
+	replaced, err := repository.GetByID(context.Background(), movie.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.Name != "Heat (1995)" || replaced.Description != "A heist" || replaced.Year != 0 {
+		t.Errorf("replaced movie = %+v", replaced)
+	}
+	if replaced.Rating != movie.Rating {
+		t.Errorf("rating = %+v, want %+v", replaced.Rating, movie.Rating)
+	}
+
+	decode(t, serve(router, http.MethodPatch, path, gin.H{"year": 1995}), http.StatusOK, nil)
+
+	updated, err := repository.GetByID(context.Background(), movie.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "Heat (1995)" || updated.Year != 1995 {
+		t.Errorf("updated movie = %+v", updated)
+	}
+
+	missing := "/movies/" + models.NewObjectID().Hex()
+	decode(t, serve(router, http.MethodPut, missing, gin.H{"name": "Missing"}), http.StatusNotFound, nil)
+	decode(t, serve(router, http.MethodPatch, missing, gin.H{}), http.StatusNotFound, nil)
+	decode(t, serve(router, http.MethodPatch, "/movies/invalid", gin.H{}), http.StatusBadRequest, nil)
+}
+
+func TestReplaceMovieWithCover(t *testing.T) {
+	movie := models.Movie{ID: models.NewObjectID(), Name: "Heat", Year: 1995}
+	router, repository := newMovieRouter(t, movie)
+	path := "/movies/" + movie.ID.Hex()
+
+	// The uploaded cover replaces the cover image with its path
+	err := repository.SetCover(context.Background(), movie.ID.Hex(), models.MovieCover{"image/jpeg", time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found models.Movie
+	decode(t, serve(router, http.MethodGet, path, nil), http.StatusOK, &found)
+	if found.CoverImage != "/api/v1"+path+"/cover" {
+		t.Fatalf("cover image = %q", found.CoverImage)
+	}
+
+	found.Year = 1996
+	decode(t, serve(router, http.MethodPut, path, found), http.StatusOK, nil)
+
+	replaced, err := repository.GetByID(context.Background(), movie.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.CoverImage != found.CoverImage || replaced.Year != 1996 {
+		t.Errorf("replaced movie = %+v", replaced)
+	}
+
+	for _, coverImage := range []string{"cover.jpg", "//movies.example.com/cover.jpg", "ftp:cover.jpg"} {
+		decode(t, serve(router, http.MethodPatch, path, gin.H{"coverImage": coverImage}), http.StatusBadRequest, nil)
+	}
+}
+
+func TestSearchMovies(t *testing.T) {
+	scifi, heat := models.NewObjectID(), models.NewObjectID()
+	router, repository := newMovieRouter(t,
+		models.Movie{ID: models.NewObjectID(), Name: "The Matrix", Year: 1999, Genres: []models.ObjectID{scifi},
+			Description: "A hacker learns the truth about the matrix"},
+		models.Movie{ID: models.NewObjectID(), Name: "Hackers", Year: 1995,
+			Description: "Teenage hackers discover a plot, the matrix of a virus"},
+		models.Movie{ID: heat, Name: "Heat", Year: 1995, Description: "A heist"},
+	)
+
+	var page models.MovieSearchPage
+	decode(t, serve(router, http.MethodGet, "/movies/search?q=matrix&facets=true", nil), http.StatusOK, &page)
+	if page.Total != 2 || page.Items[0].Movie.Name != "The Matrix" || page.Items[1].Movie.Name != "Hackers" {
+		t.Fatalf("page = %+v", page)
+	}
+	if len(page.Items[0].Highlights.Name) != 1 || page.Items[0].Highlights.Name[0] != "The <em>Matrix</em>" {
+		t.Errorf("highlights = %+v", page.Items[0].Highlights)
+	}
+	if page.Facets == nil || len(page.Facets.Genres) != 1 || page.Facets.Genres[0].ID != scifi || len(page.Facets.Years) != 2 || page.Facets.Years[0].Year != 1999 {
+		t.Errorf("facets = %+v", page.Facets)
+	}
+
+	decode(t, serve(router, http.MethodGet, "/movies/search?q=matrix+-virus", nil), http.StatusOK, &page)
+	if page.Total != 1 || page.Items[0].Movie.Name != "The Matrix" {
+		t.Errorf("excluded page = %+v", page)
+	}
+
+	decode(t, serve(router, http.MethodGet, "/movies/search?q=hacker&year=1995", nil), http.StatusOK, &page)
+	if page.Total != 1 || page.Items[0].Movie.Name != "Hackers" {
+		t.Errorf("year page = %+v", page)
+	}
+
+	decode(t, serve(router, http.MethodGet, "/movies/search?q=matrix&limit=1&offset=1", nil), http.StatusOK, &page)
+	if page.Total != 2 || len(page.Items) != 1 || page.Items[0].Movie.Name != "Hackers" {
+		t.Errorf("second page = %+v", page)
+	}
+
+	decode(t, serve(router, http.MethodGet, "/movies/search?q=+", nil), http.StatusBadRequest, nil)
+
+	// The search follows the modifications of the movies
+	name := "Heat Reloaded"
+	if err := repository.UpdateFields(context.Background(), heat.Hex(), models.UpdateMovie{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	decode(t, serve(router, http.MethodGet, "/movies/search?q=reloaded", nil), http.StatusOK, &page)
+	if page.Total != 1 || page.Items[0].Movie.ID != heat {
+		t.Errorf("updated page = %+v", page)
+	}
+
+	if err := repository.DeleteByID(context.Background(), heat.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	decode(t, serve(router, http.MethodGet, "/movies/search?q=reloaded", nil), http.StatusOK, &page)
+	if page.Total != 0 || len(page.Items) != 0 {
+		t.Errorf("deleted page = %+v", page)
+	}
+}
+
+func TestGenres(t *testing.T) {
+	router, repository := newMovieRouter(t)
+
+	var action, drama models.MovieGenre
+	decode(t, serve(router, http.MethodPost, "/genres", gin.H{"name": "Action"}), http.StatusOK, &action)
+	decode(t, serve(router, http.MethodPost, "/genres", gin.H{"name": "Drama"}), http.StatusOK, &drama)
+
+	var genres []models.MovieGenre
+	decode(t, serve(router, http.MethodGet, "/genres/list", nil), http.StatusOK, &genres)
+	if len(genres) != 2 || genres[0] != action || genres[1] != drama {
+		t.Fatalf("genres = %+v", genres)
+	}
+
+	decode(t, serve(router, http.MethodPost, "/movies", gin.H{"name": "Heat", "description": "A heist", "genres": []models.ObjectID{drama.ID, action.ID}}), http.StatusOK, nil)
+	decode(t, serve(router, http.MethodPost, "/movies", gin.H{"name": "Speed", "description": "A heist on a bus", "genres": []models.ObjectID{action.ID}}), http.StatusOK, nil)
+	decode(t, serve(router, http.MethodPost, "/movies", gin.H{"name": "Missing", "genres": []models.ObjectID{models.NewObjectID()}}), http.StatusBadRequest, nil)
+
+	var page models.MoviePage
+	decode(t, serve(router, http.MethodGet, "/movies/list?sort=name&genre=action", nil), http.StatusOK, &page)
+	if page.Total != 2 || page.Items[0].Name != "Heat" || len(page.Items[0].Genres) != 2 || page.Items[0].Genres[0] != drama.ID {
+		t.Fatalf("genre page = %+v", page)
+	}
+
+	var search models.MovieSearchPage
+	decode(t, serve(router, http.MethodGet, "/movies/search?q=heist&facets=true", nil), http.StatusOK, &search)
+	if search.Facets == nil || len(search.Facets.Genres) != 2 || search.Facets.Genres[0].Name != "Action" || search.Facets.Genres[0].Count != 2 || search.Facets.Genres[1].Name != "Drama" {
+		t.Errorf("facets = %+v", search.Facets)
+	}
+
+	decode(t, serve(router, http.MethodDelete, "/genres/"+action.ID.Hex(), nil), http.StatusConflict, nil)
+	decode(t, serve(router, http.MethodDelete, "/genres/"+action.ID.Hex()+"?cascade=true", nil), http.StatusOK, nil)
+
+	count, err := repository.CountByGenre(context.Background(), action.ID)
+	if err != nil || count != 0 {
+		t.Errorf("count = %d, %v", count, err)
+	}
+	decode(t, serve(router, http.MethodGet, "/movies/list?sort=name", nil), http.StatusOK, &page)
+	if len(page.Items) != 2 || len(page.Items[0].Genres) != 1 || page.Items[0].Genres[0] != drama.ID {
+		t.Errorf("page = %+v", page)
+	}
+}
+
+func TestDeleteMovie(t *testing.T) {
+	heat := models.Movie{ID: models.NewObjectID(), Name: "Heat", Year: 1995}
+	speed := models.Movie{ID: models.NewObjectID(), Name: "Speed", Year: 1994}
+	router, repository := newMovieRouter(t, heat, speed)
+	path := "/movies/" + heat.ID.Hex()
+
+	for _, movie := range []models.Movie{heat, speed} {
+		decode(t, serve(router, http.MethodPost, "/movies/"+movie.ID.Hex()+"/ratings", gin.H{"score": 4, "review": "Great"}), http.StatusOK, nil)
+		decode(t, serve(router, http.MethodPost, "/me/watchlist", gin.H{"movieId": movie.ID}), http.StatusOK, nil)
+		decode(t, serve(router, http.MethodPut, "/me/history/"+movie.ID.Hex(), gin.H{"position": 60, "duration": 7200}), http.StatusOK, nil)
+	}
+	err := storage.Covers.Save(context.Background(), coverName(heat.ID.Hex(), common.CoverOriginal), []byte("cover"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The movie is deleted last, it's kept when its cover can't be deleted
+	covers := storage.Covers
+	file := filepath.Join(t.TempDir(), "file")
+	if err = ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	storage.Covers = &storage.Local{Dir: file}
+	decode(t, serve(router, http.MethodDelete, path, nil), http.StatusInternalServerError, nil)
+	decode(t, serve(router, http.MethodGet, path, nil), http.StatusOK, nil)
+	storage.Covers = covers
+
+	decode(t, serve(router, http.MethodDelete, path, nil), http.StatusOK, nil)
+	decode(t, serve(router, http.MethodGet, path, nil), http.StatusNotFound, nil)
+	decode(t, serve(router, http.MethodDelete, path, nil), http.StatusNotFound, nil)
+	decode(t, serve(router, http.MethodDelete, "/movies/invalid", nil), http.StatusBadRequest, nil)
+
+	// The ratings, the watches and the cover of the movie are deleted with it
+	var watchlist models.WatchlistPage
+	decode(t, serve(router, http.MethodGet, "/me/watchlist", nil), http.StatusOK, &watchlist)
+	if watchlist.Total != 1 || watchlist.Items[0].MovieID != speed.ID {
+		t.Errorf("watchlist = %+v", watchlist)
+	}
+	var history models.WatchPage
+	decode(t, serve(router, http.MethodGet, "/me/history", nil), http.StatusOK, &history)
+	if history.Total != 1 || history.Items[0].MovieID != speed.ID {
+		t.Errorf("history = %+v", history)
+	}
+	if _, err = storage.Covers.Load(context.Background(), coverName(heat.ID.Hex(), common.CoverOriginal)); err != storage.ErrNotExist {
+		t.Errorf("cover error = %v", err)
+	}
+
+	if err = repository.Insert(context.Background(), heat); err != nil {
+		t.Fatal(err)
+	}
+	var reviews models.ReviewPage
+	decode(t, serve(router, http.MethodGet, path+"/reviews", nil), http.StatusOK, &reviews)
+	if reviews.Total != 0 {
+		t.Errorf("reviews = %+v", reviews)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int    // status of the user service
+		body   string // response of the user service
+		want   int
+		code   int // code of the error, 0 when a token is returned
+	}{
+		{"token", http.StatusOK, `{"token": "access", "refreshToken": "refresh"}`, http.StatusOK, 0},
+		{"error relayed", http.StatusUnauthorized, `{"code": 14, "kind": "unauthorized", "message": "Invalid credentials"}`,
+			http.StatusUnauthorized, common.StatusUnauthorized},
+		{"token not JSON", http.StatusOK, "<html>Bad Gateway</html>", http.StatusServiceUnavailable, common.StatusUpstreamUnavailable},
+		{"token truncated", http.StatusOK, `{"token": "acc`, http.StatusServiceUnavailable, common.StatusUpstreamUnavailable},
+		{"error not JSON", http.StatusBadGateway, "Bad Gateway", http.StatusServiceUnavailable, common.StatusUpstreamUnavailable},
+		{"error empty", http.StatusInternalServerError, "", http.StatusServiceUnavailable, common.StatusUpstreamUnavailable},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/admin/auth" || r.PostFormValue("user") != "admin" || r.PostFormValue("password") != "secret" {
+					t.Errorf("request = %s %v", r.URL.Path, r.PostForm)
+				}
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer auth.Close()
+
+			gin.SetMode(gin.TestMode)
+			c := NewMovie(nil, nil, nil, nil, nil, func() *common.Configuration {
+				return &common.Configuration{AuthAddr: auth.URL}
+			})
+			router := gin.New()
+			router.Use(middlewares.Errors())
+			router.POST("/login", c.Login)
+
+			form := url.Values{"user": {"admin"}, "password": {"secret"}}
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if test.code == 0 {
+				var token models.Token
+				decode(t, w, test.want, &token)
+				if token.Token != "access" || token.RefreshToken != "refresh" {
+					t.Errorf("token = %+v", token)
+				}
+				return
+			}
+
+			var apiErr models.Error
+			decode(t, w, test.want, &apiErr)
+			if apiErr.Code != test.code {
+				t.Errorf("error = %+v, want code %d", apiErr, test.code)
+			}
+			if test.code == common.StatusUpstreamUnavailable && apiErr.Message != common.ErrAuthUnavailable {
+				t.Errorf("error = %+v, want %q", apiErr, common.ErrAuthUnavailable)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+/*
+ * @File: controllers.rating.go
+ * @Description: Implements Rating and Review API logic functions
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"../common"
+	"../daos"
+	"../middlewares"
+	"../models"
+	"../utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Rating manages the Ratings and the Reviews of the Movies
+type Rating struct {
+	utils     utils.Utils
+	ratingDAO daos.RatingRepository
+	movieDAO  daos.MovieRepository
+}
+
+// NewRating creates the Rating APIs storing the Ratings and the Movies in the given repositories
+func NewRating(movieDAO daos.MovieRepository, ratingDAO daos.RatingRepository) *Rating {
+	return &Rating{ratingDAO: ratingDAO, movieDAO: movieDAO}
+}
+
+// RateMovie godoc
+// @Summary Rate a movie
+// @Description Rate a movie from 1 to 5 with an optional review, a new rating replaces the previous one of the user
+// @Tags rating
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param id path string true "Movie ID"
+// @Param rating body models.AddRating true "Rating"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Rating
+// @Router /movies/{id}/ratings [post]
+func (r *Rating) RateMovie(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	if err := r.utils.ValidateObjectID(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var addRating models.AddRating
+	if err := ctx.ShouldBindJSON(&addRating); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	claims := userClaims(ctx)
+	if claims == nil || !checkMovie(ctx, r.movieDAO, id) {
+		return
+	}
+
+	movieID := utils.ObjectIDHex(id)
+	rating, err := r.ratingDAO.Upsert(ctx.Request.Context(), movieID, claims.Subject, claims.Name, addRating)
+	if err == nil {
+		err = r.updateMovieRating(ctx.Request.Context(), movieID)
+	}
+
+	if err == nil {
+		ctx.JSON(http.StatusOK, rating)
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// DeleteRating godoc
+// @Summary Delete a rating
+// @Description Delete the rating of a movie by the authenticated user
+// @Tags rating
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param id path string true "Movie ID"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Message
+// @Router /movies/{id}/ratings [delete]
+func (r *Rating) DeleteRating(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	if err := r.utils.ValidateObjectID(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	claims := userClaims(ctx)
+	if claims == nil {
+		return
+	}
+
+	movieID := utils.ObjectIDHex(id)
+	err := r.ratingDAO.Delete(ctx.Request.Context(), movieID, claims.Subject)
+	if err == mongo.ErrNoDocuments {
+		ctx.Error(models.NewError(models.KindNotFound, common.ErrRatingNotFound))
+		return
+	}
+	if err == nil {
+		err = r.updateMovieRating(ctx.Request.Context(), movieID)
+	}
+
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.Message{"Successfully"})
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// ListReviews godoc
+// @Summary List the reviews of a movie
+// @Description List a page of the reviews of a movie, the newest first by default. The reviews hidden by the admins are not listed.
+// @Tags rating
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Movie ID"
+// @Param limit query int false "Page size, 20 by default, 100 at most"
+// @Param after query string false "Cursor of the previous page"
+// @Param offset query int false "Number of reviews to skip, can't be used with after"
+// @Param sort query string false "Sort field: id, score, createdAt or updatedAt, prefixed by - for the descending order"
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.ReviewPage
+// @Router /movies/{id}/reviews [get]
+func (r *Rating) ListReviews(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	if err := r.utils.ValidateObjectID(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	query, ok := bindPageQuery(ctx, "-createdAt")
+	if !ok || !checkMovie(ctx, r.movieDAO, id) {
+		return
+	}
+
+	reviews, next, total, err := r.ratingDAO.GetReviews(ctx.Request.Context(), utils.ObjectIDHex(id), query)
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.ReviewPage{reviews, next, total})
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// ModerateReview godoc
+// @Summary Moderate a review
+// @Description Hide or show again a review of a movie, the rating still counts in the movie average
+// @Tags rating
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param id path string true "Movie ID"
+// @Param reviewId path string true "Review ID"
+// @Param moderation body models.ModerateReview true "Moderation"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Rating
+// @Router /movies/{id}/reviews/{reviewId} [patch]
+func (r *Rating) ModerateReview(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	reviewID := ctx.Params.ByName("reviewId")
+	for _, value := range []string{id, reviewID} {
+		if err := r.utils.ValidateObjectID(value); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	var moderateReview models.ModerateReview
+	if err := ctx.ShouldBindJSON(&moderateReview); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	claims := middlewares.Claims(ctx)
+	moderation := models.ReviewModeration{moderateReview.Hidden, moderateReview.Reason, claims.Name, time.Now()}
+	rating, err := r.ratingDAO.Moderate(ctx.Request.Context(), utils.ObjectIDHex(id), utils.ObjectIDHex(reviewID), moderation)
+	if err == nil {
+		ctx.JSON(http.StatusOK, rating)
+	} else if err == mongo.ErrNoDocuments {
+		ctx.Error(models.NewError(models.KindNotFound, common.ErrReviewNotFound))
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// updateMovieRating stores the average and the count of the ratings on the movie
+func (r *Rating) updateMovieRating(ctx context.Context, movieID models.ObjectID) error {
+	return r.movieDAO.UpdateRating(ctx, movieID)
+}
@@ -0,0 +1,136 @@
+/*
+ * @File: controllers.rating_test.go
+ * @Description: Tests the Rating API logic functions against an in-memory or a SQL repository
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"../models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRatings(t *testing.T) {
+	movie := models.Movie{ID: models.NewObjectID(), Name: "Heat", Year: 1995}
+	router, _ := newMovieRouter(t, movie)
+	path := "/movies/" + movie.ID.Hex()
+
+	var rating models.Rating
+	decode(t, serve(router, http.MethodPost, path+"/ratings", gin.H{"score": 4, "review": "Great"}), http.StatusOK, &rating)
+	if rating.ID.IsZero() || rating.UserID != testUserID || rating.Score != 4 {
+		t.Fatalf("rating = %+v", rating)
+	}
+	decode(t, serve(router, http.MethodPost, path+"/ratings", gin.H{"score": 6}), http.StatusBadRequest, nil)
+	decode(t, serve(router, http.MethodPost, "/movies/"+models.NewObjectID().Hex()+"/ratings", gin.H{"score": 4}), http.StatusNotFound, nil)
+
+	// Rating again replaces the rating of the user
+	var replaced models.Rating
+	decode(t, serve(router, http.MethodPost, path+"/ratings", gin.H{"score": 2}), http.StatusOK, &replaced)
+	if replaced.ID != rating.ID || replaced.Score != 2 || len(replaced.Review) > 0 {
+		t.Fatalf("replaced rating = %+v", replaced)
+	}
+
+	var found models.Movie
+	decode(t, serve(router, http.MethodGet, path, nil), http.StatusOK, &found)
+	if found.Rating != (models.MovieRating{2, 1}) {
+		t.Errorf("movie rating = %+v", found.Rating)
+	}
+
+	// The ratings without a review and the hidden reviews are not listed
+	var reviews models.ReviewPage
+	decode(t, serve(router, http.MethodGet, path+"/reviews", nil), http.StatusOK, &reviews)
+	if reviews.Total != 0 || len(reviews.Items) != 0 {
+		t.Errorf("reviews = %+v", reviews)
+	}
+	decode(t, serve(router, http.MethodPost, path+"/ratings", gin.H{"score": 5, "review": "Great"}), http.StatusOK, nil)
+	decode(t, serve(router, http.MethodGet, path+"/reviews", nil), http.StatusOK, &reviews)
+	if reviews.Total != 1 || reviews.Items[0].Review != "Great" {
+		t.Errorf("reviews = %+v", reviews)
+	}
+	decode(t, serve(router, http.MethodGet, path+"/reviews?sort=name", nil), http.StatusBadRequest, nil)
+
+	var moderated models.Rating
+	decode(t, serve(router, http.MethodPatch, path+"/reviews/"+rating.ID.Hex(), gin.H{"hidden": true, "reason": "spam"}), http.StatusOK, &moderated)
+	if moderated.Moderation == nil || !moderated.Moderation.Hidden || moderated.Moderation.ModeratedBy != "viewer" {
+		t.Errorf("moderated rating = %+v", moderated)
+	}
+	decode(t, serve(router, http.MethodPatch, path+"/reviews/"+models.NewObjectID().Hex(), gin.H{"hidden": true}), http.StatusNotFound, nil)
+	decode(t, serve(router, http.MethodGet, path+"/reviews", nil), http.StatusOK, &reviews)
+	if reviews.Total != 0 {
+		t.Errorf("reviews = %+v", reviews)
+	}
+
+	decode(t, serve(router, http.MethodDelete, path+"/ratings", nil), http.StatusOK, nil)
+	decode(t, serve(router, http.MethodDelete, path+"/ratings", nil), http.StatusNotFound, nil)
+	decode(t, serve(router, http.MethodGet, path, nil), http.StatusOK, &found)
+	if found.Rating != (models.MovieRating{}) {
+		t.Errorf("movie rating = %+v", found.Rating)
+	}
+}
+
+func TestConcurrentRatings(t *testing.T) {
+	movie := models.Movie{ID: models.NewObjectID(), Name: "Heat", Year: 1995}
+	router, _ := newMovieRouter(t, movie)
+	path := "/movies/" + movie.ID.Hex()
+
+	// rate sends the requests of the users concurrently, the score 0 deletes the rating
+	rate := func(scores map[string]int) {
+		var wait sync.WaitGroup
+		for userID, score := range scores {
+			wait.Add(1)
+			go func(userID string, score int) {
+				defer wait.Done()
+
+				req := httptest.NewRequest(http.MethodDelete, path+"/ratings", nil)
+				if score > 0 {
+					body, _ := json.Marshal(gin.H{"score": score})
+					req = httptest.NewRequest(http.MethodPost, path+"/ratings", bytes.NewReader(body))
+				}
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set(testUserHeader, userID)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					t.Errorf("status = %d: %s", w.Code, w.Body.String())
+				}
+			}(userID, score)
+		}
+		wait.Wait()
+	}
+
+	tests := []struct {
+		name   string
+		scores map[string]int
+		rating models.MovieRating
+	}{
+		{"new ratings", map[string]int{}, models.MovieRating{3, 20}},
+		{"replaced and deleted ratings", map[string]int{}, models.MovieRating{4.5, 10}},
+	}
+	for i := 0; i < 20; i++ {
+		userID := models.NewObjectID().Hex()
+		tests[0].scores[userID] = i%5 + 1
+		tests[1].scores[userID] = 5 - i%2*5
+		if i%4 == 0 {
+			tests[1].scores[userID] = 4
+		}
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rate(test.scores)
+
+			var found models.Movie
+			decode(t, serve(router, http.MethodGet, path, nil), http.StatusOK, &found)
+			if found.Rating != test.rating {
+				t.Errorf("movie rating = %+v, want %+v", found.Rating, test.rating)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+/*
+ * @File: controllers.watch.go
+ * @Description: Implements Watchlist and Watch History API logic functions
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"net/http"
+
+	"../common"
+	"../daos"
+	"../models"
+	"../utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Watch manages the Watchlists and the Watch Histories of the users
+type Watch struct {
+	utils        utils.Utils
+	watchlistDAO daos.WatchlistRepository
+	historyDAO   daos.HistoryRepository
+	movieDAO     daos.MovieRepository
+}
+
+// NewWatch creates the Watchlist and Watch History APIs storing them and the Movies in the given repositories
+func NewWatch(movieDAO daos.MovieRepository, watchlistDAO daos.WatchlistRepository, historyDAO daos.HistoryRepository) *Watch {
+	return &Watch{watchlistDAO: watchlistDAO, historyDAO: historyDAO, movieDAO: movieDAO}
+}
+
+// ListWatchlist godoc
+// @Summary List the watchlist
+// @Description List a page of the watchlist of the authenticated user, the latest added movies first by default
+// @Tags watch
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param limit query int false "Page size, 20 by default, 100 at most"
+// @Param after query string false "Cursor of the previous page"
+// @Param offset query int false "Number of movies to skip, can't be used with after"
+// @Param sort query string false "Sort field: id or addedAt, prefixed by - for the descending order"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.WatchlistPage
+// @Router /me/watchlist [get]
+func (w *Watch) ListWatchlist(ctx *gin.Context) {
+	claims := userClaims(ctx)
+	if claims == nil {
+		return
+	}
+
+	query, ok := bindPageQuery(ctx, "-addedAt")
+	if !ok {
+		return
+	}
+
+	items, next, total, err := w.watchlistDAO.GetPage(ctx.Request.Context(), claims.Subject, query)
+	if err == nil {
+		movieIDs := make([]models.ObjectID, len(items))
+		for i, item := range items {
+			movieIDs[i] = item.MovieID
+		}
+
+		var movies map[models.ObjectID]*models.Movie
+		movies, err = w.movieDAO.GetByIDs(ctx.Request.Context(), movieIDs)
+		for i := range items {
+			items[i].Movie = movies[items[i].MovieID]
+		}
+	}
+
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.WatchlistPage{items, next, total})
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// AddToWatchlist godoc
+// @Summary Add a movie to the watchlist
+// @Description Add a movie to the watchlist of the authenticated user
+// @Tags watch
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param item body models.AddWatchlistItem true "Movie"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.WatchlistItem
+// @Router /me/watchlist [post]
+func (w *Watch) AddToWatchlist(ctx *gin.Context) {
+	var addItem models.AddWatchlistItem
+	if err := ctx.ShouldBindJSON(&addItem); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	claims := userClaims(ctx)
+	if claims == nil || !checkMovie(ctx, w.movieDAO, addItem.MovieID.Hex()) {
+		return
+	}
+
+	item, err := w.watchlistDAO.Add(ctx.Request.Context(), claims.Subject, addItem.MovieID)
+	if err == nil {
+		ctx.JSON(http.StatusOK, item)
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// RemoveFromWatchlist godoc
+// @Summary Remove a movie from the watchlist
+// @Description Remove a movie from the watchlist of the authenticated user
+// @Tags watch
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param movieId path string true "Movie ID"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Message
+// @Router /me/watchlist/{movieId} [delete]
+func (w *Watch) RemoveFromWatchlist(ctx *gin.Context) {
+	movieID := ctx.Params.ByName("movieId")
+	if err := w.utils.ValidateObjectID(movieID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	claims := userClaims(ctx)
+	if claims == nil {
+		return
+	}
+
+	err := w.watchlistDAO.Remove(ctx.Request.Context(), claims.Subject, utils.ObjectIDHex(movieID))
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.Message{"Successfully"})
+	} else if err == mongo.ErrNoDocuments {
+		ctx.Error(models.NewError(models.KindNotFound, common.ErrWatchlistItemNotFound))
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// ListHistory godoc
+// @Summary List the watch history
+// @Description List a page of the watch history of the authenticated user, the latest watched movies first by default
+// @Tags watch
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param limit query int false "Page size, 20 by default, 100 at most"
+// @Param after query string false "Cursor of the previous page"
+// @Param offset query int false "Number of movies to skip, can't be used with after"
+// @Param sort query string false "Sort field: id or updatedAt, prefixed by - for the descending order"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.WatchPage
+// @Router /me/history [get]
+func (w *Watch) ListHistory(ctx *gin.Context) {
+	claims := userClaims(ctx)
+	if claims == nil {
+		return
+	}
+
+	query, ok := bindPageQuery(ctx, "-updatedAt")
+	if !ok {
+		return
+	}
+
+	watches, next, total, err := w.historyDAO.GetPage(ctx.Request.Context(), claims.Subject, query)
+	if err == nil {
+		movieIDs := make([]models.ObjectID, len(watches))
+		for i, watch := range watches {
+			movieIDs[i] = watch.MovieID
+		}
+
+		var movies map[models.ObjectID]*models.Movie
+		movies, err = w.movieDAO.GetByIDs(ctx.Request.Context(), movieIDs)
+		for i := range watches {
+			watches[i].Movie = movies[watches[i].MovieID]
+		}
+	}
+
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.WatchPage{watches, next, total})
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// GetProgress godoc
+// @Summary Get the playback progress of a movie
+// @Description Get the position where the authenticated user stopped watching a movie
+// @Tags watch
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param movieId path string true "Movie ID"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Watch
+// @Router /me/history/{movieId} [get]
+func (w *Watch) GetProgress(ctx *gin.Context) {
+	movieID := ctx.Params.ByName("movieId")
+	if err := w.utils.ValidateObjectID(movieID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	claims := userClaims(ctx)
+	if claims == nil {
+		return
+	}
+
+	watch, err := w.historyDAO.Get(ctx.Request.Context(), claims.Subject, utils.ObjectIDHex(movieID))
+	if err == nil {
+		ctx.JSON(http.StatusOK, watch)
+	} else if err == mongo.ErrNoDocuments {
+		ctx.Error(models.NewError(models.KindNotFound, common.ErrWatchNotFound))
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// SaveProgress godoc
+// @Summary Save the playback progress of a movie
+// @Description Save the position where the authenticated user is watching a movie, the movie is completed when the position reaches 95% of the duration
+// @Tags watch
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param movieId path string true "Movie ID"
+// @Param progress body models.WatchProgress true "Progress"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Watch
+// @Router /me/history/{movieId} [put]
+func (w *Watch) SaveProgress(ctx *gin.Context) {
+	movieID := ctx.Params.ByName("movieId")
+	if err := w.utils.ValidateObjectID(movieID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var progress models.WatchProgress
+	if err := ctx.ShouldBindJSON(&progress); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	if err := progress.Validate(); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	claims := userClaims(ctx)
+	if claims == nil || !checkMovie(ctx, w.movieDAO, movieID) {
+		return
+	}
+
+	watch, err := w.historyDAO.Save(ctx.Request.Context(), claims.Subject, utils.ObjectIDHex(movieID), progress)
+	if err == nil {
+		ctx.JSON(http.StatusOK, watch)
+	} else {
+		ctx.Error(err)
+	}
+}
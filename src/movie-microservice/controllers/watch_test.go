@@ -0,0 +1,94 @@
+/*
+ * @File: controllers.watch_test.go
+ * @Description: Tests the Watchlist and Watch History API logic functions against an in-memory or a SQL repository
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"../models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestWatchlist(t *testing.T) {
+	movies := []models.Movie{
+		{ID: models.NewObjectID(), Name: "Heat"},
+		{ID: models.NewObjectID(), Name: "Ronin"},
+		{ID: models.NewObjectID(), Name: "Speed"},
+	}
+	router, _ := newMovieRouter(t, movies...)
+
+	var item models.WatchlistItem
+	for _, movie := range movies {
+		decode(t, serve(router, http.MethodPost, "/me/watchlist", gin.H{"movieId": movie.ID}), http.StatusOK, &item)
+	}
+	decode(t, serve(router, http.MethodPost, "/me/watchlist", gin.H{"movieId": models.NewObjectID()}), http.StatusNotFound, nil)
+
+	// Adding a movie again keeps the first item
+	var again models.WatchlistItem
+	decode(t, serve(router, http.MethodPost, "/me/watchlist", gin.H{"movieId": movies[2].ID}), http.StatusOK, &again)
+	if again.ID != item.ID || !again.AddedAt.Equal(item.AddedAt) {
+		t.Errorf("item = %+v, want %+v", again, item)
+	}
+
+	// The pages follow each other with their cursors
+	found := make(map[models.ObjectID]bool)
+	var page models.WatchlistPage
+	decode(t, serve(router, http.MethodGet, "/me/watchlist?limit=2", nil), http.StatusOK, &page)
+	if page.Total != 3 || len(page.Items) != 2 || len(page.NextCursor) == 0 {
+		t.Fatalf("first page = %+v", page)
+	}
+	for _, item := range page.Items {
+		found[item.MovieID] = item.Movie != nil
+	}
+	decode(t, serve(router, http.MethodGet, "/me/watchlist?limit=2&after="+page.NextCursor, nil), http.StatusOK, &page)
+	if len(page.Items) != 1 || len(page.NextCursor) > 0 {
+		t.Fatalf("last page = %+v", page)
+	}
+	found[page.Items[0].MovieID] = page.Items[0].Movie != nil
+	for _, movie := range movies {
+		if !found[movie.ID] {
+			t.Errorf("movie %s is not listed with its movie", movie.Name)
+		}
+	}
+
+	decode(t, serve(router, http.MethodDelete, "/me/watchlist/"+movies[0].ID.Hex(), nil), http.StatusOK, nil)
+	decode(t, serve(router, http.MethodDelete, "/me/watchlist/"+movies[0].ID.Hex(), nil), http.StatusNotFound, nil)
+	decode(t, serve(router, http.MethodGet, "/me/watchlist", nil), http.StatusOK, &page)
+	if page.Total != 2 {
+		t.Errorf("page = %+v", page)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	heat := models.Movie{ID: models.NewObjectID(), Name: "Heat"}
+	router, _ := newMovieRouter(t, heat)
+	path := "/me/history/" + heat.ID.Hex()
+
+	decode(t, serve(router, http.MethodGet, path, nil), http.StatusNotFound, nil)
+	decode(t, serve(router, http.MethodPut, path, gin.H{"position": 8000, "duration": 7200}), http.StatusBadRequest, nil)
+	decode(t, serve(router, http.MethodPut, "/me/history/"+models.NewObjectID().Hex(), gin.H{"position": 60}), http.StatusNotFound, nil)
+
+	var watch models.Watch
+	decode(t, serve(router, http.MethodPut, path, gin.H{"position": 60, "duration": 7200}), http.StatusOK, &watch)
+	if watch.Position != 60 || watch.Completed {
+		t.Fatalf("watch = %+v", watch)
+	}
+
+	// Saving the progress again updates it, the end of the movie completes it
+	var saved models.Watch
+	decode(t, serve(router, http.MethodPut, path, gin.H{"position": 7000, "duration": 7200}), http.StatusOK, &saved)
+	decode(t, serve(router, http.MethodGet, path, nil), http.StatusOK, &watch)
+	if watch.ID != saved.ID || watch.Position != 7000 || !watch.Completed {
+		t.Errorf("watch = %+v", watch)
+	}
+
+	var page models.WatchPage
+	decode(t, serve(router, http.MethodGet, "/me/history", nil), http.StatusOK, &page)
+	if page.Total != 1 || page.Items[0].Movie == nil || page.Items[0].Movie.Name != "Heat" {
+		t.Errorf("page = %+v", page)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+ * @File: daos.genre.go
+ * @Description: Implements Movie Genre CRUD functions for MongoDB
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"regexp"
+
+	"../common"
+	"../databases"
+	"../models"
+	"../utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Genre manages Movie Genre CRUD
+type Genre struct {
+	utils *utils.Utils
+}
+
+// GetAll gets the list of Genres
+func (g *Genre) GetAll(ctx context.Context) ([]models.MovieGenre, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColGenres)
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var genres []models.MovieGenre
+	err = cursor.All(ctx, &genres)
+	return genres, err
+}
+
+// GetByID finds a Genre by its id
+func (g *Genre) GetByID(ctx context.Context, id string) (models.MovieGenre, error) {
+	err := g.utils.ValidateObjectID(id)
+	if err != nil {
+		return models.MovieGenre{}, err
+	}
+
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColGenres)
+
+	var genre models.MovieGenre
+	err = collection.FindOne(ctx, bson.M{"_id": utils.ObjectIDHex(id)}).Decode(&genre)
+	return genre, err
+}
+
+// GetByName finds a Genre by its name, ignoring the case
+func (g *Genre) GetByName(ctx context.Context, name string) (models.MovieGenre, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColGenres)
+
+	var genre models.MovieGenre
+	err := collection.FindOne(ctx, bson.M{"name": primitive.Regex{"^" + regexp.QuoteMeta(name) + "$", "i"}}).Decode(&genre)
+	return genre, err
+}
+
+// Exist checks if all the given Genres exist
+func (g *Genre) Exist(ctx context.Context, ids []models.ObjectID) (bool, error) {
+	unique := make(map[models.ObjectID]bool)
+	for _, id := range ids {
+		unique[id] = true
+	}
+	if len(unique) == 0 {
+		return true, nil
+	}
+
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColGenres)
+
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	return int(count) == len(unique), err
+}
+
+// Insert adds a new Genre into database
+func (g *Genre) Insert(ctx context.Context, genre models.MovieGenre) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColGenres)
+
+	_, err := collection.InsertOne(ctx, &genre)
+	return err
+}
+
+// Update modifies an existing Genre
+func (g *Genre) Update(ctx context.Context, genre models.MovieGenre) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColGenres)
+
+	return updated(collection.ReplaceOne(ctx, bson.M{"_id": genre.ID}, &genre))
+}
+
+// DeleteByID removes a Genre by its id
+func (g *Genre) DeleteByID(ctx context.Context, id string) error {
+	err := g.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColGenres)
+
+	return deleted(collection.DeleteOne(ctx, bson.M{"_id": utils.ObjectIDHex(id)}))
+}
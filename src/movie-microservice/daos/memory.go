@@ -0,0 +1,132 @@
+/*
+ * @File: daos.memory.go
+ * @Description: Implements the pagination of the in-memory repositories
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"time"
+
+	"../models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memorySortKey returns the id of the i-th item of a page and the value of its given sort field,
+// a string, an int or a time
+type memorySortKey func(i int, field string) (models.ObjectID, interface{})
+
+// memoryPage pages the items of an in-memory repository like findPage does, the items are sorted by the
+// requested field then by id. The indexes of the items of the page are returned with one more index
+// when there is a next page, and the field of the cursor of the next page.
+func memoryPage(count int, key memorySortKey, query models.PageQuery, sortFields []string) ([]int, string, error) {
+	field, descending := query.Sort, strings.HasPrefix(query.Sort, "-")
+	if descending {
+		field = field[1:]
+	}
+	switch {
+	case len(field) == 0 || field == "id":
+		field = "_id"
+	case !containsString(sortFields, field):
+		return nil, "", ErrPageSortInvalid
+	}
+
+	// compare orders the i-th item and the item of the given id and sort value, in the requested order
+	compare := func(i int, id models.ObjectID, value interface{}) int {
+		itemID, itemValue := key(i, field)
+		result := 0
+		if field != "_id" {
+			result = compareSortValues(itemValue, value)
+		}
+		if result == 0 {
+			result = compareIDs(itemID, id)
+		}
+		if descending {
+			return -result
+		}
+		return result
+	}
+
+	indexes := make([]int, count)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.Slice(indexes, func(a, b int) bool {
+		id, value := key(indexes[b], field)
+		return compare(indexes[a], id, value) < 0
+	})
+
+	if len(query.After) > 0 {
+		cursor, err := decodePageCursor(query.After)
+		if err != nil {
+			return nil, "", err
+		}
+
+		start := sort.Search(len(indexes), func(k int) bool {
+			return compare(indexes[k], cursor.ID, cursor.Value) > 0
+		})
+		indexes = indexes[start:]
+	}
+
+	if query.Offset >= len(indexes) {
+		return []int{}, field, nil
+	}
+	indexes = indexes[query.Offset:]
+
+	// Keep one more item to know if there is a next page
+	if len(indexes) > query.Limit+1 {
+		indexes = indexes[:query.Limit+1]
+	}
+
+	return indexes, field, nil
+}
+
+// compareSortValues orders two values of a sort field, the values of the page cursors are in their bson form
+func compareSortValues(a interface{}, b interface{}) int {
+	a, b = sortValue(a), sortValue(b)
+	switch x := a.(type) {
+	case string:
+		y, _ := b.(string)
+		return strings.Compare(x, y)
+	case int64:
+		y, _ := b.(int64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// sortValue returns the numbers and the times as int64, the times are truncated to milliseconds like bson does
+func sortValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case time.Time:
+		return int64(primitive.NewDateTimeFromTime(v))
+	case primitive.DateTime:
+		return int64(v)
+	}
+
+	return value
+}
+
+// compareIDs orders two ids like MongoDB does
+func compareIDs(a models.ObjectID, b models.ObjectID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// duplicateIDError returns the error of MongoDB for an id already used
+func duplicateIDError(id models.ObjectID) error {
+	return mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "duplicate key error: " + id.Hex()}}}
+}
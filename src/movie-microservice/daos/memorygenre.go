@@ -0,0 +1,153 @@
+/*
+ * @File: daos.memorygenre.go
+ * @Description: Implements Movie Genre CRUD functions in memory
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"../models"
+	"../utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryGenre manages Movie Genre CRUD in memory, it's safe for concurrent use
+type MemoryGenre struct {
+	utils  *utils.Utils
+	mutex  sync.RWMutex
+	genres map[models.ObjectID]models.MovieGenre
+}
+
+// NewMemoryGenre creates an empty in-memory GenreRepository
+func NewMemoryGenre() *MemoryGenre {
+	return &MemoryGenre{genres: make(map[models.ObjectID]models.MovieGenre)}
+}
+
+// names returns the names of the Genres by id
+func (g *MemoryGenre) names() map[models.ObjectID]string {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	names := make(map[models.ObjectID]string, len(g.genres))
+	for id, genre := range g.genres {
+		names[id] = genre.Name
+	}
+
+	return names
+}
+
+// GetAll gets the list of Genres
+func (g *MemoryGenre) GetAll(ctx context.Context) ([]models.MovieGenre, error) {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	genres := []models.MovieGenre{}
+	for _, genre := range g.genres {
+		genres = append(genres, genre)
+	}
+
+	sort.Slice(genres, func(i, j int) bool {
+		if genres[i].Name != genres[j].Name {
+			return genres[i].Name < genres[j].Name
+		}
+		return compareIDs(genres[i].ID, genres[j].ID) < 0
+	})
+	return genres, nil
+}
+
+// GetByID finds a Genre by its id
+func (g *MemoryGenre) GetByID(ctx context.Context, id string) (models.MovieGenre, error) {
+	err := g.utils.ValidateObjectID(id)
+	if err != nil {
+		return models.MovieGenre{}, err
+	}
+
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	genre, ok := g.genres[utils.ObjectIDHex(id)]
+	if !ok {
+		return models.MovieGenre{}, mongo.ErrNoDocuments
+	}
+	return genre, nil
+}
+
+// GetByName finds a Genre by its name, ignoring the case
+func (g *MemoryGenre) GetByName(ctx context.Context, name string) (models.MovieGenre, error) {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	var found *models.MovieGenre
+	for _, genre := range g.genres {
+		if strings.EqualFold(genre.Name, name) && (found == nil || compareIDs(genre.ID, found.ID) < 0) {
+			genre := genre
+			found = &genre
+		}
+	}
+
+	if found == nil {
+		return models.MovieGenre{}, mongo.ErrNoDocuments
+	}
+	return *found, nil
+}
+
+// Exist checks if all the given Genres exist
+func (g *MemoryGenre) Exist(ctx context.Context, ids []models.ObjectID) (bool, error) {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	for _, id := range ids {
+		if _, ok := g.genres[id]; !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// Insert adds a new Genre
+func (g *MemoryGenre) Insert(ctx context.Context, genre models.MovieGenre) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if _, ok := g.genres[genre.ID]; ok {
+		return duplicateIDError(genre.ID)
+	}
+	g.genres[genre.ID] = genre
+	return nil
+}
+
+// Update modifies an existing Genre
+func (g *MemoryGenre) Update(ctx context.Context, genre models.MovieGenre) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if _, ok := g.genres[genre.ID]; !ok {
+		return mongo.ErrNoDocuments
+	}
+	g.genres[genre.ID] = genre
+	return nil
+}
+
+// DeleteByID removes a Genre by its id
+func (g *MemoryGenre) DeleteByID(ctx context.Context, id string) error {
+	err := g.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	genreID := utils.ObjectIDHex(id)
+	if _, ok := g.genres[genreID]; !ok {
+		return mongo.ErrNoDocuments
+	}
+	delete(g.genres, genreID)
+	return nil
+}
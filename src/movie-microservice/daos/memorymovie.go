@@ -0,0 +1,299 @@
+/*
+ * @File: daos.memorymovie.go
+ * @Description: Implements Movie CRUD functions in memory
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"../models"
+	"../utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryMovie manages Movie CRUD in memory, it's safe for concurrent use
+type MemoryMovie struct {
+	utils   *utils.Utils
+	genres  *MemoryGenre
+	ratings *MemoryRating
+	mutex   sync.RWMutex
+	movies  map[models.ObjectID]models.Movie
+}
+
+// NewMemoryMovie creates an empty in-memory MovieRepository, the names of the facets
+// of the searches are the ones of the given Genres and the ratings of the Movies are
+// the aggregates of the given Ratings
+func NewMemoryMovie(genres *MemoryGenre, ratings *MemoryRating) *MemoryMovie {
+	return &MemoryMovie{genres: genres, ratings: ratings, movies: make(map[models.ObjectID]models.Movie)}
+}
+
+// copyMovie returns a copy of a Movie sharing nothing with it, the Movies are copied in
+// and out of the repository like they are by a database
+func copyMovie(movie models.Movie) models.Movie {
+	if movie.Genres != nil {
+		movie.Genres = append([]models.ObjectID{}, movie.Genres...)
+	}
+	if movie.Cover != nil {
+		cover := *movie.Cover
+		movie.Cover = &cover
+	}
+
+	return movie
+}
+
+// matchMovie checks if a Movie is selected by the filter, like movieQuery does
+func matchMovie(movie models.Movie, filter models.MovieFilter) bool {
+	if len(filter.Name) > 0 && !strings.Contains(strings.ToLower(movie.Name), strings.ToLower(filter.Name)) {
+		return false
+	}
+	if !filter.Genre.IsZero() && !hasGenre(movie, filter.Genre) {
+		return false
+	}
+
+	return filter.Year <= 0 || movie.Year == filter.Year
+}
+
+func hasGenre(movie models.Movie, genreID models.ObjectID) bool {
+	for _, genre := range movie.Genres {
+		if genre == genreID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// find returns the Movies matching the filter in the order of their ids
+func (m *MemoryMovie) find(filter models.MovieFilter) []models.Movie {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	movies := []models.Movie{}
+	for _, movie := range m.movies {
+		if matchMovie(movie, filter) {
+			movies = append(movies, copyMovie(movie))
+		}
+	}
+
+	sort.Slice(movies, func(i, j int) bool {
+		return compareIDs(movies[i].ID, movies[j].ID) < 0
+	})
+	return movies
+}
+
+// modify modifies an existing Movie
+func (m *MemoryMovie) modify(id models.ObjectID, modify func(movie *models.Movie)) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	movie, ok := m.movies[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+
+	// The stored Movie is never modified, its genres and cover may be shared with a reader
+	movie = copyMovie(movie)
+	modify(&movie)
+	m.movies[id] = movie
+	return nil
+}
+
+// GetByIDs finds the existing Movies of the given ids
+func (m *MemoryMovie) GetByIDs(ctx context.Context, ids []models.ObjectID) (map[models.ObjectID]*models.Movie, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	movies := make(map[models.ObjectID]*models.Movie)
+	for _, id := range ids {
+		if movie, ok := m.movies[id]; ok {
+			movie = copyMovie(movie)
+			movies[id] = &movie
+		}
+	}
+
+	return movies, nil
+}
+
+// GetPage gets a page of the Movies matching the filter, with the cursor of the next page and the total count
+func (m *MemoryMovie) GetPage(ctx context.Context, filter models.MovieFilter, query models.PageQuery) ([]models.Movie, string, int, error) {
+	movies := m.find(filter)
+	indexes, field, err := memoryPage(len(movies), func(i int, field string) (models.ObjectID, interface{}) {
+		return movies[i].ID, movies[i].Name
+	}, query, movieSortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	page := make([]models.Movie, len(indexes))
+	for k, i := range indexes {
+		page[k] = movies[i]
+	}
+
+	var next string
+	if len(page) > query.Limit {
+		page = page[:query.Limit]
+		next, err = nextPageCursor(page[len(page)-1], field)
+	}
+
+	return page, next, len(movies), err
+}
+
+// GetByID finds a Movie by its id
+func (m *MemoryMovie) GetByID(ctx context.Context, id string) (models.Movie, error) {
+	err := m.utils.ValidateObjectID(id)
+	if err != nil {
+		return models.Movie{}, err
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	movie, ok := m.movies[utils.ObjectIDHex(id)]
+	if !ok {
+		return models.Movie{}, mongo.ErrNoDocuments
+	}
+	return copyMovie(movie), nil
+}
+
+// DeleteByID removes a Movie by its id
+func (m *MemoryMovie) DeleteByID(ctx context.Context, id string) error {
+	err := m.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	return m.Delete(ctx, models.Movie{ID: utils.ObjectIDHex(id)})
+}
+
+// Insert adds a new Movie
+func (m *MemoryMovie) Insert(ctx context.Context, movie models.Movie) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.movies[movie.ID]; ok {
+		return duplicateIDError(movie.ID)
+	}
+	m.movies[movie.ID] = copyMovie(movie)
+	return nil
+}
+
+// Delete remove an existing Movie
+func (m *MemoryMovie) Delete(ctx context.Context, movie models.Movie) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.movies[movie.ID]; !ok {
+		return mongo.ErrNoDocuments
+	}
+	delete(m.movies, movie.ID)
+	return nil
+}
+
+// Update modifies an existing Movie
+func (m *MemoryMovie) Update(ctx context.Context, movie models.Movie) error {
+	return m.modify(movie.ID, func(stored *models.Movie) {
+		*stored = copyMovie(movie)
+	})
+}
+
+// UpdateFields modifies the given fields of an existing Movie
+func (m *MemoryMovie) UpdateFields(ctx context.Context, id string, update models.UpdateMovie) error {
+	err := m.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	return m.modify(utils.ObjectIDHex(id), func(movie *models.Movie) {
+		if update.Name != nil {
+			movie.Name = *update.Name
+		}
+		if update.URL != nil {
+			movie.URL = *update.URL
+		}
+		if update.CoverImage != nil {
+			movie.CoverImage = *update.CoverImage
+		}
+		if update.Description != nil {
+			movie.Description = *update.Description
+		}
+		if update.Year != nil {
+			movie.Year = *update.Year
+		}
+		if update.Genres != nil {
+			movie.Genres = append([]models.ObjectID{}, *update.Genres...)
+		}
+	})
+}
+
+// Search finds the Movies matching the text search and the filter, the best matches come first.
+// The Movies are scored like searchMovies does.
+func (m *MemoryMovie) Search(ctx context.Context, text string, filter models.MovieFilter, limit int, offset int) ([]models.MovieSearchHit, int, error) {
+	hits := searchMovies(m.utils, text, m.find(filter))
+	return pageHits(hits, limit, offset), len(hits), nil
+}
+
+// SearchFacets counts the Movies matching the text search and the filter per Genre and per year
+func (m *MemoryMovie) SearchFacets(ctx context.Context, text string, filter models.MovieFilter) (models.MovieFacets, error) {
+	hits := searchMovies(m.utils, text, m.find(filter))
+	return countFacets(hits, m.genres.names()), nil
+}
+
+// SetCover stores the description of the cover image of a Movie, its cover image
+// url becomes the url of the cover API
+func (m *MemoryMovie) SetCover(ctx context.Context, id string, cover models.MovieCover) error {
+	err := m.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	return m.modify(utils.ObjectIDHex(id), func(movie *models.Movie) {
+		movie.Cover = &cover
+		movie.CoverImage = "/api/v1/movies/" + id + "/cover"
+	})
+}
+
+// UpdateRating stores the aggregate of the Ratings of a Movie, it's computed while the Movies
+// are locked so that a concurrent update can't store an older aggregate
+func (m *MemoryMovie) UpdateRating(ctx context.Context, id models.ObjectID) error {
+	err := m.modify(id, func(movie *models.Movie) {
+		movie.Rating = m.ratings.aggregate(id)
+	})
+	if err == mongo.ErrNoDocuments {
+		// The Movie has been deleted meanwhile
+		err = nil
+	}
+	return err
+}
+
+// CountByGenre counts the Movies having the given Genre
+func (m *MemoryMovie) CountByGenre(ctx context.Context, genreID models.ObjectID) (int, error) {
+	return len(m.find(models.MovieFilter{Genre: genreID})), nil
+}
+
+// RemoveGenre removes the given Genre from all Movies
+func (m *MemoryMovie) RemoveGenre(ctx context.Context, genreID models.ObjectID) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for id, movie := range m.movies {
+		if !hasGenre(movie, genreID) {
+			continue
+		}
+
+		genres := []models.ObjectID{}
+		for _, genre := range movie.Genres {
+			if genre != genreID {
+				genres = append(genres, genre)
+			}
+		}
+		movie.Genres = genres
+		m.movies[id] = movie
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+/*
+ * @File: daos.memoryrating.go
+ * @Description: Implements Rating CRUD functions in memory
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"../models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryRating manages Rating CRUD in memory, it's safe for concurrent use
+type MemoryRating struct {
+	mutex   sync.RWMutex
+	ratings map[models.ObjectID]models.Rating
+}
+
+// NewMemoryRating creates an empty in-memory RatingRepository
+func NewMemoryRating() *MemoryRating {
+	return &MemoryRating{ratings: make(map[models.ObjectID]models.Rating)}
+}
+
+// copyRating returns a copy of a Rating sharing nothing with it
+func copyRating(rating models.Rating) models.Rating {
+	if rating.Moderation != nil {
+		moderation := *rating.Moderation
+		rating.Moderation = &moderation
+	}
+
+	return rating
+}
+
+// find finds the Rating of a Movie by a user, the mutex must be held
+func (r *MemoryRating) find(movieID models.ObjectID, userID string) (models.Rating, bool) {
+	for _, rating := range r.ratings {
+		if rating.MovieID == movieID && rating.UserID == userID {
+			return rating, true
+		}
+	}
+
+	return models.Rating{}, false
+}
+
+// Upsert adds or replaces the Rating of a Movie by a user, a user has one Rating per Movie
+func (r *MemoryRating) Upsert(ctx context.Context, movieID models.ObjectID, userID string, userName string, addRating models.AddRating) (models.Rating, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	rating, ok := r.find(movieID, userID)
+	if !ok {
+		rating = models.Rating{ID: models.NewObjectID(), MovieID: movieID, UserID: userID, CreatedAt: now}
+	}
+	rating.UserName, rating.Score, rating.Review, rating.UpdatedAt = userName, addRating.Score, addRating.Review, now
+
+	r.ratings[rating.ID] = rating
+	return copyRating(rating), nil
+}
+
+// Delete removes the Rating of a Movie by a user
+func (r *MemoryRating) Delete(ctx context.Context, movieID models.ObjectID, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	rating, ok := r.find(movieID, userID)
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	delete(r.ratings, rating.ID)
+	return nil
+}
+
+// DeleteByMovie removes all Ratings of a Movie
+func (r *MemoryRating) DeleteByMovie(ctx context.Context, movieID models.ObjectID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, rating := range r.ratings {
+		if rating.MovieID == movieID {
+			delete(r.ratings, id)
+		}
+	}
+
+	return nil
+}
+
+// GetReviews gets a page of the visible reviews of a Movie, with the cursor of the next page and the total count
+func (r *MemoryRating) GetReviews(ctx context.Context, movieID models.ObjectID, query models.PageQuery) ([]models.Rating, string, int, error) {
+	r.mutex.RLock()
+	ratings := []models.Rating{}
+	for _, rating := range r.ratings {
+		hidden := rating.Moderation != nil && rating.Moderation.Hidden
+		if rating.MovieID == movieID && len(rating.Review) > 0 && !hidden {
+			ratings = append(ratings, copyRating(rating))
+		}
+	}
+	r.mutex.RUnlock()
+
+	indexes, field, err := memoryPage(len(ratings), func(i int, field string) (models.ObjectID, interface{}) {
+		switch field {
+		case "score":
+			return ratings[i].ID, ratings[i].Score
+		case "createdAt":
+			return ratings[i].ID, ratings[i].CreatedAt
+		default:
+			return ratings[i].ID, ratings[i].UpdatedAt
+		}
+	}, query, reviewSortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	page := make([]models.Rating, len(indexes))
+	for k, i := range indexes {
+		page[k] = ratings[i]
+	}
+
+	var next string
+	if len(page) > query.Limit {
+		page = page[:query.Limit]
+		next, err = nextPageCursor(page[len(page)-1], field)
+	}
+
+	return page, next, len(ratings), err
+}
+
+// Moderate sets the moderation of a review of a Movie
+func (r *MemoryRating) Moderate(ctx context.Context, movieID models.ObjectID, id models.ObjectID, moderation models.ReviewModeration) (models.Rating, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	rating, ok := r.ratings[id]
+	if !ok || rating.MovieID != movieID {
+		return models.Rating{}, mongo.ErrNoDocuments
+	}
+	rating.Moderation = &moderation
+
+	r.ratings[id] = rating
+	return copyRating(rating), nil
+}
+
+// aggregate computes the average and the count of the Ratings of a Movie
+func (r *MemoryRating) aggregate(movieID models.ObjectID) models.MovieRating {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var rating models.MovieRating
+	total := 0
+	for _, other := range r.ratings {
+		if other.MovieID == movieID {
+			rating.Count++
+			total += other.Score
+		}
+	}
+
+	if rating.Count > 0 {
+		rating.Average = math.Round(float64(total)/float64(rating.Count)*100) / 100
+	}
+	return rating
+}
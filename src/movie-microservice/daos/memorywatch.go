@@ -0,0 +1,205 @@
+/*
+ * @File: daos.memorywatch.go
+ * @Description: Implements Watchlist and Watch History CRUD functions in memory
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"../models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryWatchlist manages the Watchlists of the users in memory, it's safe for concurrent use
+type MemoryWatchlist struct {
+	mutex sync.RWMutex
+	items map[models.ObjectID]models.WatchlistItem
+}
+
+// NewMemoryWatchlist creates an empty in-memory WatchlistRepository
+func NewMemoryWatchlist() *MemoryWatchlist {
+	return &MemoryWatchlist{items: make(map[models.ObjectID]models.WatchlistItem)}
+}
+
+// find finds the item of a Movie in the Watchlist of a user, the mutex must be held
+func (w *MemoryWatchlist) find(userID string, movieID models.ObjectID) (models.WatchlistItem, bool) {
+	for _, item := range w.items {
+		if item.UserID == userID && item.MovieID == movieID {
+			return item, true
+		}
+	}
+
+	return models.WatchlistItem{}, false
+}
+
+// Add adds a Movie to the Watchlist of a user, adding it again keeps the first one
+func (w *MemoryWatchlist) Add(ctx context.Context, userID string, movieID models.ObjectID) (models.WatchlistItem, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	item, ok := w.find(userID, movieID)
+	if !ok {
+		item = models.WatchlistItem{ID: models.NewObjectID(), UserID: userID, MovieID: movieID, AddedAt: time.Now()}
+		w.items[item.ID] = item
+	}
+
+	return item, nil
+}
+
+// Remove removes a Movie from the Watchlist of a user
+func (w *MemoryWatchlist) Remove(ctx context.Context, userID string, movieID models.ObjectID) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	item, ok := w.find(userID, movieID)
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	delete(w.items, item.ID)
+	return nil
+}
+
+// GetPage gets a page of the Watchlist of a user, with the cursor of the next page and the total count
+func (w *MemoryWatchlist) GetPage(ctx context.Context, userID string, query models.PageQuery) ([]models.WatchlistItem, string, int, error) {
+	w.mutex.RLock()
+	items := []models.WatchlistItem{}
+	for _, item := range w.items {
+		if item.UserID == userID {
+			items = append(items, item)
+		}
+	}
+	w.mutex.RUnlock()
+
+	indexes, field, err := memoryPage(len(items), func(i int, field string) (models.ObjectID, interface{}) {
+		return items[i].ID, items[i].AddedAt
+	}, query, watchlistSortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	page := make([]models.WatchlistItem, len(indexes))
+	for k, i := range indexes {
+		page[k] = items[i]
+	}
+
+	var next string
+	if len(page) > query.Limit {
+		page = page[:query.Limit]
+		next, err = nextPageCursor(page[len(page)-1], field)
+	}
+
+	return page, next, len(items), err
+}
+
+// DeleteByMovie removes a Movie from all Watchlists
+func (w *MemoryWatchlist) DeleteByMovie(ctx context.Context, movieID models.ObjectID) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for id, item := range w.items {
+		if item.MovieID == movieID {
+			delete(w.items, id)
+		}
+	}
+
+	return nil
+}
+
+// MemoryHistory manages the Watch History of the users in memory, it's safe for concurrent use
+type MemoryHistory struct {
+	mutex   sync.RWMutex
+	watches map[models.ObjectID]models.Watch
+}
+
+// NewMemoryHistory creates an empty in-memory HistoryRepository
+func NewMemoryHistory() *MemoryHistory {
+	return &MemoryHistory{watches: make(map[models.ObjectID]models.Watch)}
+}
+
+// find finds the playback progress of a Movie by a user, the mutex must be held
+func (h *MemoryHistory) find(userID string, movieID models.ObjectID) (models.Watch, bool) {
+	for _, watch := range h.watches {
+		if watch.UserID == userID && watch.MovieID == movieID {
+			return watch, true
+		}
+	}
+
+	return models.Watch{}, false
+}
+
+// Save stores the playback progress of a Movie by a user
+func (h *MemoryHistory) Save(ctx context.Context, userID string, movieID models.ObjectID, progress models.WatchProgress) (models.Watch, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	watch, ok := h.find(userID, movieID)
+	if !ok {
+		watch = models.Watch{ID: models.NewObjectID(), UserID: userID, MovieID: movieID}
+	}
+	watch.Position, watch.Duration, watch.Completed, watch.UpdatedAt = progress.Position, progress.Duration, progress.Completed, time.Now()
+
+	h.watches[watch.ID] = watch
+	return watch, nil
+}
+
+// Get finds the playback progress of a Movie by a user
+func (h *MemoryHistory) Get(ctx context.Context, userID string, movieID models.ObjectID) (models.Watch, error) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	watch, ok := h.find(userID, movieID)
+	if !ok {
+		return models.Watch{}, mongo.ErrNoDocuments
+	}
+	return watch, nil
+}
+
+// GetPage gets a page of the Watch History of a user, with the cursor of the next page and the total count
+func (h *MemoryHistory) GetPage(ctx context.Context, userID string, query models.PageQuery) ([]models.Watch, string, int, error) {
+	h.mutex.RLock()
+	watches := []models.Watch{}
+	for _, watch := range h.watches {
+		if watch.UserID == userID {
+			watches = append(watches, watch)
+		}
+	}
+	h.mutex.RUnlock()
+
+	indexes, field, err := memoryPage(len(watches), func(i int, field string) (models.ObjectID, interface{}) {
+		return watches[i].ID, watches[i].UpdatedAt
+	}, query, historySortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	page := make([]models.Watch, len(indexes))
+	for k, i := range indexes {
+		page[k] = watches[i]
+	}
+
+	var next string
+	if len(page) > query.Limit {
+		page = page[:query.Limit]
+		next, err = nextPageCursor(page[len(page)-1], field)
+	}
+
+	return page, next, len(watches), err
+}
+
+// DeleteByMovie removes a Movie from all Watch Histories
+func (h *MemoryHistory) DeleteByMovie(ctx context.Context, movieID models.ObjectID) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for id, watch := range h.watches {
+		if watch.MovieID == movieID {
+			delete(h.watches, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+/*
+ * @File: daos.meteredmovie.go
+ * @Description: Times and traces the calls of a MovieRepository
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+
+	"../models"
+)
+
+// MeteredMovie times and traces the calls of a MovieRepository, see metrics.DAOCallDuration
+type MeteredMovie struct {
+	repository MovieRepository
+}
+
+// NewMeteredMovie creates a MovieRepository timing and tracing the calls of the given one
+func NewMeteredMovie(repository MovieRepository) *MeteredMovie {
+	return &MeteredMovie{repository}
+}
+
+// GetByIDs times and traces the GetByIDs call of the repository
+func (m *MeteredMovie) GetByIDs(ctx context.Context, ids []models.ObjectID) (map[models.ObjectID]*models.Movie, error) {
+	ctx, call := startCall(ctx, "movie", "GetByIDs")
+	movies, err := m.repository.GetByIDs(ctx, ids)
+	call.end(err)
+	return movies, err
+}
+
+// GetPage times and traces the GetPage call of the repository
+func (m *MeteredMovie) GetPage(ctx context.Context, filter models.MovieFilter, query models.PageQuery) ([]models.Movie, string, int, error) {
+	ctx, call := startCall(ctx, "movie", "GetPage")
+	movies, next, total, err := m.repository.GetPage(ctx, filter, query)
+	call.end(err)
+	return movies, next, total, err
+}
+
+// GetByID times and traces the GetByID call of the repository
+func (m *MeteredMovie) GetByID(ctx context.Context, id string) (models.Movie, error) {
+	ctx, call := startCall(ctx, "movie", "GetByID")
+	movie, err := m.repository.GetByID(ctx, id)
+	call.end(err)
+	return movie, err
+}
+
+// DeleteByID times and traces the DeleteByID call of the repository
+func (m *MeteredMovie) DeleteByID(ctx context.Context, id string) error {
+	ctx, call := startCall(ctx, "movie", "DeleteByID")
+	err := m.repository.DeleteByID(ctx, id)
+	call.end(err)
+	return err
+}
+
+// Insert times and traces the Insert call of the repository
+func (m *MeteredMovie) Insert(ctx context.Context, movie models.Movie) error {
+	ctx, call := startCall(ctx, "movie", "Insert")
+	err := m.repository.Insert(ctx, movie)
+	call.end(err)
+	return err
+}
+
+// Delete times and traces the Delete call of the repository
+func (m *MeteredMovie) Delete(ctx context.Context, movie models.Movie) error {
+	ctx, call := startCall(ctx, "movie", "Delete")
+	err := m.repository.Delete(ctx, movie)
+	call.end(err)
+	return err
+}
+
+// Update times and traces the Update call of the repository
+func (m *MeteredMovie) Update(ctx context.Context, movie models.Movie) error {
+	ctx, call := startCall(ctx, "movie", "Update")
+	err := m.repository.Update(ctx, movie)
+	call.end(err)
+	return err
+}
+
+// UpdateFields times and traces the UpdateFields call of the repository
+func (m *MeteredMovie) UpdateFields(ctx context.Context, id string, update models.UpdateMovie) error {
+	ctx, call := startCall(ctx, "movie", "UpdateFields")
+	err := m.repository.UpdateFields(ctx, id, update)
+	call.end(err)
+	return err
+}
+
+// Search times and traces the Search call of the repository
+func (m *MeteredMovie) Search(ctx context.Context, text string, filter models.MovieFilter, limit int, offset int) ([]models.MovieSearchHit, int, error) {
+	ctx, call := startCall(ctx, "movie", "Search")
+	hits, total, err := m.repository.Search(ctx, text, filter, limit, offset)
+	call.end(err)
+	return hits, total, err
+}
+
+// SearchFacets times and traces the SearchFacets call of the repository
+func (m *MeteredMovie) SearchFacets(ctx context.Context, text string, filter models.MovieFilter) (models.MovieFacets, error) {
+	ctx, call := startCall(ctx, "movie", "SearchFacets")
+	facets, err := m.repository.SearchFacets(ctx, text, filter)
+	call.end(err)
+	return facets, err
+}
+
+// SetCover times and traces the SetCover call of the repository
+func (m *MeteredMovie) SetCover(ctx context.Context, id string, cover models.MovieCover) error {
+	ctx, call := startCall(ctx, "movie", "SetCover")
+	err := m.repository.SetCover(ctx, id, cover)
+	call.end(err)
+	return err
+}
+
+// UpdateRating times and traces the UpdateRating call of the repository
+func (m *MeteredMovie) UpdateRating(ctx context.Context, id models.ObjectID) error {
+	ctx, call := startCall(ctx, "movie", "UpdateRating")
+	err := m.repository.UpdateRating(ctx, id)
+	call.end(err)
+	return err
+}
+
+// CountByGenre times and traces the CountByGenre call of the repository
+func (m *MeteredMovie) CountByGenre(ctx context.Context, genreID models.ObjectID) (int, error) {
+	ctx, call := startCall(ctx, "movie", "CountByGenre")
+	total, err := m.repository.CountByGenre(ctx, genreID)
+	call.end(err)
+	return total, err
+}
+
+// RemoveGenre times and traces the RemoveGenre call of the repository
+func (m *MeteredMovie) RemoveGenre(ctx context.Context, genreID models.ObjectID) error {
+	ctx, call := startCall(ctx, "movie", "RemoveGenre")
+	err := m.repository.RemoveGenre(ctx, genreID)
+	call.end(err)
+	return err
+}
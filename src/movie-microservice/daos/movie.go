@@ -6,78 +6,384 @@
 package daos
 
 import (
+	"context"
+	"regexp"
+
+	"../common"
 	"../databases"
 	"../models"
-	"gopkg.in/mgo.v2/bson"
+	"../utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Movie manages Movie CRUD
 type Movie struct {
+	utils *utils.Utils
 }
 
-// COLLECTION of the database table
-const (
-	COLLECTION = "movies"
-)
+// movieSortFields are the fields of the Movies which can be sorted
+var movieSortFields = []string{"name"}
+
+// GetByIDs finds the existing Movies of the given ids
+func (m *Movie) GetByIDs(ctx context.Context, ids []models.ObjectID) (map[models.ObjectID]*models.Movie, error) {
+	movies := make(map[models.ObjectID]*models.Movie)
+	if len(ids) == 0 {
+		return movies, nil
+	}
 
-// GetAll gets the list of Movie
-func (m *Movie) GetAll() ([]models.Movie, error) {
-	sessionCopy := databases.Database.MgDbSession.Copy()
-	defer sessionCopy.Close()
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColMovies)
 
-	// Get a collection to execute thbcegmorste query against.
-	collection := sessionCopy.DB(databases.Database.Databasename).C(COLLECTION)
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return movies, err
+	}
 
-	var movies []models.Movie
-	err := collection.Find(bson.M{}).All(&movies)
+	var found []models.Movie
+	err = cursor.All(ctx, &found)
+	for i := range found {
+		movies[found[i].ID] = &found[i]
+	}
 	return movies, err
 }
 
+// movieQuery returns the MongoDB query of a Movie filter
+func movieQuery(filter models.MovieFilter) bson.M {
+	query := bson.M{}
+	if len(filter.Name) > 0 {
+		query["name"] = primitive.Regex{regexp.QuoteMeta(filter.Name), "i"}
+	}
+	if !filter.Genre.IsZero() {
+		query["genres"] = filter.Genre
+	}
+	if filter.Year > 0 {
+		query["year"] = filter.Year
+	}
+
+	return query
+}
+
+// movieFields returns the MongoDB fields of a Movie modification
+func movieFields(update models.UpdateMovie) bson.M {
+	fields := bson.M{}
+	if update.Name != nil {
+		fields["name"] = *update.Name
+	}
+	if update.URL != nil {
+		fields["url"] = *update.URL
+	}
+	if update.CoverImage != nil {
+		fields["coverImage"] = *update.CoverImage
+	}
+	if update.Description != nil {
+		fields["description"] = *update.Description
+	}
+	if update.Year != nil {
+		fields["year"] = *update.Year
+	}
+	if update.Genres != nil {
+		fields["genres"] = *update.Genres
+	}
+
+	return fields
+}
+
+// GetPage gets a page of the Movies matching the filter, with the cursor of the next page and the total count
+func (m *Movie) GetPage(ctx context.Context, filter models.MovieFilter, query models.PageQuery) ([]models.Movie, string, int, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColMovies)
+
+	raws, next, total, err := findPage(ctx, collection, movieQuery(filter), query, movieSortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	movies, err := unmarshalMovies(raws)
+	return movies, next, total, err
+}
+
+// unmarshalMovies unmarshals the Movies of a page
+func unmarshalMovies(raws []bson.Raw) ([]models.Movie, error) {
+	movies := make([]models.Movie, len(raws))
+	for i, raw := range raws {
+		if err := bson.Unmarshal(raw, &movies[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return movies, nil
+}
+
 // GetByID finds a Movie by its id
-func (m *Movie) GetByID(id string) (models.Movie, error) {
-	sessionCopy := databases.Database.MgDbSession.Copy()
-	defer sessionCopy.Close()
+func (m *Movie) GetByID(ctx context.Context, id string) (models.Movie, error) {
+	err := m.utils.ValidateObjectID(id)
+	if err != nil {
+		return models.Movie{}, err
+	}
+
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
 
 	// Get a collection to execute the query against.
-	collection := sessionCopy.DB(databases.Database.Databasename).C(COLLECTION)
+	collection := databases.Database.Collection(common.ColMovies)
 
 	var movie models.Movie
-	err := collection.FindId(bson.ObjectIdHex(id)).One(&movie)
+	err = collection.FindOne(ctx, bson.M{"_id": utils.ObjectIDHex(id)}).Decode(&movie)
 	return movie, err
 }
 
+// DeleteByID removes a Movie by its id
+func (m *Movie) DeleteByID(ctx context.Context, id string) error {
+	err := m.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColMovies)
+
+	return deleted(collection.DeleteOne(ctx, bson.M{"_id": utils.ObjectIDHex(id)}))
+}
+
 // Insert adds a new Movie into database'
-func (m *Movie) Insert(movie models.Movie) error {
-	sessionCopy := databases.Database.MgDbSession.Copy()
-	defer sessionCopy.Close()
+func (m *Movie) Insert(ctx context.Context, movie models.Movie) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
 
 	// Get a collection to execute the query against.
-	collection := sessionCopy.DB(databases.Database.Databasename).C(COLLECTION)
+	collection := databases.Database.Collection(common.ColMovies)
 
-	err := collection.Insert(&movie)
+	_, err := collection.InsertOne(ctx, &movie)
 	return err
 }
 
 // Delete remove an existing Movie
-func (m *Movie) Delete(movie models.Movie) error {
-	sessionCopy := databases.Database.MgDbSession.Copy()
-	defer sessionCopy.Close()
+func (m *Movie) Delete(ctx context.Context, movie models.Movie) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
 
 	// Get a collection to execute the query against.
-	collection := sessionCopy.DB(databases.Database.Databasename).C(COLLECTION)
+	collection := databases.Database.Collection(common.ColMovies)
 
-	err := collection.Remove(&movie)
-	return err
+	return deleted(collection.DeleteOne(ctx, bson.M{"_id": movie.ID}))
 }
 
 // Update modifies an existing Movie
-func (m *Movie) Update(movie models.Movie) error {
-	sessionCopy := databases.Database.MgDbSession.Copy()
-	defer sessionCopy.Close()
+func (m *Movie) Update(ctx context.Context, movie models.Movie) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColMovies)
+
+	return updated(collection.ReplaceOne(ctx, bson.M{"_id": movie.ID}, &movie))
+}
+
+// UpdateFields modifies the given fields of an existing Movie
+func (m *Movie) UpdateFields(ctx context.Context, id string, update models.UpdateMovie) error {
+	err := m.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColMovies)
+
+	fields := movieFields(update)
+	if len(fields) == 0 {
+		// Nothing to modify, only check that the Movie exists
+		var count int64
+		count, err = collection.CountDocuments(ctx, bson.M{"_id": utils.ObjectIDHex(id)}, options.Count().SetLimit(1))
+		if err == nil && count == 0 {
+			err = mongo.ErrNoDocuments
+		}
+		return err
+	}
+
+	return updated(collection.UpdateOne(ctx, bson.M{"_id": utils.ObjectIDHex(id)}, bson.M{"$set": fields}))
+}
+
+// Search finds the Movies matching the text search and the filter, the best matches come first.
+// The total count of the matched Movies is returned with the requested page.
+func (m *Movie) Search(ctx context.Context, text string, filter models.MovieFilter, limit int, offset int) ([]models.MovieSearchHit, int, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColMovies)
+
+	search := textFilter(text, filter)
+	total, err := collection.CountDocuments(ctx, search)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, search, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var results []struct {
+		models.Movie `bson:",inline"`
+		Score        float64 `bson:"score"`
+	}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]models.MovieSearchHit, len(results))
+	for i, result := range results {
+		hits[i].Movie = result.Movie
+		hits[i].Score = result.Score
+	}
+
+	return hits, int(total), nil
+}
+
+// SearchFacets counts the Movies matching the text search and the filter per Genre and per year
+func (m *Movie) SearchFacets(ctx context.Context, text string, filter models.MovieFilter) (models.MovieFacets, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColMovies)
+
+	pipeline := []bson.M{
+		{"$match": textFilter(text, filter)},
+		{"$facet": bson.M{
+			"genres": []bson.M{
+				{"$unwind": "$genres"},
+				{"$group": bson.M{"_id": "$genres", "count": bson.M{"$sum": 1}}},
+				{"$lookup": bson.M{"from": common.ColGenres, "localField": "_id", "foreignField": "_id", "as": "genre"}},
+				{"$project": bson.M{"count": 1, "name": bson.M{"$arrayElemAt": []interface{}{"$genre.name", 0}}}},
+				{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "name", Value: 1}}},
+			},
+			"years": []bson.M{
+				{"$match": bson.M{"year": bson.M{"$gt": 0}}},
+				{"$group": bson.M{"_id": "$year", "count": bson.M{"$sum": 1}}},
+				{"$sort": bson.M{"_id": -1}},
+			},
+		}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return models.MovieFacets{}, err
+	}
+	defer cursor.Close(ctx)
+
+	// $facet always outputs one document
+	var facets models.MovieFacets
+	if cursor.Next(ctx) {
+		err = cursor.Decode(&facets)
+	} else {
+		err = cursor.Err()
+	}
+	return facets, err
+}
+
+// textFilter adds the text search to the query of a filter
+func textFilter(text string, filter models.MovieFilter) bson.M {
+	search := movieQuery(filter)
+	search["$text"] = bson.M{"$search": text}
+	return search
+}
+
+// SetCover stores the description of the cover image of a Movie, its cover image
+// url becomes the url of the cover API
+func (m *Movie) SetCover(ctx context.Context, id string, cover models.MovieCover) error {
+	err := m.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColMovies)
+
+	return updated(collection.UpdateOne(ctx, bson.M{"_id": utils.ObjectIDHex(id)}, bson.M{"$set": bson.M{"cover": cover, "coverImage": "/api/v1/movies/" + id + "/cover"}}))
+}
+
+// UpdateRating computes the aggregate of the Ratings of a Movie and stores it. The concurrent updates
+// are ordered by the ratingVersion of the Movie, it's increased after the Rating has been written so that
+// the aggregate read next includes it. An aggregate never replaces the one of a later version.
+func (m *Movie) UpdateRating(ctx context.Context, id models.ObjectID) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColMovies)
+
+	var version struct {
+		Version int64 `bson:"ratingVersion"`
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"ratingVersion": 1})
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"ratingVersion": 1}}, opts).Decode(&version)
+	if err == mongo.ErrNoDocuments {
+		// The Movie has been deleted meanwhile
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rating, err := aggregateRatings(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": id, "$or": []bson.M{
+		{"ratingStoredVersion": bson.M{"$lt": version.Version}},
+		{"ratingStoredVersion": bson.M{"$exists": false}},
+	}}
+	_, err = collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"rating": rating, "ratingStoredVersion": version.Version}})
+	return err
+}
+
+// CountByGenre counts the Movies having the given Genre
+func (m *Movie) CountByGenre(ctx context.Context, genreID models.ObjectID) (int, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColMovies)
+
+	count, err := collection.CountDocuments(ctx, bson.M{"genres": genreID})
+	return int(count), err
+}
+
+// RemoveGenre removes the given Genre from all Movies
+func (m *Movie) RemoveGenre(ctx context.Context, genreID models.ObjectID) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
 
 	// Get a collection to execute the query against.
-	collection := sessionCopy.DB(databases.Database.Databasename).C(COLLECTION)
+	collection := databases.Database.Collection(common.ColMovies)
 
-	err := collection.UpdateId(movie.ID, &movie)
+	_, err := collection.UpdateMany(ctx, bson.M{"genres": genreID}, bson.M{"$pull": bson.M{"genres": genreID}})
 	return err
 }
@@ -0,0 +1,140 @@
+/*
+ * @File: daos.rating.go
+ * @Description: Implements Rating CRUD functions for MongoDB
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"../common"
+	"../databases"
+	"../models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Rating manages Rating CRUD
+type Rating struct {
+}
+
+// reviewSortFields are the fields of the Reviews which can be sorted
+var reviewSortFields = []string{"score", "createdAt", "updatedAt"}
+
+// Upsert adds or replaces the Rating of a Movie by a user, a user has one Rating per Movie
+func (r *Rating) Upsert(ctx context.Context, movieID models.ObjectID, userID string, userName string, addRating models.AddRating) (models.Rating, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColRatings)
+
+	now := time.Now()
+	update := bson.M{
+		"$set":         bson.M{"userName": userName, "score": addRating.Score, "review": addRating.Review, "updatedAt": now},
+		"$setOnInsert": bson.M{"createdAt": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var rating models.Rating
+	selector := bson.M{"movieId": movieID, "userId": userID}
+	err := collection.FindOneAndUpdate(ctx, selector, update, opts).Decode(&rating)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent request has inserted the Rating first, update it
+		err = collection.FindOneAndUpdate(ctx, selector, update, opts).Decode(&rating)
+	}
+	return rating, err
+}
+
+// Delete removes the Rating of a Movie by a user
+func (r *Rating) Delete(ctx context.Context, movieID models.ObjectID, userID string) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColRatings)
+
+	return deleted(collection.DeleteOne(ctx, bson.M{"movieId": movieID, "userId": userID}))
+}
+
+// DeleteByMovie removes all Ratings of a Movie
+func (r *Rating) DeleteByMovie(ctx context.Context, movieID models.ObjectID) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColRatings)
+
+	_, err := collection.DeleteMany(ctx, bson.M{"movieId": movieID})
+	return err
+}
+
+// GetReviews gets a page of the visible reviews of a Movie, with the cursor of the next page and the total count
+func (r *Rating) GetReviews(ctx context.Context, movieID models.ObjectID, query models.PageQuery) ([]models.Rating, string, int, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColRatings)
+
+	filter := bson.M{"movieId": movieID, "review": bson.M{"$gt": ""}, "moderation.hidden": bson.M{"$ne": true}}
+	raws, next, total, err := findPage(ctx, collection, filter, query, reviewSortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	ratings := make([]models.Rating, len(raws))
+	for i, raw := range raws {
+		if err = bson.Unmarshal(raw, &ratings[i]); err != nil {
+			return nil, "", 0, err
+		}
+	}
+
+	return ratings, next, total, nil
+}
+
+// Moderate sets the moderation of a review of a Movie
+func (r *Rating) Moderate(ctx context.Context, movieID models.ObjectID, id models.ObjectID, moderation models.ReviewModeration) (models.Rating, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColRatings)
+
+	update := bson.M{"$set": bson.M{"moderation": moderation}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var rating models.Rating
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "movieId": movieID}, update, opts).Decode(&rating)
+	return rating, err
+}
+
+// aggregateRatings computes the average and the count of the Ratings of a Movie
+func aggregateRatings(ctx context.Context, movieID models.ObjectID) (models.MovieRating, error) {
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColRatings)
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"movieId": movieID}},
+		{"$group": bson.M{"_id": nil, "average": bson.M{"$avg": "$score"}, "count": bson.M{"$sum": 1}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return models.MovieRating{}, err
+	}
+
+	var results []models.MovieRating
+	err = cursor.All(ctx, &results)
+	if err != nil || len(results) == 0 {
+		return models.MovieRating{}, err
+	}
+
+	rating := results[0]
+	rating.Average = math.Round(rating.Average*100) / 100
+	return rating, nil
+}
@@ -0,0 +1,94 @@
+/*
+ * @File: daos.repository.go
+ * @Description: Defines the repositories used by the API logic functions
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+
+	"../models"
+)
+
+// MovieRepository stores the Movies. Movie implements it with MongoDB, SQLMovie
+// with PostgreSQL or SQLite and MemoryMovie keeps the Movies in memory.
+type MovieRepository interface {
+	GetByIDs(ctx context.Context, ids []models.ObjectID) (map[models.ObjectID]*models.Movie, error)
+	GetPage(ctx context.Context, filter models.MovieFilter, query models.PageQuery) ([]models.Movie, string, int, error)
+	GetByID(ctx context.Context, id string) (models.Movie, error)
+	DeleteByID(ctx context.Context, id string) error
+	Insert(ctx context.Context, movie models.Movie) error
+	Delete(ctx context.Context, movie models.Movie) error
+	Update(ctx context.Context, movie models.Movie) error
+	UpdateFields(ctx context.Context, id string, update models.UpdateMovie) error
+	Search(ctx context.Context, text string, filter models.MovieFilter, limit int, offset int) ([]models.MovieSearchHit, int, error)
+	SearchFacets(ctx context.Context, text string, filter models.MovieFilter) (models.MovieFacets, error)
+	SetCover(ctx context.Context, id string, cover models.MovieCover) error
+	UpdateRating(ctx context.Context, id models.ObjectID) error
+	CountByGenre(ctx context.Context, genreID models.ObjectID) (int, error)
+	RemoveGenre(ctx context.Context, genreID models.ObjectID) error
+}
+
+// GenreRepository stores the Movie Genres. Genre implements it with MongoDB, SQLGenre
+// with PostgreSQL or SQLite and MemoryGenre keeps the Genres in memory.
+type GenreRepository interface {
+	GetAll(ctx context.Context) ([]models.MovieGenre, error)
+	GetByID(ctx context.Context, id string) (models.MovieGenre, error)
+	GetByName(ctx context.Context, name string) (models.MovieGenre, error)
+	Exist(ctx context.Context, ids []models.ObjectID) (bool, error)
+	Insert(ctx context.Context, genre models.MovieGenre) error
+	Update(ctx context.Context, genre models.MovieGenre) error
+	DeleteByID(ctx context.Context, id string) error
+}
+
+// RatingRepository stores the Ratings and the Reviews of the Movies. Rating implements it
+// with MongoDB, SQLRating with PostgreSQL or SQLite and MemoryRating keeps them in memory.
+type RatingRepository interface {
+	Upsert(ctx context.Context, movieID models.ObjectID, userID string, userName string, addRating models.AddRating) (models.Rating, error)
+	Delete(ctx context.Context, movieID models.ObjectID, userID string) error
+	DeleteByMovie(ctx context.Context, movieID models.ObjectID) error
+	GetReviews(ctx context.Context, movieID models.ObjectID, query models.PageQuery) ([]models.Rating, string, int, error)
+	Moderate(ctx context.Context, movieID models.ObjectID, id models.ObjectID, moderation models.ReviewModeration) (models.Rating, error)
+}
+
+// WatchlistRepository stores the Watchlists of the users. Watchlist implements it with
+// MongoDB, SQLWatchlist with PostgreSQL or SQLite and MemoryWatchlist keeps them in memory.
+type WatchlistRepository interface {
+	Add(ctx context.Context, userID string, movieID models.ObjectID) (models.WatchlistItem, error)
+	Remove(ctx context.Context, userID string, movieID models.ObjectID) error
+	GetPage(ctx context.Context, userID string, query models.PageQuery) ([]models.WatchlistItem, string, int, error)
+	DeleteByMovie(ctx context.Context, movieID models.ObjectID) error
+}
+
+// HistoryRepository stores the Watch Histories of the users. History implements it with
+// MongoDB, SQLHistory with PostgreSQL or SQLite and MemoryHistory keeps them in memory.
+type HistoryRepository interface {
+	Save(ctx context.Context, userID string, movieID models.ObjectID, progress models.WatchProgress) (models.Watch, error)
+	Get(ctx context.Context, userID string, movieID models.ObjectID) (models.Watch, error)
+	GetPage(ctx context.Context, userID string, query models.PageQuery) ([]models.Watch, string, int, error)
+	DeleteByMovie(ctx context.Context, movieID models.ObjectID) error
+}
+
+var (
+	_ MovieRepository = (*Movie)(nil)
+	_ MovieRepository = (*SQLMovie)(nil)
+	_ MovieRepository = (*MemoryMovie)(nil)
+	_ MovieRepository = (*MeteredMovie)(nil)
+
+	_ GenreRepository = (*Genre)(nil)
+	_ GenreRepository = (*SQLGenre)(nil)
+	_ GenreRepository = (*MemoryGenre)(nil)
+
+	_ RatingRepository = (*Rating)(nil)
+	_ RatingRepository = (*SQLRating)(nil)
+	_ RatingRepository = (*MemoryRating)(nil)
+
+	_ WatchlistRepository = (*Watchlist)(nil)
+	_ WatchlistRepository = (*SQLWatchlist)(nil)
+	_ WatchlistRepository = (*MemoryWatchlist)(nil)
+
+	_ HistoryRepository = (*History)(nil)
+	_ HistoryRepository = (*SQLHistory)(nil)
+	_ HistoryRepository = (*MemoryHistory)(nil)
+)
@@ -0,0 +1,231 @@
+/*
+ * @File: daos.sql.go
+ * @Description: Implements the helpers shared by the SQL DAOs
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"../databases"
+	"../models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// sqlID reads an id column, the ids are stored as the hex strings of the ObjectIds
+type sqlID struct {
+	id *models.ObjectID
+}
+
+// Scan parses the hex string of the id
+func (i sqlID) Scan(src interface{}) error {
+	var err error
+	switch value := src.(type) {
+	case string:
+		*i.id, err = models.ObjectIDFromHex(strings.TrimSpace(value))
+	case []byte:
+		*i.id, err = models.ObjectIDFromHex(strings.TrimSpace(string(value)))
+	default:
+		err = fmt.Errorf("id column can't be read from %T", src)
+	}
+	return err
+}
+
+// sqlTime reads and writes a time column, see databases.SQLTimeLayout
+type sqlTime struct {
+	time *time.Time
+}
+
+// Value formats the time
+func (t sqlTime) Value() (driver.Value, error) {
+	return t.time.UTC().Format(databases.SQLTimeLayout), nil
+}
+
+// Scan reads the time, PostgreSQL returns times and SQLite returns texts.
+// NULL reads as the zero time.
+func (t sqlTime) Scan(src interface{}) error {
+	var err error
+	switch value := src.(type) {
+	case nil:
+		*t.time = time.Time{}
+	case time.Time:
+		*t.time = value.UTC()
+	case string:
+		*t.time, err = time.Parse(databases.SQLTimeLayout, value)
+	case []byte:
+		*t.time, err = time.Parse(databases.SQLTimeLayout, string(value))
+	default:
+		err = fmt.Errorf("time column can't be read from %T", src)
+	}
+	return err
+}
+
+// sqlValue converts a value of a filter or of a page cursor to its column value
+func sqlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case models.ObjectID:
+		return v.Hex()
+	case primitive.ObjectID:
+		return v.Hex()
+	case primitive.DateTime:
+		t := v.Time()
+		return sqlTime{&t}
+	case time.Time:
+		return sqlTime{&v}
+	default:
+		return value
+	}
+}
+
+// sqlQuery builds the WHERE clause and the arguments of a query. The arguments
+// are numbered like in PostgreSQL, SQLite understands them too.
+type sqlQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg adds an argument and returns its placeholder
+func (q *sqlQuery) arg(value interface{}) string {
+	q.args = append(q.args, sqlValue(value))
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// in adds the arguments of an IN list and returns the list
+func (q *sqlQuery) in(values []interface{}) string {
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = q.arg(value)
+	}
+
+	return "(" + strings.Join(placeholders, ", ") + ")"
+}
+
+// where adds a condition
+func (q *sqlQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// clause returns the WHERE clause of the conditions
+func (q *sqlQuery) clause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+}
+
+// count counts the rows of a table matching the conditions
+func (q *sqlQuery) count(ctx context.Context, db *sql.DB, table string) (int, error) {
+	var count int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table+q.clause(), q.args...).Scan(&count)
+	return count, err
+}
+
+// page adds the conditions of a page query, the rows are sorted like findPage does.
+// It returns the ORDER BY and LIMIT clauses, requesting one more row than the limit to know
+// if there is a next page, and the field of the page cursor.
+func (q *sqlQuery) page(query models.PageQuery, columns map[string]string, sortFields []string) (string, string, error) {
+	field, descending := query.Sort, strings.HasPrefix(query.Sort, "-")
+	if descending {
+		field = field[1:]
+	}
+	switch {
+	case len(field) == 0 || field == "id":
+		field = "_id"
+	case !containsString(sortFields, field):
+		return "", "", ErrPageSortInvalid
+	}
+	column, idColumn := columns[field], columns["_id"]
+
+	direction, operator := " ASC", " > "
+	if descending {
+		direction, operator = " DESC", " < "
+	}
+
+	if len(query.After) > 0 {
+		cursor, err := decodePageCursor(query.After)
+		if err != nil {
+			return "", "", err
+		}
+
+		id := q.arg(cursor.ID)
+		if field == "_id" {
+			q.where(idColumn + operator + id)
+		} else {
+			value := q.arg(cursor.Value)
+			q.where("(" + column + operator + value + " OR (" + column + " = " + value + " AND " + idColumn + operator + id + "))")
+		}
+	}
+
+	order := " ORDER BY " + column + direction
+	if field != "_id" {
+		order += ", " + idColumn + direction
+	}
+	order += " LIMIT " + q.arg(query.Limit+1) + " OFFSET " + q.arg(query.Offset)
+
+	return order, field, nil
+}
+
+// nextPageCursor returns the cursor of the page ending with the given model
+func nextPageCursor(model interface{}, field string) (string, error) {
+	data, err := bson.Marshal(model)
+	if err != nil {
+		return "", err
+	}
+
+	return encodePageCursor(bson.Raw(data), field)
+}
+
+// sqlFound returns mongo.ErrNoDocuments when a query found no row, like the other DAOs
+func sqlFound(err error) error {
+	if err == sql.ErrNoRows {
+		return mongo.ErrNoDocuments
+	}
+
+	return err
+}
+
+// sqlAffected returns mongo.ErrNoDocuments when a statement matched no row, like the other DAOs
+func sqlAffected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err == nil && count == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return err
+}
+
+// inTx runs the statements of a function in a transaction, the transaction
+// is rolled back when the function fails
+func inTx(ctx context.Context, db *sql.DB, statements func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = statements(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
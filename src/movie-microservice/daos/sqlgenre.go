@@ -0,0 +1,137 @@
+/*
+ * @File: daos.sqlgenre.go
+ * @Description: Implements Movie Genre CRUD functions for PostgreSQL and SQLite
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+
+	"../databases"
+	"../models"
+	"../utils"
+)
+
+// SQLGenre manages Movie Genre CRUD in the genres table of a SQL database
+type SQLGenre struct {
+	utils *utils.Utils
+	db    *databases.SQL
+}
+
+// NewSQLGenre creates a GenreRepository storing the Genres in the given database
+func NewSQLGenre(db *databases.SQL) *SQLGenre {
+	return &SQLGenre{db: db}
+}
+
+// genreSelect selects the columns read by scanGenre
+const genreSelect = "SELECT id, name, description FROM genres"
+
+// scanGenre reads a Genre from a row of genreSelect
+func scanGenre(row interface{ Scan(...interface{}) error }) (models.MovieGenre, error) {
+	var genre models.MovieGenre
+	err := row.Scan(sqlID{&genre.ID}, &genre.Name, &genre.Description)
+	return genre, err
+}
+
+// GetAll gets the list of Genres
+func (g *SQLGenre) GetAll(ctx context.Context) ([]models.MovieGenre, error) {
+	ctx, cancel := g.db.Context(ctx)
+	defer cancel()
+
+	rows, err := g.db.DB.QueryContext(ctx, genreSelect+" ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := []models.MovieGenre{}
+	for rows.Next() {
+		var genre models.MovieGenre
+		genre, err = scanGenre(rows)
+		if err != nil {
+			return nil, err
+		}
+		genres = append(genres, genre)
+	}
+
+	return genres, rows.Err()
+}
+
+// GetByID finds a Genre by its id
+func (g *SQLGenre) GetByID(ctx context.Context, id string) (models.MovieGenre, error) {
+	err := g.utils.ValidateObjectID(id)
+	if err != nil {
+		return models.MovieGenre{}, err
+	}
+
+	ctx, cancel := g.db.Context(ctx)
+	defer cancel()
+
+	genre, err := scanGenre(g.db.DB.QueryRowContext(ctx, genreSelect+" WHERE id = $1", id))
+	return genre, sqlFound(err)
+}
+
+// GetByName finds a Genre by its name, ignoring the case
+func (g *SQLGenre) GetByName(ctx context.Context, name string) (models.MovieGenre, error) {
+	ctx, cancel := g.db.Context(ctx)
+	defer cancel()
+
+	genre, err := scanGenre(g.db.DB.QueryRowContext(ctx, genreSelect+" WHERE LOWER(name) = LOWER($1) ORDER BY id LIMIT 1", name))
+	return genre, sqlFound(err)
+}
+
+// Exist checks if all the given Genres exist
+func (g *SQLGenre) Exist(ctx context.Context, ids []models.ObjectID) (bool, error) {
+	unique := make(map[models.ObjectID]bool)
+	var values []interface{}
+	for _, id := range ids {
+		if !unique[id] {
+			unique[id] = true
+			values = append(values, id)
+		}
+	}
+	if len(unique) == 0 {
+		return true, nil
+	}
+
+	ctx, cancel := g.db.Context(ctx)
+	defer cancel()
+
+	q := &sqlQuery{}
+	q.where("id IN " + q.in(values))
+	count, err := q.count(ctx, g.db.DB, "genres")
+	return count == len(unique), err
+}
+
+// Insert adds a new Genre into database
+func (g *SQLGenre) Insert(ctx context.Context, genre models.MovieGenre) error {
+	ctx, cancel := g.db.Context(ctx)
+	defer cancel()
+
+	_, err := g.db.DB.ExecContext(ctx, "INSERT INTO genres (id, name, description) VALUES ($1, $2, $3)",
+		genre.ID.Hex(), genre.Name, genre.Description)
+	return err
+}
+
+// Update modifies an existing Genre
+func (g *SQLGenre) Update(ctx context.Context, genre models.MovieGenre) error {
+	ctx, cancel := g.db.Context(ctx)
+	defer cancel()
+
+	return sqlAffected(g.db.DB.ExecContext(ctx, "UPDATE genres SET name = $1, description = $2 WHERE id = $3",
+		genre.Name, genre.Description, genre.ID.Hex()))
+}
+
+// DeleteByID removes a Genre by its id
+func (g *SQLGenre) DeleteByID(ctx context.Context, id string) error {
+	err := g.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := g.db.Context(ctx)
+	defer cancel()
+
+	return sqlAffected(g.db.DB.ExecContext(ctx, "DELETE FROM genres WHERE id = $1", id))
+}
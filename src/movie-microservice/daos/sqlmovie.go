@@ -0,0 +1,604 @@
+/*
+ * @File: daos.sqlmovie.go
+ * @Description: Implements Movie CRUD functions for PostgreSQL and SQLite
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"strings"
+
+	"../common"
+	"../databases"
+	"../models"
+	"../utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SQLMovie manages Movie CRUD in the movies table of a SQL database,
+// the Genres of the Movies are stored in the movie_genres table
+type SQLMovie struct {
+	utils *utils.Utils
+	db    *databases.SQL
+}
+
+// NewSQLMovie creates a MovieRepository storing the Movies in the given database
+func NewSQLMovie(db *databases.SQL) *SQLMovie {
+	return &SQLMovie{db: db}
+}
+
+// movieColumns maps the sorted fields of the Movies to the columns of the movies table
+var movieColumns = map[string]string{
+	"_id":  "id",
+	"name": "name",
+}
+
+// movieSelectColumns are the columns read by scanMovie
+const movieSelectColumns = "movies.id, movies.name, movies.url, movies.cover_image, movies.description, movies.year, movies.rating_average, movies.rating_count, movies.cover_content_type, movies.cover_updated_at"
+
+// movieSelect selects the columns read by scanMovie
+const movieSelect = "SELECT " + movieSelectColumns + " FROM movies"
+
+// genresBatchSize is the number of Movies whose Genres are read by a query
+const genresBatchSize = 500
+
+// scanMovie reads a Movie without its Genres from a row of movieSelect, the columns
+// selected after the ones of movieSelectColumns are read into extra
+func scanMovie(row interface{ Scan(...interface{}) error }, extra ...interface{}) (models.Movie, error) {
+	var movie models.Movie
+	var cover models.MovieCover
+	var contentType sql.NullString
+	dest := []interface{}{sqlID{&movie.ID}, &movie.Name, &movie.URL, &movie.CoverImage, &movie.Description, &movie.Year,
+		&movie.Rating.Average, &movie.Rating.Count, &contentType, sqlTime{&cover.UpdatedAt}}
+	err := row.Scan(append(dest, extra...)...)
+	if err == nil && contentType.Valid {
+		cover.ContentType = contentType.String
+		movie.Cover = &cover
+	}
+	return movie, err
+}
+
+// coverValues returns the values of the cover columns, NULL when the Movie has no cover
+func coverValues(cover *models.MovieCover) (interface{}, interface{}) {
+	if cover == nil {
+		return nil, nil
+	}
+
+	return cover.ContentType, sqlTime{&cover.UpdatedAt}
+}
+
+// movieFilter adds the conditions of a Movie filter, the Genre condition checks the movie_genres table.
+// SQLite only lowers the case of the ASCII letters, the other letters of the name must match its case.
+func movieFilter(q *sqlQuery, filter models.MovieFilter) {
+	if len(filter.Name) > 0 {
+		q.where("LOWER(movies.name) LIKE " + q.arg("%"+escapeLike(strings.ToLower(filter.Name))+"%") + ` ESCAPE '\'`)
+	}
+	if !filter.Genre.IsZero() {
+		q.where("EXISTS (SELECT 1 FROM movie_genres WHERE movie_genres.movie_id = movies.id AND movie_genres.genre_id = " + q.arg(filter.Genre) + ")")
+	}
+	if filter.Year > 0 {
+		q.where("movies.year = " + q.arg(filter.Year))
+	}
+}
+
+// find reads the Movies selected by a query, with their Genres
+func (m *SQLMovie) find(ctx context.Context, query string, args ...interface{}) ([]models.Movie, error) {
+	movies, err := m.findMovies(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]*models.Movie, len(movies))
+	for i := range movies {
+		refs[i] = &movies[i]
+	}
+
+	return movies, m.loadGenres(ctx, refs)
+}
+
+// findMovies reads the Movies selected by a query, without their Genres
+func (m *SQLMovie) findMovies(ctx context.Context, query string, args ...interface{}) ([]models.Movie, error) {
+	rows, err := m.db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := []models.Movie{}
+	for rows.Next() {
+		var movie models.Movie
+		movie, err = scanMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, movie)
+	}
+
+	return movies, rows.Err()
+}
+
+// loadGenres reads the Genres of the Movies in their order.
+// The rows of the Movies must be closed, a SQLite database has a single connection.
+func (m *SQLMovie) loadGenres(ctx context.Context, movies []*models.Movie) error {
+	byID := make(map[models.ObjectID]*models.Movie)
+	for _, movie := range movies {
+		byID[movie.ID] = movie
+	}
+
+	for start := 0; start < len(movies); start += genresBatchSize {
+		end := start + genresBatchSize
+		if end > len(movies) {
+			end = len(movies)
+		}
+
+		ids := make([]interface{}, 0, end-start)
+		for _, movie := range movies[start:end] {
+			ids = append(ids, movie.ID)
+		}
+
+		q := &sqlQuery{}
+		q.where("movie_id IN " + q.in(ids))
+		rows, err := m.db.DB.QueryContext(ctx, "SELECT movie_id, genre_id FROM movie_genres"+q.clause()+" ORDER BY movie_id, position", q.args...)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var movieID, genreID models.ObjectID
+			err = rows.Scan(sqlID{&movieID}, sqlID{&genreID})
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			movie := byID[movieID]
+			movie.Genres = append(movie.Genres, genreID)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetByIDs finds the existing Movies of the given ids
+func (m *SQLMovie) GetByIDs(ctx context.Context, ids []models.ObjectID) (map[models.ObjectID]*models.Movie, error) {
+	movies := make(map[models.ObjectID]*models.Movie)
+	if len(ids) == 0 {
+		return movies, nil
+	}
+
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	values := make([]interface{}, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+
+	q := &sqlQuery{}
+	q.where("id IN " + q.in(values))
+	found, err := m.find(ctx, movieSelect+q.clause(), q.args...)
+	for i := range found {
+		movies[found[i].ID] = &found[i]
+	}
+	return movies, err
+}
+
+// GetPage gets a page of the Movies matching the filter, with the cursor of the next page and the total count
+func (m *SQLMovie) GetPage(ctx context.Context, filter models.MovieFilter, query models.PageQuery) ([]models.Movie, string, int, error) {
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	q := &sqlQuery{}
+	movieFilter(q, filter)
+
+	total, err := q.count(ctx, m.db.DB, "movies")
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	order, field, err := q.page(query, movieColumns, movieSortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	movies, err := m.find(ctx, movieSelect+q.clause()+order, q.args...)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var next string
+	if len(movies) > query.Limit {
+		movies = movies[:query.Limit]
+		next, err = nextPageCursor(movies[len(movies)-1], field)
+	}
+
+	return movies, next, total, err
+}
+
+// GetByID finds a Movie by its id
+func (m *SQLMovie) GetByID(ctx context.Context, id string) (models.Movie, error) {
+	err := m.utils.ValidateObjectID(id)
+	if err != nil {
+		return models.Movie{}, err
+	}
+
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	movies, err := m.find(ctx, movieSelect+" WHERE id = $1", id)
+	if err != nil {
+		return models.Movie{}, err
+	}
+	if len(movies) == 0 {
+		return models.Movie{}, mongo.ErrNoDocuments
+	}
+
+	return movies[0], nil
+}
+
+// DeleteByID removes a Movie by its id
+func (m *SQLMovie) DeleteByID(ctx context.Context, id string) error {
+	err := m.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	return m.delete(ctx, id)
+}
+
+// delete removes a Movie and its Genres
+func (m *SQLMovie) delete(ctx context.Context, id string) error {
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	return inTx(ctx, m.db.DB, func(tx *sql.Tx) error {
+		err := sqlAffected(tx.ExecContext(ctx, "DELETE FROM movies WHERE id = $1", id))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM movie_genres WHERE movie_id = $1", id)
+		return err
+	})
+}
+
+// setGenres replaces the Genres of a Movie
+func setGenres(ctx context.Context, tx *sql.Tx, id models.ObjectID, genres []models.ObjectID) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM movie_genres WHERE movie_id = $1", id.Hex())
+	if err != nil {
+		return err
+	}
+
+	for i, genre := range genres {
+		_, err = tx.ExecContext(ctx, "INSERT INTO movie_genres (movie_id, genre_id, position) VALUES ($1, $2, $3)", id.Hex(), genre.Hex(), i)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Insert adds a new Movie into database
+func (m *SQLMovie) Insert(ctx context.Context, movie models.Movie) error {
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	contentType, coverUpdatedAt := coverValues(movie.Cover)
+	return inTx(ctx, m.db.DB, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO movies (id, name, url, cover_image, description, year, rating_average, rating_count, cover_content_type, cover_updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+			movie.ID.Hex(), movie.Name, movie.URL, movie.CoverImage, movie.Description, movie.Year, movie.Rating.Average, movie.Rating.Count, contentType, coverUpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		return setGenres(ctx, tx, movie.ID, movie.Genres)
+	})
+}
+
+// Delete remove an existing Movie
+func (m *SQLMovie) Delete(ctx context.Context, movie models.Movie) error {
+	return m.delete(ctx, movie.ID.Hex())
+}
+
+// Update modifies an existing Movie
+func (m *SQLMovie) Update(ctx context.Context, movie models.Movie) error {
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	contentType, coverUpdatedAt := coverValues(movie.Cover)
+	return inTx(ctx, m.db.DB, func(tx *sql.Tx) error {
+		err := sqlAffected(tx.ExecContext(ctx, "UPDATE movies SET name = $1, url = $2, cover_image = $3, description = $4, year = $5, rating_average = $6, rating_count = $7, cover_content_type = $8, cover_updated_at = $9 WHERE id = $10",
+			movie.Name, movie.URL, movie.CoverImage, movie.Description, movie.Year, movie.Rating.Average, movie.Rating.Count, contentType, coverUpdatedAt, movie.ID.Hex()))
+		if err != nil {
+			return err
+		}
+
+		return setGenres(ctx, tx, movie.ID, movie.Genres)
+	})
+}
+
+// UpdateFields modifies the given fields of an existing Movie
+func (m *SQLMovie) UpdateFields(ctx context.Context, id string, update models.UpdateMovie) error {
+	err := m.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	q := &sqlQuery{}
+	var assignments []string
+	set := func(column string, value interface{}) {
+		assignments = append(assignments, column+" = "+q.arg(value))
+	}
+	if update.Name != nil {
+		set("name", *update.Name)
+	}
+	if update.URL != nil {
+		set("url", *update.URL)
+	}
+	if update.CoverImage != nil {
+		set("cover_image", *update.CoverImage)
+	}
+	if update.Description != nil {
+		set("description", *update.Description)
+	}
+	if update.Year != nil {
+		set("year", *update.Year)
+	}
+
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	return inTx(ctx, m.db.DB, func(tx *sql.Tx) error {
+		var err error
+		if len(assignments) > 0 {
+			err = sqlAffected(tx.ExecContext(ctx, "UPDATE movies SET "+strings.Join(assignments, ", ")+" WHERE id = "+q.arg(id), q.args...))
+		} else {
+			// Only check that the Movie exists
+			var count int
+			err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM movies WHERE id = $1", id).Scan(&count)
+			if err == nil && count == 0 {
+				err = mongo.ErrNoDocuments
+			}
+		}
+		if err != nil || update.Genres == nil {
+			return err
+		}
+
+		return setGenres(ctx, tx, utils.ObjectIDHex(id), *update.Genres)
+	})
+}
+
+// Search finds the Movies matching the text search and the filter, the best matches come first.
+// The total count of the matched Movies is returned with the requested page.
+func (m *SQLMovie) Search(ctx context.Context, text string, filter models.MovieFilter, limit int, offset int) ([]models.MovieSearchHit, int, error) {
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	q := &sqlQuery{}
+	from, score, ok := m.searchFrom(q, text, filter)
+	if !ok {
+		return []models.MovieSearchHit{}, 0, nil
+	}
+
+	total, err := q.count(ctx, m.db.DB, from)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page := "SELECT " + movieSelectColumns + ", " + score + " AS score FROM " + from + q.clause() +
+		" ORDER BY score DESC, movies.id LIMIT " + q.arg(limit) + " OFFSET " + q.arg(offset)
+	rows, err := m.db.DB.QueryContext(ctx, page, q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	hits := []models.MovieSearchHit{}
+	for rows.Next() {
+		var hit models.MovieSearchHit
+		hit.Movie, err = scanMovie(rows, &hit.Score)
+		if err != nil {
+			return nil, 0, err
+		}
+		hits = append(hits, hit)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	rows.Close()
+
+	movies := make([]*models.Movie, len(hits))
+	for i := range hits {
+		movies[i] = &hits[i].Movie
+	}
+
+	return hits, total, m.loadGenres(ctx, movies)
+}
+
+// SearchFacets counts the Movies matching the text search and the filter per Genre and per year
+func (m *SQLMovie) SearchFacets(ctx context.Context, text string, filter models.MovieFilter) (models.MovieFacets, error) {
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	facets := models.MovieFacets{Genres: []models.GenreFacet{}, Years: []models.YearFacet{}}
+	q := &sqlQuery{}
+	from, _, ok := m.searchFrom(q, text, filter)
+	if !ok {
+		return facets, nil
+	}
+
+	// The Genres are sorted by count then by name, like in MongoDB
+	rows, err := m.db.DB.QueryContext(ctx, "SELECT movie_genres.genre_id, COALESCE(genres.name, ''), COUNT(*) FROM "+from+
+		" JOIN movie_genres ON movie_genres.movie_id = movies.id LEFT JOIN genres ON genres.id = movie_genres.genre_id"+q.clause()+
+		" GROUP BY movie_genres.genre_id, genres.name ORDER BY 3 DESC, 2, 1", q.args...)
+	if err != nil {
+		return models.MovieFacets{}, err
+	}
+	for rows.Next() {
+		var facet models.GenreFacet
+		err = rows.Scan(sqlID{&facet.ID}, &facet.Name, &facet.Count)
+		if err != nil {
+			rows.Close()
+			return models.MovieFacets{}, err
+		}
+		facets.Genres = append(facets.Genres, facet)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return models.MovieFacets{}, err
+	}
+
+	q.where("movies.year > 0")
+	rows, err = m.db.DB.QueryContext(ctx, "SELECT movies.year, COUNT(*) FROM "+from+q.clause()+" GROUP BY movies.year ORDER BY movies.year DESC", q.args...)
+	if err != nil {
+		return models.MovieFacets{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var facet models.YearFacet
+		err = rows.Scan(&facet.Year, &facet.Count)
+		if err != nil {
+			return models.MovieFacets{}, err
+		}
+		facets.Years = append(facets.Years, facet)
+	}
+
+	return facets, rows.Err()
+}
+
+// searchFrom adds the conditions of a text search and of a filter to a query, it returns the searched
+// tables and the expression of the scores. The Movies must contain one of the words and none of the
+// excluded words, the words of the names weigh more. ok is false when the search has no word.
+// PostgreSQL searches the search column of the movies, SQLite the movies_search table.
+func (m *SQLMovie) searchFrom(q *sqlQuery, text string, filter models.MovieFilter) (from string, score string, ok bool) {
+	terms := m.utils.SearchTerms(text)
+	if len(terms) == 0 {
+		return "", "", false
+	}
+	excluded := excludedTerms(m.utils, text)
+
+	if m.db.Storage == common.StoragePostgres {
+		query := "to_tsquery('simple', " + q.arg(tsQuery(terms, excluded)) + ")"
+		q.where("movies.search @@ " + query)
+		from, score = "movies", "ts_rank('{0, 0, 0.2, 1}'::REAL[], movies.search, "+query+")"
+	} else {
+		q.where("movies_search MATCH " + q.arg(fts5Query(terms, excluded)))
+		from, score = "movies JOIN movies_search ON movies_search.id = movies.id", "-bm25(movies_search, 0, 5, 1)"
+	}
+	movieFilter(q, filter)
+
+	return from, score, true
+}
+
+// tsQuery returns the PostgreSQL text query matching the words starting with one of the terms
+// and none of the excluded terms
+func tsQuery(terms []string, excluded []string) string {
+	quote := func(term string) string {
+		return "'" + strings.ReplaceAll(term, "'", "''") + "':*"
+	}
+
+	matches := make([]string, len(terms))
+	for i, term := range terms {
+		matches[i] = quote(term)
+	}
+	query := "(" + strings.Join(matches, " | ") + ")"
+	for _, term := range excluded {
+		query += " & !" + quote(term)
+	}
+
+	return query
+}
+
+// fts5Query returns the SQLite FTS5 query matching the words starting with one of the terms
+// and none of the excluded terms
+func fts5Query(terms []string, excluded []string) string {
+	quote := func(term string) string {
+		return `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+
+	matches := make([]string, len(terms))
+	for i, term := range terms {
+		matches[i] = quote(term)
+	}
+	query := "(" + strings.Join(matches, " OR ") + ")"
+	for _, term := range excluded {
+		query += " NOT " + quote(term)
+	}
+
+	return query
+}
+
+// SetCover stores the description of the cover image of a Movie, its cover image
+// url becomes the url of the cover API
+func (m *SQLMovie) SetCover(ctx context.Context, id string, cover models.MovieCover) error {
+	err := m.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	return sqlAffected(m.db.DB.ExecContext(ctx, "UPDATE movies SET cover_content_type = $1, cover_updated_at = $2, cover_image = $3 WHERE id = $4",
+		cover.ContentType, sqlTime{&cover.UpdatedAt}, "/api/v1/movies/"+id+"/cover", id))
+}
+
+// UpdateRating computes the aggregate of the Ratings of a Movie and stores it. The row of the
+// Movie is locked first, the concurrent updates wait for each other and the last one reads
+// all the Ratings.
+func (m *SQLMovie) UpdateRating(ctx context.Context, id models.ObjectID) error {
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	err := inTx(ctx, m.db.DB, func(tx *sql.Tx) error {
+		err := sqlAffected(tx.ExecContext(ctx, "UPDATE movies SET rating_count = rating_count WHERE id = $1", id.Hex()))
+		if err != nil {
+			return err
+		}
+
+		var average sql.NullFloat64
+		var rating models.MovieRating
+		err = tx.QueryRowContext(ctx, "SELECT AVG(score), COUNT(*) FROM ratings WHERE movie_id = $1", id.Hex()).Scan(&average, &rating.Count)
+		if err != nil {
+			return err
+		}
+		rating.Average = math.Round(average.Float64*100) / 100
+
+		_, err = tx.ExecContext(ctx, "UPDATE movies SET rating_average = $1, rating_count = $2 WHERE id = $3", rating.Average, rating.Count, id.Hex())
+		return err
+	})
+	if err == mongo.ErrNoDocuments {
+		// The Movie has been deleted meanwhile
+		err = nil
+	}
+	return err
+}
+
+// CountByGenre counts the Movies having the given Genre
+func (m *SQLMovie) CountByGenre(ctx context.Context, genreID models.ObjectID) (int, error) {
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	var count int
+	err := m.db.DB.QueryRowContext(ctx, "SELECT COUNT(DISTINCT movie_id) FROM movie_genres WHERE genre_id = $1", genreID.Hex()).Scan(&count)
+	return count, err
+}
+
+// RemoveGenre removes the given Genre from all Movies
+func (m *SQLMovie) RemoveGenre(ctx context.Context, genreID models.ObjectID) error {
+	ctx, cancel := m.db.Context(ctx)
+	defer cancel()
+
+	_, err := m.db.DB.ExecContext(ctx, "DELETE FROM movie_genres WHERE genre_id = $1", genreID.Hex())
+	return err
+}
@@ -0,0 +1,148 @@
+/*
+ * @File: daos.sqlrating.go
+ * @Description: Implements Rating CRUD functions for PostgreSQL and SQLite
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"../databases"
+	"../models"
+)
+
+// SQLRating manages Rating CRUD in the ratings table of a SQL database
+type SQLRating struct {
+	db *databases.SQL
+}
+
+// NewSQLRating creates a RatingRepository storing the Ratings in the given database
+func NewSQLRating(db *databases.SQL) *SQLRating {
+	return &SQLRating{db: db}
+}
+
+// ratingColumns maps the sorted fields of the Reviews to the columns of the ratings table
+var ratingColumns = map[string]string{
+	"_id":       "id",
+	"score":     "score",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+// ratingSelectColumns are the columns read by scanRating
+const ratingSelectColumns = "id, movie_id, user_id, user_name, score, review, moderation_hidden, moderation_reason, moderated_by, moderated_at, created_at, updated_at"
+
+// scanRating reads a Rating from a row of ratingSelectColumns
+func scanRating(row interface{ Scan(...interface{}) error }) (models.Rating, error) {
+	var rating models.Rating
+	var moderation models.ReviewModeration
+	var hidden sql.NullBool
+	var reason, moderatedBy sql.NullString
+	err := row.Scan(sqlID{&rating.ID}, sqlID{&rating.MovieID}, &rating.UserID, &rating.UserName, &rating.Score, &rating.Review,
+		&hidden, &reason, &moderatedBy, sqlTime{&moderation.ModeratedAt}, sqlTime{&rating.CreatedAt}, sqlTime{&rating.UpdatedAt})
+	if err == nil && hidden.Valid {
+		moderation.Hidden, moderation.Reason, moderation.ModeratedBy = hidden.Bool, reason.String, moderatedBy.String
+		rating.Moderation = &moderation
+	}
+	return rating, err
+}
+
+// Upsert adds or replaces the Rating of a Movie by a user, a user has one Rating per Movie
+func (r *SQLRating) Upsert(ctx context.Context, movieID models.ObjectID, userID string, userName string, addRating models.AddRating) (models.Rating, error) {
+	ctx, cancel := r.db.Context(ctx)
+	defer cancel()
+
+	now := time.Now()
+	rating, err := scanRating(r.db.DB.QueryRowContext(ctx, "INSERT INTO ratings (id, movie_id, user_id, user_name, score, review, created_at, updated_at) "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $7) ON CONFLICT (movie_id, user_id) DO UPDATE SET "+
+		"user_name = excluded.user_name, score = excluded.score, review = excluded.review, updated_at = excluded.updated_at "+
+		"RETURNING "+ratingSelectColumns,
+		models.NewObjectID().Hex(), movieID.Hex(), userID, userName, addRating.Score, addRating.Review, sqlTime{&now}))
+	return rating, err
+}
+
+// Delete removes the Rating of a Movie by a user
+func (r *SQLRating) Delete(ctx context.Context, movieID models.ObjectID, userID string) error {
+	ctx, cancel := r.db.Context(ctx)
+	defer cancel()
+
+	return sqlAffected(r.db.DB.ExecContext(ctx, "DELETE FROM ratings WHERE movie_id = $1 AND user_id = $2", movieID.Hex(), userID))
+}
+
+// DeleteByMovie removes all Ratings of a Movie
+func (r *SQLRating) DeleteByMovie(ctx context.Context, movieID models.ObjectID) error {
+	ctx, cancel := r.db.Context(ctx)
+	defer cancel()
+
+	_, err := r.db.DB.ExecContext(ctx, "DELETE FROM ratings WHERE movie_id = $1", movieID.Hex())
+	return err
+}
+
+// GetReviews gets a page of the visible reviews of a Movie, with the cursor of the next page and the total count
+func (r *SQLRating) GetReviews(ctx context.Context, movieID models.ObjectID, query models.PageQuery) ([]models.Rating, string, int, error) {
+	ctx, cancel := r.db.Context(ctx)
+	defer cancel()
+
+	q := &sqlQuery{}
+	q.where("movie_id = " + q.arg(movieID))
+	q.where("review <> ''")
+	q.where("moderation_hidden IS NOT TRUE")
+
+	total, err := q.count(ctx, r.db.DB, "ratings")
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	order, field, err := q.page(query, ratingColumns, reviewSortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	ratings, err := r.find(ctx, "SELECT "+ratingSelectColumns+" FROM ratings"+q.clause()+order, q.args...)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var next string
+	if len(ratings) > query.Limit {
+		ratings = ratings[:query.Limit]
+		next, err = nextPageCursor(ratings[len(ratings)-1], field)
+	}
+
+	return ratings, next, total, err
+}
+
+// find reads the Ratings selected by a query
+func (r *SQLRating) find(ctx context.Context, query string, args ...interface{}) ([]models.Rating, error) {
+	rows, err := r.db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ratings := []models.Rating{}
+	for rows.Next() {
+		var rating models.Rating
+		rating, err = scanRating(rows)
+		if err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, rating)
+	}
+
+	return ratings, rows.Err()
+}
+
+// Moderate sets the moderation of a review of a Movie
+func (r *SQLRating) Moderate(ctx context.Context, movieID models.ObjectID, id models.ObjectID, moderation models.ReviewModeration) (models.Rating, error) {
+	ctx, cancel := r.db.Context(ctx)
+	defer cancel()
+
+	rating, err := scanRating(r.db.DB.QueryRowContext(ctx, "UPDATE ratings SET moderation_hidden = $1, moderation_reason = $2, moderated_by = $3, moderated_at = $4 "+
+		"WHERE id = $5 AND movie_id = $6 RETURNING "+ratingSelectColumns,
+		moderation.Hidden, moderation.Reason, moderation.ModeratedBy, sqlTime{&moderation.ModeratedAt}, id.Hex(), movieID.Hex()))
+	return rating, sqlFound(err)
+}
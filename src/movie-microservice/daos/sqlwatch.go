@@ -0,0 +1,221 @@
+/*
+ * @File: daos.sqlwatch.go
+ * @Description: Implements Watchlist and Watch History CRUD functions for PostgreSQL and SQLite
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"time"
+
+	"../databases"
+	"../models"
+)
+
+// SQLWatchlist manages the Watchlists of the users in the watchlists table of a SQL database
+type SQLWatchlist struct {
+	db *databases.SQL
+}
+
+// NewSQLWatchlist creates a WatchlistRepository storing the Watchlists in the given database
+func NewSQLWatchlist(db *databases.SQL) *SQLWatchlist {
+	return &SQLWatchlist{db: db}
+}
+
+// watchlistColumns maps the sorted fields of the Watchlists to the columns of the watchlists table
+var watchlistColumns = map[string]string{
+	"_id":     "id",
+	"addedAt": "added_at",
+}
+
+// watchlistSelect selects the columns read by scanWatchlistItem
+const watchlistSelect = "SELECT id, user_id, movie_id, added_at FROM watchlists"
+
+// scanWatchlistItem reads a WatchlistItem from a row of watchlistSelect
+func scanWatchlistItem(row interface{ Scan(...interface{}) error }) (models.WatchlistItem, error) {
+	var item models.WatchlistItem
+	err := row.Scan(sqlID{&item.ID}, &item.UserID, sqlID{&item.MovieID}, sqlTime{&item.AddedAt})
+	return item, err
+}
+
+// Add adds a Movie to the Watchlist of a user, adding it again keeps the first one
+func (w *SQLWatchlist) Add(ctx context.Context, userID string, movieID models.ObjectID) (models.WatchlistItem, error) {
+	ctx, cancel := w.db.Context(ctx)
+	defer cancel()
+
+	now := time.Now()
+	_, err := w.db.DB.ExecContext(ctx, "INSERT INTO watchlists (id, user_id, movie_id, added_at) VALUES ($1, $2, $3, $4) ON CONFLICT (user_id, movie_id) DO NOTHING",
+		models.NewObjectID().Hex(), userID, movieID.Hex(), sqlTime{&now})
+	if err != nil {
+		return models.WatchlistItem{}, err
+	}
+
+	return scanWatchlistItem(w.db.DB.QueryRowContext(ctx, watchlistSelect+" WHERE user_id = $1 AND movie_id = $2", userID, movieID.Hex()))
+}
+
+// Remove removes a Movie from the Watchlist of a user
+func (w *SQLWatchlist) Remove(ctx context.Context, userID string, movieID models.ObjectID) error {
+	ctx, cancel := w.db.Context(ctx)
+	defer cancel()
+
+	return sqlAffected(w.db.DB.ExecContext(ctx, "DELETE FROM watchlists WHERE user_id = $1 AND movie_id = $2", userID, movieID.Hex()))
+}
+
+// GetPage gets a page of the Watchlist of a user, with the cursor of the next page and the total count
+func (w *SQLWatchlist) GetPage(ctx context.Context, userID string, query models.PageQuery) ([]models.WatchlistItem, string, int, error) {
+	ctx, cancel := w.db.Context(ctx)
+	defer cancel()
+
+	q := &sqlQuery{}
+	q.where("user_id = " + q.arg(userID))
+
+	total, err := q.count(ctx, w.db.DB, "watchlists")
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	order, field, err := q.page(query, watchlistColumns, watchlistSortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	rows, err := w.db.DB.QueryContext(ctx, watchlistSelect+q.clause()+order, q.args...)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	defer rows.Close()
+
+	items := []models.WatchlistItem{}
+	for rows.Next() {
+		var item models.WatchlistItem
+		item, err = scanWatchlistItem(rows)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", 0, err
+	}
+
+	var next string
+	if len(items) > query.Limit {
+		items = items[:query.Limit]
+		next, err = nextPageCursor(items[len(items)-1], field)
+	}
+
+	return items, next, total, err
+}
+
+// DeleteByMovie removes a Movie from all Watchlists
+func (w *SQLWatchlist) DeleteByMovie(ctx context.Context, movieID models.ObjectID) error {
+	ctx, cancel := w.db.Context(ctx)
+	defer cancel()
+
+	_, err := w.db.DB.ExecContext(ctx, "DELETE FROM watchlists WHERE movie_id = $1", movieID.Hex())
+	return err
+}
+
+// SQLHistory manages the Watch History of the users in the watch_history table of a SQL database
+type SQLHistory struct {
+	db *databases.SQL
+}
+
+// NewSQLHistory creates a HistoryRepository storing the Watch Histories in the given database
+func NewSQLHistory(db *databases.SQL) *SQLHistory {
+	return &SQLHistory{db: db}
+}
+
+// historyColumns maps the sorted fields of the Watch Histories to the columns of the watch_history table
+var historyColumns = map[string]string{
+	"_id":       "id",
+	"updatedAt": "updated_at",
+}
+
+// historySelectColumns are the columns read by scanWatch
+const historySelectColumns = "id, user_id, movie_id, position, duration, completed, updated_at"
+
+// scanWatch reads a Watch from a row of historySelectColumns
+func scanWatch(row interface{ Scan(...interface{}) error }) (models.Watch, error) {
+	var watch models.Watch
+	err := row.Scan(sqlID{&watch.ID}, &watch.UserID, sqlID{&watch.MovieID}, &watch.Position, &watch.Duration, &watch.Completed, sqlTime{&watch.UpdatedAt})
+	return watch, err
+}
+
+// Save stores the playback progress of a Movie by a user
+func (h *SQLHistory) Save(ctx context.Context, userID string, movieID models.ObjectID, progress models.WatchProgress) (models.Watch, error) {
+	ctx, cancel := h.db.Context(ctx)
+	defer cancel()
+
+	now := time.Now()
+	return scanWatch(h.db.DB.QueryRowContext(ctx, "INSERT INTO watch_history ("+historySelectColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7) "+
+		"ON CONFLICT (user_id, movie_id) DO UPDATE SET "+
+		"position = excluded.position, duration = excluded.duration, completed = excluded.completed, updated_at = excluded.updated_at "+
+		"RETURNING "+historySelectColumns,
+		models.NewObjectID().Hex(), userID, movieID.Hex(), progress.Position, progress.Duration, progress.Completed, sqlTime{&now}))
+}
+
+// Get finds the playback progress of a Movie by a user
+func (h *SQLHistory) Get(ctx context.Context, userID string, movieID models.ObjectID) (models.Watch, error) {
+	ctx, cancel := h.db.Context(ctx)
+	defer cancel()
+
+	watch, err := scanWatch(h.db.DB.QueryRowContext(ctx, "SELECT "+historySelectColumns+" FROM watch_history WHERE user_id = $1 AND movie_id = $2", userID, movieID.Hex()))
+	return watch, sqlFound(err)
+}
+
+// GetPage gets a page of the Watch History of a user, with the cursor of the next page and the total count
+func (h *SQLHistory) GetPage(ctx context.Context, userID string, query models.PageQuery) ([]models.Watch, string, int, error) {
+	ctx, cancel := h.db.Context(ctx)
+	defer cancel()
+
+	q := &sqlQuery{}
+	q.where("user_id = " + q.arg(userID))
+
+	total, err := q.count(ctx, h.db.DB, "watch_history")
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	order, field, err := q.page(query, historyColumns, historySortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	rows, err := h.db.DB.QueryContext(ctx, "SELECT "+historySelectColumns+" FROM watch_history"+q.clause()+order, q.args...)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	defer rows.Close()
+
+	watches := []models.Watch{}
+	for rows.Next() {
+		var watch models.Watch
+		watch, err = scanWatch(rows)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		watches = append(watches, watch)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", 0, err
+	}
+
+	var next string
+	if len(watches) > query.Limit {
+		watches = watches[:query.Limit]
+		next, err = nextPageCursor(watches[len(watches)-1], field)
+	}
+
+	return watches, next, total, err
+}
+
+// DeleteByMovie removes a Movie from all Watch Histories
+func (h *SQLHistory) DeleteByMovie(ctx context.Context, movieID models.ObjectID) error {
+	ctx, cancel := h.db.Context(ctx)
+	defer cancel()
+
+	_, err := h.db.DB.ExecContext(ctx, "DELETE FROM watch_history WHERE movie_id = $1", movieID.Hex())
+	return err
+}
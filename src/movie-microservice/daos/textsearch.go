@@ -0,0 +1,134 @@
+/*
+ * @File: daos.textsearch.go
+ * @Description: Implements the text search of the Movies kept in memory
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"../models"
+	"../utils"
+)
+
+// searchMovies scores the Movies for a text search, the matched Movies are returned with the best matches first.
+// The words are matched like the text index does, except that the phrases are matched word by word
+// and the scores only count the matched words, the words of the names weigh more.
+// The Movies of equal scores keep their order.
+func searchMovies(u *utils.Utils, text string, movies []models.Movie) []models.MovieSearchHit {
+	hits := []models.MovieSearchHit{}
+	for _, movie := range movies {
+		if score := textScore(u, text, movie); score > 0 {
+			hits = append(hits, models.MovieSearchHit{Movie: movie, Score: score})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+
+	return hits
+}
+
+// pageHits returns the requested page of the matched Movies
+func pageHits(hits []models.MovieSearchHit, limit int, offset int) []models.MovieSearchHit {
+	if offset >= len(hits) {
+		return []models.MovieSearchHit{}
+	}
+	hits = hits[offset:]
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+// countFacets counts the matched Movies per Genre and per year. The Genres are named by
+// the given names and sorted by count, then by name and id like in the databases.
+func countFacets(hits []models.MovieSearchHit, names map[models.ObjectID]string) models.MovieFacets {
+	genres := make(map[models.ObjectID]int)
+	years := make(map[int]int)
+	for _, hit := range hits {
+		for _, genre := range hit.Movie.Genres {
+			genres[genre]++
+		}
+		if hit.Movie.Year > 0 {
+			years[hit.Movie.Year]++
+		}
+	}
+
+	facets := models.MovieFacets{Genres: []models.GenreFacet{}, Years: []models.YearFacet{}}
+	for id, count := range genres {
+		facets.Genres = append(facets.Genres, models.GenreFacet{ID: id, Name: names[id], Count: count})
+	}
+	for year, count := range years {
+		facets.Years = append(facets.Years, models.YearFacet{year, count})
+	}
+
+	sort.Slice(facets.Genres, func(i, j int) bool {
+		if facets.Genres[i].Count != facets.Genres[j].Count {
+			return facets.Genres[i].Count > facets.Genres[j].Count
+		}
+		if facets.Genres[i].Name != facets.Genres[j].Name {
+			return facets.Genres[i].Name < facets.Genres[j].Name
+		}
+		return facets.Genres[i].ID.Hex() < facets.Genres[j].ID.Hex()
+	})
+	sort.Slice(facets.Years, func(i, j int) bool {
+		return facets.Years[i].Year > facets.Years[j].Year
+	})
+
+	return facets
+}
+
+// textScore scores a Movie for a text search, the Movies having an excluded word or none of the words score 0
+func textScore(u *utils.Utils, text string, movie models.Movie) float64 {
+	nameWords, descriptionWords := textWords(movie.Name), textWords(movie.Description)
+
+	for _, excluded := range excludedTerms(u, text) {
+		if countWords(nameWords, excluded)+countWords(descriptionWords, excluded) > 0 {
+			return 0
+		}
+	}
+
+	var score float64
+	for _, term := range u.SearchTerms(text) {
+		score += float64(5*countWords(nameWords, term) + countWords(descriptionWords, term))
+	}
+
+	return score
+}
+
+// excludedTerms returns the lower case terms of the excluded (-word) words of a text search
+func excludedTerms(u *utils.Utils, text string) []string {
+	var terms []string
+	for _, field := range strings.Fields(text) {
+		if strings.HasPrefix(field, "-") {
+			terms = append(terms, u.SearchTerms(field[1:])...)
+		}
+	}
+
+	return terms
+}
+
+// textWords returns the lower case words of a text
+func textWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// countWords counts the words starting with the term
+func countWords(words []string, term string) int {
+	count := 0
+	for _, word := range words {
+		if strings.HasPrefix(word, term) {
+			count++
+		}
+	}
+
+	return count
+}
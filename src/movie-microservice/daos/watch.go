@@ -0,0 +1,173 @@
+/*
+ * @File: daos.watch.go
+ * @Description: Implements Watchlist and Watch History CRUD functions for MongoDB
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"time"
+
+	"../common"
+	"../databases"
+	"../models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Watchlist manages the Watchlists of the users
+type Watchlist struct {
+}
+
+// Sort fields of the Watchlists and of the Watch Histories
+var (
+	watchlistSortFields = []string{"addedAt"}
+	historySortFields   = []string{"updatedAt"}
+)
+
+// Add adds a Movie to the Watchlist of a user, adding it again keeps the first one
+func (w *Watchlist) Add(ctx context.Context, userID string, movieID models.ObjectID) (models.WatchlistItem, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColWatchlists)
+
+	update := bson.M{"$setOnInsert": bson.M{"addedAt": time.Now()}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var item models.WatchlistItem
+	selector := bson.M{"userId": userID, "movieId": movieID}
+	err := collection.FindOneAndUpdate(ctx, selector, update, opts).Decode(&item)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent request has inserted the item first
+		err = collection.FindOne(ctx, selector).Decode(&item)
+	}
+	return item, err
+}
+
+// Remove removes a Movie from the Watchlist of a user
+func (w *Watchlist) Remove(ctx context.Context, userID string, movieID models.ObjectID) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColWatchlists)
+
+	return deleted(collection.DeleteOne(ctx, bson.M{"userId": userID, "movieId": movieID}))
+}
+
+// GetPage gets a page of the Watchlist of a user, with the cursor of the next page and the total count
+func (w *Watchlist) GetPage(ctx context.Context, userID string, query models.PageQuery) ([]models.WatchlistItem, string, int, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColWatchlists)
+
+	raws, next, total, err := findPage(ctx, collection, bson.M{"userId": userID}, query, watchlistSortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	items := make([]models.WatchlistItem, len(raws))
+	for i, raw := range raws {
+		if err = bson.Unmarshal(raw, &items[i]); err != nil {
+			return nil, "", 0, err
+		}
+	}
+
+	return items, next, total, nil
+}
+
+// DeleteByMovie removes a Movie from all Watchlists
+func (w *Watchlist) DeleteByMovie(ctx context.Context, movieID models.ObjectID) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColWatchlists)
+
+	_, err := collection.DeleteMany(ctx, bson.M{"movieId": movieID})
+	return err
+}
+
+// History manages the Watch History of the users
+type History struct {
+}
+
+// Save stores the playback progress of a Movie by a user
+func (h *History) Save(ctx context.Context, userID string, movieID models.ObjectID, progress models.WatchProgress) (models.Watch, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColWatchHistory)
+
+	update := bson.M{"$set": bson.M{
+		"position":  progress.Position,
+		"duration":  progress.Duration,
+		"completed": progress.Completed,
+		"updatedAt": time.Now(),
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var watch models.Watch
+	selector := bson.M{"userId": userID, "movieId": movieID}
+	err := collection.FindOneAndUpdate(ctx, selector, update, opts).Decode(&watch)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent request has inserted the progress first, update it
+		err = collection.FindOneAndUpdate(ctx, selector, update, opts).Decode(&watch)
+	}
+	return watch, err
+}
+
+// Get finds the playback progress of a Movie by a user
+func (h *History) Get(ctx context.Context, userID string, movieID models.ObjectID) (models.Watch, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColWatchHistory)
+
+	var watch models.Watch
+	err := collection.FindOne(ctx, bson.M{"userId": userID, "movieId": movieID}).Decode(&watch)
+	return watch, err
+}
+
+// GetPage gets a page of the Watch History of a user, with the cursor of the next page and the total count
+func (h *History) GetPage(ctx context.Context, userID string, query models.PageQuery) ([]models.Watch, string, int, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColWatchHistory)
+
+	raws, next, total, err := findPage(ctx, collection, bson.M{"userId": userID}, query, historySortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	watches := make([]models.Watch, len(raws))
+	for i, raw := range raws {
+		if err = bson.Unmarshal(raw, &watches[i]); err != nil {
+			return nil, "", 0, err
+		}
+	}
+
+	return watches, next, total, nil
+}
+
+// DeleteByMovie removes a Movie from all Watch Histories
+func (h *History) DeleteByMovie(ctx context.Context, movieID models.ObjectID) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColWatchHistory)
+
+	_, err := collection.DeleteMany(ctx, bson.M{"movieId": movieID})
+	return err
+}
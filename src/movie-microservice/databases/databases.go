@@ -7,5 +7,9 @@ package databases
 
 // Database shares global database instance
 var (
+	// Database stores the movies, their genres, ratings and the watches of the users when the storage is mongodb
 	Database MongoDB
+
+	// SQLDatabase stores them when the storage is postgres or sqlite
+	SQLDatabase SQL
 )
@@ -0,0 +1,7 @@
+-- The text search of the movies matches the words of their names and descriptions,
+-- the names weigh more like in the MongoDB text index
+ALTER TABLE movies ADD COLUMN search TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', name), 'A') || setweight(to_tsvector('simple', description), 'B')
+) STORED;
+
+CREATE INDEX movies_search ON movies USING GIN (search);
@@ -0,0 +1,34 @@
+-- The ids are the hex strings of the ObjectIds, like in MongoDB.
+-- The times are stored as text, see databases.SQLTimeLayout.
+CREATE TABLE genres (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL
+);
+
+CREATE INDEX genres_name ON genres (LOWER(name));
+
+CREATE TABLE movies (
+    id                 TEXT PRIMARY KEY,
+    name               TEXT NOT NULL,
+    url                TEXT NOT NULL,
+    cover_image        TEXT NOT NULL,
+    description        TEXT NOT NULL,
+    year               INTEGER NOT NULL,
+    rating_average     REAL NOT NULL,
+    rating_count       INTEGER NOT NULL,
+    cover_content_type TEXT,
+    cover_updated_at   TEXT
+);
+
+CREATE INDEX movies_name ON movies (name);
+
+-- The genres of a movie, in their order
+CREATE TABLE movie_genres (
+    movie_id TEXT NOT NULL,
+    genre_id TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (movie_id, position)
+);
+
+CREATE INDEX movie_genres_genre_id ON movie_genres (genre_id);
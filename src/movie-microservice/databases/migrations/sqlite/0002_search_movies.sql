@@ -0,0 +1,17 @@
+-- The text search of the movies matches the words of their names and descriptions,
+-- the triggers keep the index of the movies up to date
+CREATE VIRTUAL TABLE movies_search USING fts5(id UNINDEXED, name, description);
+
+INSERT INTO movies_search (id, name, description) SELECT id, name, description FROM movies;
+
+CREATE TRIGGER movies_search_insert AFTER INSERT ON movies BEGIN
+    INSERT INTO movies_search (id, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+CREATE TRIGGER movies_search_update AFTER UPDATE OF name, description ON movies BEGIN
+    UPDATE movies_search SET name = new.name, description = new.description WHERE id = old.id;
+END;
+
+CREATE TRIGGER movies_search_delete AFTER DELETE ON movies BEGIN
+    DELETE FROM movies_search WHERE id = old.id;
+END;
@@ -0,0 +1,45 @@
+-- The ratings and the reviews of the movies, a user rates a movie once.
+-- The moderation columns are NULL until the review is moderated, the booleans are stored as 0 or 1.
+CREATE TABLE ratings (
+    id                TEXT PRIMARY KEY,
+    movie_id          TEXT NOT NULL,
+    user_id           TEXT NOT NULL,
+    user_name         TEXT NOT NULL,
+    score             INTEGER NOT NULL,
+    review            TEXT NOT NULL,
+    moderation_hidden INTEGER,
+    moderation_reason TEXT,
+    moderated_by      TEXT,
+    moderated_at      TEXT,
+    created_at        TEXT NOT NULL,
+    updated_at        TEXT NOT NULL,
+    UNIQUE (movie_id, user_id)
+);
+
+-- Recommendations read the ratings of the users
+CREATE INDEX ratings_user_id ON ratings (user_id);
+
+-- A movie is once in the watchlist and the watch history of a user
+CREATE TABLE watchlists (
+    id       TEXT PRIMARY KEY,
+    user_id  TEXT NOT NULL,
+    movie_id TEXT NOT NULL,
+    added_at TEXT NOT NULL,
+    UNIQUE (user_id, movie_id)
+);
+
+CREATE INDEX watchlists_movie_id ON watchlists (movie_id);
+
+CREATE TABLE watch_history (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    movie_id   TEXT NOT NULL,
+    position   INTEGER NOT NULL,
+    duration   INTEGER NOT NULL,
+    completed  INTEGER NOT NULL,
+    updated_at TEXT NOT NULL,
+    UNIQUE (user_id, movie_id)
+);
+
+-- Recommendations count the watches of the movies
+CREATE INDEX watch_history_movie_id ON watch_history (movie_id);
@@ -6,48 +6,215 @@
 package databases
 
 import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"../common"
+	"../metrics"
 	log "github.com/sirupsen/logrus"
-	mgo "gopkg.in/mgo.v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
+// connectTimeout bounds the connection to the MongoDB deployment when the service starts
+const connectTimeout = 60 * time.Second
+
+// maxConnectWait bounds the wait between the connection attempts when the service waits for MongoDB
+const maxConnectWait = 30 * time.Second
+
 // MongoDB manages MongoDB connection
 type MongoDB struct {
-	MgDbSession  *mgo.Session
+	Client       *mongo.Client
 	Databasename string
+	connected    int32
 }
 
-// Init initializes mongo database
+// Init creates the client of the MongoDB deployment, the client connects in the background
 func (db *MongoDB) Init() error {
-	db.Databasename = common.Config.MgDbName
+	db.Databasename = common.Config().MgDbName
 
-	// DialInfo holds options for establishing a session with a MongoDB cluster.
-	dialInfo := &mgo.DialInfo{
-		Addrs:    []string{common.Config.MgAddrs}, // Get HOST + PORT
-		Timeout:  60 * time.Second,
-		Database: db.Databasename,            // Database name
-		Username: common.Config.MgDbUsername, // Username
-		Password: common.Config.MgDbPassword, // Password
+	clientOptions, err := newClientOptions()
+	if err != nil {
+		return err
 	}
 
-	// Create a session which maintains a pool of socket connections
-	// to the DB MongoDB database.
-	var err error
-	db.MgDbSession, err = mgo.DialWithInfo(dialInfo)
+	db.Client, err = mongo.Connect(context.Background(), clientOptions)
+	return err
+}
 
+// Connect waits for the MongoDB deployment, then initializes the database. Ping reports
+// the deployment as unavailable until it is connected.
+func (db *MongoDB) Connect() error {
+	err := db.wait()
 	if err != nil {
 		log.Debug("Can't connect to mongo, go error: ", err)
 		return err
 	}
 
+	err = db.initIndexes()
+	if err != nil {
+		return err
+	}
+
+	atomic.StoreInt32(&db.connected, 1)
+	log.Info("Connected to MongoDB")
+	return nil
+}
+
+// wait pings the MongoDB deployment. When it is unavailable, the ping is retried
+// until mgStartupTimeout seconds are elapsed, so that the service waits for MongoDB.
+func (db *MongoDB) wait() error {
+	deadline := time.Now().Add(time.Duration(common.Config().MgStartupTimeout) * time.Second)
+	wait := time.Second
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+		err := db.Client.Ping(ctx, nil)
+		cancel()
+		if err == nil || time.Now().Add(wait).After(deadline) {
+			return err
+		}
+
+		log.Warn("MongoDB is unavailable, retrying in ", wait, ", go error: ", err)
+		time.Sleep(wait)
+
+		wait *= 2
+		if wait > maxConnectWait {
+			wait = maxConnectWait
+		}
+	}
+}
+
+// newClientOptions returns the connection, pool and concern options of the configuration
+func newClientOptions() (*options.ClientOptions, error) {
+	clientOptions := options.Client().
+		SetHosts(strings.Split(common.Config().MgAddrs, ",")). // Get HOST + PORT
+		SetConnectTimeout(connectTimeout).
+		SetMaxPoolSize(common.Config().MgMaxPoolSize).
+		SetMinPoolSize(common.Config().MgMinPoolSize).
+		SetMaxConnIdleTime(time.Duration(common.Config().MgMaxConnIdleTime) * time.Second).
+		SetPoolMonitor(metrics.PoolMonitor())
+
+	if len(common.Config().MgDbUsername) > 0 {
+		// The users are authenticated by the database of the service
+		clientOptions.SetAuth(options.Credential{
+			AuthSource: common.Config().MgDbName,
+			Username:   common.Config().MgDbUsername,
+			Password:   common.Config().MgDbPassword,
+		})
+	}
+
+	// The concerns of the deployment are used when they are not configured
+	if len(common.Config().MgReadConcern) > 0 {
+		clientOptions.SetReadConcern(&readconcern.ReadConcern{Level: common.Config().MgReadConcern})
+	}
+
+	switch w := common.Config().MgWriteConcern; {
+	case len(w) == 0:
+	case w == "majority":
+		clientOptions.SetWriteConcern(writeconcern.Majority())
+	default:
+		nodes, err := strconv.Atoi(w)
+		if err != nil || nodes < 0 {
+			return nil, errors.New(common.ErrMgWriteConcernInvalid)
+		}
+		clientOptions.SetWriteConcern(&writeconcern.WriteConcern{W: nodes})
+	}
+
+	mode, err := readpref.ModeFromString(common.Config().MgReadPreference)
+	if err != nil {
+		return nil, errors.New(common.ErrMgReadPreferenceInvalid)
+	}
+	readPreference, err := readpref.New(mode)
+	if err != nil {
+		return nil, err
+	}
+	clientOptions.SetReadPreference(readPreference)
+
+	return clientOptions, clientOptions.Validate()
+}
+
+// Context returns the context of a database call, it's canceled after the configured timeout
+func (db *MongoDB) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, time.Duration(common.Config().MgTimeout)*time.Second)
+}
+
+// Ping checks that the MongoDB deployment is connected and reachable
+func (db *MongoDB) Ping(ctx context.Context) error {
+	if atomic.LoadInt32(&db.connected) == 0 {
+		return errors.New(common.ErrMgConnecting)
+	}
+
+	ctx, cancel := db.Context(ctx)
+	defer cancel()
+
+	return db.Client.Ping(ctx, nil)
+}
+
+// Collection returns a collection of the database of the service
+func (db *MongoDB) Collection(name string) *mongo.Collection {
+	return db.Client.Database(db.Databasename).Collection(name)
+}
+
+// initIndexes creates the indexes of the collections
+func (db *MongoDB) initIndexes() error {
+	ctx, cancel := db.Context(context.Background())
+	defer cancel()
+
+	movies := db.Collection(common.ColMovies).Indexes()
+	_, err := movies.CreateMany(ctx, []mongo.IndexModel{
+		// Movies are filtered by their genres
+		{Keys: bson.D{{Key: "genres", Value: 1}}},
+
+		// Movies are searched by the words of their names and descriptions, the names matter more
+		{
+			Keys:    bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().SetName("movies_text").SetWeights(bson.M{"name": 5, "description": 1}),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	ratings := db.Collection(common.ColRatings).Indexes()
+	_, err = ratings.CreateMany(ctx, []mongo.IndexModel{
+		// A user rates a movie once
+		{Keys: bson.D{{Key: "movieId", Value: 1}, {Key: "userId", Value: 1}}, Options: options.Index().SetUnique(true)},
+
+		// Recommendations read the ratings of the users
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// A movie is once in the watchlist and the watch history of a user
+	for _, name := range []string{common.ColWatchlists, common.ColWatchHistory} {
+		_, err = db.Collection(name).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "movieId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Recommendations count the watches of the movies
+	_, err = db.Collection(common.ColWatchHistory).Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "movieId", Value: 1}}})
 	return err
 }
 
 // Close the existing connection
 func (db *MongoDB) Close() {
-	if db.MgDbSession != nil {
-		db.MgDbSession.Close()
+	if db.Client != nil {
+		db.Client.Disconnect(context.Background())
 	}
 }
@@ -6,14 +6,28 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"./common"
 	"./controllers"
+	"./daos"
 	"./databases"
-	"github.com/gin-gonic/contrib/jwt"
+	"./middlewares"
+	"./storage"
+	"./tracing"
+	"./utils"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
 
 	_ "./docs"
 	"github.com/swaggo/gin-swagger"
@@ -25,35 +39,117 @@ type Main struct {
 	router *gin.Engine
 }
 
-func (m *Main) initServer() error {
+func (m *Main) initServer(configFiles []string) error {
 	var err error
-	// Load config file
-	err = common.LoadConfig()
+	// Load the configuration
+	err = common.LoadConfig(configFiles)
 	if err != nil {
 		return err
 	}
 
-	// Initialize mongo database
-	err = databases.Database.Init()
+	// Reload the configuration on SIGHUP and when the config files are modified
+	common.WatchConfig(common.ConfigWatchInterval)
+
+	// Trace the requests
+	err = tracing.Init()
+	if err != nil {
+		return err
+	}
+
+	// Initialize the database storing the movies, their genres, ratings and the watches of the users
+	if common.Config().Storage == common.StorageMongoDB {
+		err = databases.Database.Init()
+	} else {
+		err = databases.SQLDatabase.Init()
+	}
+	if err != nil {
+		return err
+	}
+
+	// Initialize the storage of the uploaded files
+	err = storage.Init()
 	if err != nil {
 		return err
 	}
 
 	// Setting Gin Logger
-	if common.Config.EnableGinFileLog {
+	if common.Config().EnableGinFileLog {
 		f, _ := os.Create("logs/gin.log")
-		if common.Config.EnableGinConsoleLog {
+		if common.Config().EnableGinConsoleLog {
 			gin.DefaultWriter = io.MultiWriter(os.Stdout, f)
 		} else {
 			gin.DefaultWriter = io.MultiWriter(f)
 		}
 	} else {
-		if !common.Config.EnableGinConsoleLog {
+		if !common.Config().EnableGinConsoleLog {
 			gin.DefaultWriter = io.MultiWriter()
 		}
 	}
 
+	// Register the validation rules of the request models
+	utils.RegisterValidations()
+
 	m.router = gin.Default()
+	// Trace the requests, the spans of the handlers are the children of their span
+	m.router.Use(middlewares.Tracing())
+	// Record the metrics of the requests, with the status codes of the errors
+	m.router.Use(middlewares.Metrics())
+	// Render the errors of the APIs with the error catalog
+	m.router.Use(middlewares.Errors())
+
+	return nil
+}
+
+// serve serves the APIs until the service receives SIGINT or SIGTERM. The server then stops accepting
+// connections and drains the requests in flight, they are canceled after shutdownTimeout seconds.
+// connect runs once the server listens, so that the probes respond while the databases are connecting,
+// the service stops when it fails.
+func (m *Main) serve(connect func() error) error {
+	config := common.Config()
+	server := &http.Server{
+		Addr:         config.Port,
+		Handler:      m.router,
+		ReadTimeout:  time.Duration(config.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(config.WriteTimeout) * time.Second,
+		IdleTimeout:  time.Duration(config.IdleTimeout) * time.Second,
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	listener, err := net.Listen("tcp", config.Port)
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, 2)
+	go func() {
+		log.Info("Listening and serving HTTP on ", config.Port)
+		errs <- server.Serve(listener)
+	}()
+	go func() {
+		if err := connect(); err != nil {
+			errs <- err
+		}
+	}()
+
+	select {
+	case err = <-errs:
+		server.Close()
+		return err
+	case sig := <-stop:
+		log.Info(sig, " received, draining the requests in flight")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(common.Config().ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	err = server.Shutdown(ctx)
+	if err != nil {
+		log.Warn("Requests in flight are canceled, go error: ", err)
+		server.Close()
+	}
+	log.Info("Server is stopped")
 
 	return nil
 }
@@ -66,28 +162,99 @@ func (m *Main) initServer() error {
 // @host 107.113.53.47:8809
 // @BasePath /api/v1
 func main() {
+	var configFiles common.ConfigFiles
+	flag.Var(&configFiles, "config", "config file, repeat it to override the settings of the previous files")
+	flag.Parse()
+
 	m := Main{}
 
 	// Initialize server
-	if m.initServer() != nil {
-		return
+	err := m.initServer(configFiles)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to start the service:", err)
+		os.Exit(1)
 	}
 
-	defer databases.Database.Close()
+	var movies daos.MovieRepository = &daos.Movie{}
+	var genres daos.GenreRepository = &daos.Genre{}
+	var ratings daos.RatingRepository = &daos.Rating{}
+	var watchlists daos.WatchlistRepository = &daos.Watchlist{}
+	var histories daos.HistoryRepository = &daos.History{}
+	// Probes of the orchestrator and of the load balancer
+	checks := map[string]controllers.HealthCheck{"mongodb": databases.Database.Ping}
+	// MongoDB connects once the server listens, the SQL database is connected by initServer
+	connect := databases.Database.Connect
+	if common.Config().Storage != common.StorageMongoDB {
+		movies = daos.NewSQLMovie(&databases.SQLDatabase)
+		genres = daos.NewSQLGenre(&databases.SQLDatabase)
+		ratings = daos.NewSQLRating(&databases.SQLDatabase)
+		watchlists = daos.NewSQLWatchlist(&databases.SQLDatabase)
+		histories = daos.NewSQLHistory(&databases.SQLDatabase)
+		checks = map[string]controllers.HealthCheck{common.Config().Storage: databases.SQLDatabase.Ping}
+		connect = func() error { return nil }
+	}
+	// Time the calls of the repository
+	movies = daos.NewMeteredMovie(movies)
 
-	c := controllers.Movie{}
+	c := controllers.NewMovie(movies, genres, ratings, watchlists, histories, common.Config)
+	g := controllers.NewGenre(movies, genres)
+	r := controllers.NewRating(movies, ratings)
+	w := controllers.NewWatch(movies, watchlists, histories)
+	cv := controllers.NewCover(movies, common.Config)
 
 	// Simple group: v1
 	v1 := m.router.Group("/api/v1")
 	{
 		v1.POST("/login", c.Login)
+		v1.GET("/movies", c.ListMovies)
 		v1.GET("/movies/list", c.ListMovies)
+		v1.GET("/movies/search", c.SearchMovies)
+		v1.GET("/movies/:id", c.GetMovieByID)
+		v1.GET("/movies/:id/reviews", r.ListReviews)
+		v1.GET("/movies/:id/cover", cv.GetCover)
+		v1.GET("/genres/list", g.ListGenres)
+		v1.GET("/genres/:id", g.GetGenreByID)
 
 		// APIs need to use token string
-		v1.Use(jwt.Auth(common.Config.JwtSecretPassword))
-		v1.POST("/movies", c.AddMovie)
+		isRevoked := middlewares.NewRevocationChecker()
+		jwks := utils.NewJWKS()
+		v1.Use(middlewares.Auth(jwks.Keyfunc, isRevoked))
+		v1.POST("/movies", middlewares.RequireRole(common.RoleEditor), c.AddMovie)
+		v1.PUT("/movies/:id", middlewares.RequireRole(common.RoleEditor), c.ReplaceMovie)
+		v1.PATCH("/movies/:id", middlewares.RequireRole(common.RoleEditor), c.UpdateMovie)
+		v1.DELETE("/movies/:id", middlewares.RequireRole(common.RoleEditor), c.DeleteMovieByID)
+		v1.POST("/movies/:id/cover", middlewares.RequireRole(common.RoleEditor), cv.UploadCover)
+		v1.POST("/movies/:id/ratings", middlewares.RequireRole(common.RoleViewer), r.RateMovie)
+		v1.DELETE("/movies/:id/ratings", middlewares.RequireRole(common.RoleViewer), r.DeleteRating)
+		v1.PATCH("/movies/:id/reviews/:reviewId", middlewares.RequireRole(common.RoleAdmin), r.ModerateReview)
+		v1.GET("/me/watchlist", middlewares.RequireRole(common.RoleViewer), w.ListWatchlist)
+		v1.POST("/me/watchlist", middlewares.RequireRole(common.RoleViewer), w.AddToWatchlist)
+		v1.DELETE("/me/watchlist/:movieId", middlewares.RequireRole(common.RoleViewer), w.RemoveFromWatchlist)
+		v1.GET("/me/history", middlewares.RequireRole(common.RoleViewer), w.ListHistory)
+		v1.GET("/me/history/:movieId", middlewares.RequireRole(common.RoleViewer), w.GetProgress)
+		v1.PUT("/me/history/:movieId", middlewares.RequireRole(common.RoleViewer), w.SaveProgress)
+		v1.POST("/genres", middlewares.RequireRole(common.RoleEditor), g.AddGenre)
+		v1.PUT("/genres/:id", middlewares.RequireRole(common.RoleEditor), g.UpdateGenre)
+		v1.DELETE("/genres/:id", middlewares.RequireRole(common.RoleEditor), g.DeleteGenreByID)
 	}
 
+	checks["auth"] = controllers.HTTPCheck(func() string { return common.Config().AuthAddr + "/readyz" })
+	h := controllers.NewHealth(checks)
+	m.router.GET("/healthz", h.Live)
+	m.router.GET("/readyz", h.Ready)
+
+	// Prometheus metrics
+	m.router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	m.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	m.router.Run(common.Config.Port)
+
+	// Serve the APIs until the service is stopped, then close the databases
+	err = m.serve(connect)
+	databases.SQLDatabase.Close()
+	databases.Database.Close()
+	tracing.Shutdown()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to serve the APIs:", err)
+		os.Exit(1)
+	}
 }
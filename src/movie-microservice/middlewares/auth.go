@@ -0,0 +1,77 @@
+/*
+ * @File: middlewares.auth.go
+ * @Description: Authenticates tokens and enforces role permissions of the APIs
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package middlewares
+
+import (
+	"context"
+
+	"../common"
+	"../models"
+	"../utils"
+	jwt_lib "github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/request"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// ClaimsKey is the context key of the authenticated token claims
+const ClaimsKey = "claims"
+
+// RevocationChecker tells if the token of the given id (jti) has been revoked
+type RevocationChecker func(ctx context.Context, id string) (bool, error)
+
+// Auth validates the token of the request and stores its claims in the context.
+// The keyfunc returns the public key verifying the token from its kid.
+func Auth(keyfunc jwt_lib.Keyfunc, isRevoked RevocationChecker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims := &utils.SdtClaims{}
+		_, err := request.ParseFromRequestWithClaims(ctx.Request, request.OAuth2Extractor, claims, keyfunc)
+
+		if err != nil {
+			abort(ctx, models.KindUnauthorized, common.ErrTokenInvalid)
+			return
+		}
+
+		if len(claims.Id) > 0 {
+			revoked, err := isRevoked(ctx.Request.Context(), claims.Id)
+			if err != nil {
+				log.Debug("[ERROR]: ", err)
+				abort(ctx, models.KindUpstreamUnavailable, common.ErrUpstreamUnavailable)
+				return
+			}
+			if revoked {
+				abort(ctx, models.KindUnauthorized, common.ErrTokenRevoked)
+				return
+			}
+		}
+
+		ctx.Set(ClaimsKey, claims)
+	}
+}
+
+// RequireRole rejects the requests whose token role doesn't include the given role.
+// It must be used after Auth.
+func RequireRole(role string) gin.HandlerFunc {
+	var u utils.Utils
+	return func(ctx *gin.Context) {
+		claims := Claims(ctx)
+		if claims == nil || !u.HasRole(claims.Role, role) {
+			abort(ctx, models.KindForbidden, common.ErrPermissionDenied)
+			return
+		}
+	}
+}
+
+// Claims returns the authenticated token claims of the request
+func Claims(ctx *gin.Context) *utils.SdtClaims {
+	value, exists := ctx.Get(ClaimsKey)
+	if !exists {
+		return nil
+	}
+
+	claims, _ := value.(*utils.SdtClaims)
+	return claims
+}
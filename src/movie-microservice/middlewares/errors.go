@@ -0,0 +1,64 @@
+/*
+ * @File: middlewares.errors.go
+ * @Description: Renders the errors of the APIs with the error catalog
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package middlewares
+
+import (
+	"context"
+	"errors"
+
+	"../common"
+	"../models"
+	"../utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Errors renders the error of a failed request. The handlers and the middlewares add their error
+// with ctx.Error and return without responding, the last error is rendered.
+func Errors() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+
+		err := Classify(ctx.Errors.Last().Err)
+		ctx.JSON(err.Kind.Status, err.Response())
+	}
+}
+
+// Classify returns the error of the catalog of an error. The errors of the binding tags list the
+// invalid fields, the errors of the DAOs are mapped to their kind, the messages of the database and
+// of the unknown errors are logged instead of being returned.
+func Classify(err error) *models.APIError {
+	var apiErr *models.APIError
+	var fieldErrs validator.ValidationErrors
+	switch {
+	case errors.As(err, &fieldErrs):
+		return models.NewValidationError(utils.FieldErrors(fieldErrs))
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return models.NewError(models.KindNotFound, common.ErrNotFound)
+	case mongo.IsDuplicateKeyError(err):
+		return models.NewError(models.KindDuplicate, common.ErrDuplicate)
+	case errors.Is(err, context.DeadlineExceeded), mongo.IsTimeout(err), mongo.IsNetworkError(err):
+		log.Debug("[ERROR]: ", err)
+		return models.NewError(models.KindUpstreamUnavailable, common.ErrUpstreamUnavailable)
+	default:
+		log.Debug("[ERROR]: ", err)
+		return models.NewError(models.KindInternal, common.ErrInternal)
+	}
+}
+
+// abort stops a request with an error of the catalog
+func abort(ctx *gin.Context, kind models.ErrorKind, message string) {
+	ctx.Error(models.NewError(kind, message))
+	ctx.Abort()
+}
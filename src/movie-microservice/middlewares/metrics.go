@@ -0,0 +1,31 @@
+/*
+ * @File: middlewares.metrics.go
+ * @Description: Records the metrics of the HTTP requests
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"../metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the count, the latency and the status code of the requests by route.
+// It must be used before the Errors middleware to see the status codes of the errors.
+func Metrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if len(route) == 0 {
+			route = metrics.UnmatchedRoute
+		}
+
+		metrics.HTTPRequests.WithLabelValues(route, ctx.Request.Method, strconv.Itoa(ctx.Writer.Status())).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(route, ctx.Request.Method).Observe(time.Since(start).Seconds())
+	}
+}
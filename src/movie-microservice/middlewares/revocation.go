@@ -0,0 +1,81 @@
+/*
+ * @File: middlewares.revocation.go
+ * @Description: Checks token revocations against the user service
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"../common"
+	"../models"
+	"../tracing"
+)
+
+// maxRevocationEntries bounds the cache before the expired entries are pruned
+const maxRevocationEntries = 10000
+
+type revocationEntry struct {
+	revoked bool
+	expires time.Time
+}
+
+// NewRevocationChecker returns a RevocationChecker asking the user service at the authAddr
+// of the current configuration, the answers are cached for revocationCacheTTL seconds
+func NewRevocationChecker() RevocationChecker {
+	var mutex sync.Mutex
+	cache := make(map[string]revocationEntry)
+	client := &http.Client{Timeout: 5 * time.Second, Transport: &tracing.Transport{}}
+
+	return func(ctx context.Context, id string) (bool, error) {
+		config := common.Config()
+		now := time.Now()
+
+		mutex.Lock()
+		entry, ok := cache[id]
+		mutex.Unlock()
+		if ok && now.Before(entry.expires) {
+			return entry.revoked, nil
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, config.AuthAddr+"/api/v1/admin/token/revocations/"+url.PathEscape(id), nil)
+		if err != nil {
+			return false, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return false, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return false, errors.New(common.ErrAuthUnavailable)
+		}
+
+		var revocation models.TokenRevocation
+		if err = json.NewDecoder(resp.Body).Decode(&revocation); err != nil {
+			return false, err
+		}
+
+		mutex.Lock()
+		if len(cache) >= maxRevocationEntries {
+			for key, value := range cache {
+				if now.After(value.expires) {
+					delete(cache, key)
+				}
+			}
+		}
+		cache[id] = revocationEntry{revocation.Revoked, now.Add(time.Duration(config.RevocationCacheTTL) * time.Second)}
+		mutex.Unlock()
+
+		return revocation.Revoked, nil
+	}
+}
@@ -1,12 +1,85 @@
 /*
- * @File: models.token.go
+ * @File: models.error.go
  * @Description: Defines Error information will be returned to the clients
  * @Author: Nguyen Truong Duong (seedotech@gmail.com)
  */
 package models
 
-// Error defines the response error
+import (
+	"errors"
+	"net/http"
+
+	"../common"
+)
+
+// Error defines the response error, its code and kind come from the error catalog.
+// The validation errors list the invalid fields of the request.
 type Error struct {
-	Code    int    `json:"code" example:"27"`
-	Message string `json:"message" example:"Error message"`
+	Code    int          `json:"code" example:"12"`
+	Kind    string       `json:"kind" example:"validation_failed"`
+	Message string       `json:"message" example:"Error message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError describes an invalid field of a request and the rule it breaks
+type FieldError struct {
+	Field   string `json:"field" example:"name"`
+	Rule    string `json:"rule" example:"max"`
+	Message string `json:"message" example:"must not be longer than 64 characters"`
+}
+
+// ErrorKind is an entry of the error catalog, the errors of a kind have the same code and HTTP status
+type ErrorKind struct {
+	Name   string
+	Code   int
+	Status int
+}
+
+// Error catalog
+var (
+	KindInternal            = ErrorKind{"internal", common.StatusCodeUnknown, http.StatusInternalServerError}
+	KindValidationFailed    = ErrorKind{"validation_failed", common.StatusValidationFailed, http.StatusBadRequest}
+	KindUnauthorized        = ErrorKind{"unauthorized", common.StatusUnauthorized, http.StatusUnauthorized}
+	KindForbidden           = ErrorKind{"forbidden", common.StatusForbidden, http.StatusForbidden}
+	KindNotFound            = ErrorKind{"not_found", common.StatusNotFound, http.StatusNotFound}
+	KindDuplicate           = ErrorKind{"duplicate", common.StatusDuplicate, http.StatusConflict}
+	KindConflict            = ErrorKind{"conflict", common.StatusConflict, http.StatusConflict}
+	KindPayloadTooLarge     = ErrorKind{"payload_too_large", common.StatusPayloadTooLarge, http.StatusRequestEntityTooLarge}
+	KindUpstreamUnavailable = ErrorKind{"upstream_unavailable", common.StatusUpstreamUnavailable, http.StatusServiceUnavailable}
+)
+
+// APIError is an error of the catalog, its message is returned to the clients
+type APIError struct {
+	Kind   ErrorKind
+	Err    error
+	Fields []FieldError
+}
+
+// NewError creates an error of the catalog with the given message
+func NewError(kind ErrorKind, message string) *APIError {
+	return &APIError{Kind: kind, Err: errors.New(message)}
+}
+
+// WrapError classifies an error in the catalog, its message is returned to the clients
+func WrapError(kind ErrorKind, err error) *APIError {
+	return &APIError{Kind: kind, Err: err}
+}
+
+// NewValidationError creates a validation error listing the invalid fields of a request
+func NewValidationError(fields []FieldError) *APIError {
+	return &APIError{Kind: KindValidationFailed, Err: errors.New(common.ErrValidationFailed), Fields: fields}
+}
+
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the classified error
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Response returns the response of the error
+func (e *APIError) Response() Error {
+	return Error{e.Kind.Code, e.Kind.Name, e.Err.Error(), e.Fields}
 }
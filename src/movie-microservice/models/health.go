@@ -0,0 +1,19 @@
+/*
+ * @File: models.health.go
+ * @Description: Defines Health information will be returned to the probes
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+// Health defines the liveness or the readiness of the service, the readiness lists the health of its dependencies
+type Health struct {
+	Status       string                      `json:"status" example:"ok"`
+	Dependencies map[string]DependencyHealth `json:"dependencies,omitempty"`
+}
+
+// DependencyHealth defines the health of a dependency of the service
+type DependencyHealth struct {
+	Status    string `json:"status" example:"ok"`
+	LatencyMs int64  `json:"latencyMs" example:"2"`
+	Error     string `json:"error,omitempty" example:"server selection error: context deadline exceeded"`
+}
@@ -0,0 +1,24 @@
+/*
+ * @File: models.jwk.go
+ * @Description: Defines the JSON Web Key Set publishing the token verification keys
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+// JWK is a public JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty" example:"RSA"`
+	Use string `json:"use" example:"sig"`
+	Alg string `json:"alg" example:"RS256"`
+	Kid string `json:"kid" example:"2018-10"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty" example:"AQAB"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
@@ -5,21 +5,57 @@
  */
 package models
 
-import "gopkg.in/mgo.v2/bson"
+import (
+	"time"
+)
 
 // Movie information
 type Movie struct {
-	ID          bson.ObjectId `bson:"_id" json:"id"`
-	Name        string        `bson:"name" json:"name"`
-	URL         string        `bson:"url" json:"url"`
-	CoverImage  string        `bson:"coverImage" json:"coverImage"`
-	Description string        `bson:"description" json:"description"`
+	ID          ObjectID    `bson:"_id" json:"id"`
+	Name        string      `bson:"name" json:"name"`
+	URL         string      `bson:"url" json:"url"`
+	CoverImage  string      `bson:"coverImage" json:"coverImage"`
+	Description string      `bson:"description" json:"description"`
+	Year        int         `bson:"year" json:"year"`
+	Genres      []ObjectID  `bson:"genres" json:"genres"`
+	Rating      MovieRating `bson:"rating" json:"rating"`
+	Cover       *MovieCover `bson:"cover,omitempty" json:"cover,omitempty"`
+}
+
+// MovieCover describes the uploaded cover image of a movie
+type MovieCover struct {
+	ContentType string    `bson:"contentType" json:"contentType" example:"image/jpeg"`
+	UpdatedAt   time.Time `bson:"updatedAt" json:"updatedAt"`
 }
 
 // AddMovie information
 type AddMovie struct {
-	Name        string `json:"name" example:"Movie Name"`
-	URL         string `json:"url" example:"Movie URL"`
-	CoverImage  string `json:"coverImage" example:"Movie Cover Image"`
-	Description string `json:"description" example:"Movie Description"`
+	Name        string     `json:"name" binding:"required,notblank,max=200" example:"Movie Name"`
+	URL         string     `json:"url" binding:"omitempty,url" example:"https://movies.example.com/movie"`
+	CoverImage  string     `json:"coverImage" binding:"omitempty,urlorpath" example:"https://movies.example.com/movie.jpg"`
+	Description string     `json:"description" binding:"max=5000" example:"Movie Description"`
+	Year        int        `json:"year" binding:"omitempty,min=1888,max=2100" example:"2018"`
+	Genres      []ObjectID `json:"genres"`
+}
+
+// Update returns the modification replacing the fields of an existing movie
+func (a AddMovie) Update() UpdateMovie {
+	return UpdateMovie{&a.Name, &a.URL, &a.CoverImage, &a.Description, &a.Year, &a.Genres}
+}
+
+// UpdateMovie information, only the given fields are modified
+type UpdateMovie struct {
+	Name        *string     `json:"name" binding:"omitempty,notblank,max=200" example:"Movie Name"`
+	URL         *string     `json:"url" binding:"omitempty,url" example:"https://movies.example.com/movie"`
+	CoverImage  *string     `json:"coverImage" binding:"omitempty,urlorpath" example:"https://movies.example.com/movie.jpg"`
+	Description *string     `json:"description" binding:"omitempty,max=5000" example:"Movie Description"`
+	Year        *int        `json:"year" binding:"omitempty,min=1888,max=2100" example:"2018"`
+	Genres      *[]ObjectID `json:"genres"`
+}
+
+// MovieFilter selects the movies of the list and search APIs, the zero value selects all of them
+type MovieFilter struct {
+	Name  string   // part of the name, the case is ignored
+	Genre ObjectID // genre of the movies, any genre when zero
+	Year  int      // release year, any year when 0
 }
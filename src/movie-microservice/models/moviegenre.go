@@ -5,11 +5,17 @@
  */
 package models
 
-import "gopkg.in/mgo.v2/bson"
+import ()
 
 // MovieGenre information
 type MovieGenre struct {
-	ID          bson.ObjectId `bson:"id" json:"id"`
-	Name        string        `bson:"name" json:"name"`
-	Description string        `bson:"description" json:"description"`
+	ID          ObjectID `bson:"_id" json:"id" example:"5bbdadf782ebac06a695a8e7"`
+	Name        string   `bson:"name" json:"name" example:"Comedy"`
+	Description string   `bson:"description" json:"description" example:"Genre Description"`
+}
+
+// AddMovieGenre information
+type AddMovieGenre struct {
+	Name        string `json:"name" binding:"required,notblank,max=64" example:"Comedy"`
+	Description string `json:"description" binding:"max=1000" example:"Genre Description"`
 }
@@ -0,0 +1,33 @@
+/*
+ * @File: models.page.go
+ * @Description: Defines the pagination of the list APIs
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+import (
+	"../common"
+)
+
+// PageQuery defines the page requested from a list API.
+// A page starts either after the cursor returned with the previous page or at an offset.
+type PageQuery struct {
+	Limit  int    `form:"limit" binding:"min=0,max=100"` // common.MaxPageLimit at most
+	Offset int    `form:"offset" binding:"min=0,excluded_with=After"`
+	After  string `form:"after"`
+	Sort   string `form:"sort"` // field name, prefixed by "-" for the descending order
+}
+
+// SetDefaults applies the default limit
+func (p *PageQuery) SetDefaults() {
+	if p.Limit == 0 {
+		p.Limit = common.DefaultPageLimit
+	}
+}
+
+// MoviePage is a page of movies
+type MoviePage struct {
+	Items      []Movie `json:"items"`
+	NextCursor string  `json:"nextCursor" example:"OQAAAAJzAAYAAABhZG1pbgAHaWQAW72t94LrrAamlajnAA"`
+	Total      int     `json:"total" example:"1"`
+}
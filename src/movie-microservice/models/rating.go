@@ -0,0 +1,56 @@
+/*
+ * @File: models.rating.go
+ * @Description: Defines Rating and Review information will be returned to the clients
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+import (
+	"time"
+)
+
+// MovieRating is the aggregate of the ratings of a movie
+type MovieRating struct {
+	Average float64 `bson:"average" json:"average" example:"4.25"`
+	Count   int     `bson:"count" json:"count" example:"4"`
+}
+
+// ReviewModeration is set by the admins, the hidden reviews are not listed
+type ReviewModeration struct {
+	Hidden      bool      `bson:"hidden" json:"hidden"`
+	Reason      string    `bson:"reason" json:"reason" example:"spam"`
+	ModeratedBy string    `bson:"moderatedBy" json:"moderatedBy" example:"admin"`
+	ModeratedAt time.Time `bson:"moderatedAt" json:"moderatedAt"`
+}
+
+// Rating of a movie by a user, with an optional review
+type Rating struct {
+	ID         ObjectID          `bson:"_id" json:"id" example:"5bbdadf782ebac06a695a8e7"`
+	MovieID    ObjectID          `bson:"movieId" json:"movieId" example:"5bbdadf782ebac06a695a8e7"`
+	UserID     string            `bson:"userId" json:"userId" example:"5bbdadf782ebac06a695a8e7"`
+	UserName   string            `bson:"userName" json:"userName" example:"raycad"`
+	Score      int               `bson:"score" json:"score" example:"4"`
+	Review     string            `bson:"review" json:"review" example:"Great movie"`
+	Moderation *ReviewModeration `bson:"moderation,omitempty" json:"moderation,omitempty"`
+	CreatedAt  time.Time         `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time         `bson:"updatedAt" json:"updatedAt"`
+}
+
+// AddRating information
+type AddRating struct {
+	Score  int    `json:"score" binding:"min=1,max=5" example:"4"`
+	Review string `json:"review" binding:"max=2000" example:"Great movie"`
+}
+
+// ModerateReview information
+type ModerateReview struct {
+	Hidden bool   `json:"hidden" example:"true"`
+	Reason string `json:"reason" binding:"max=200" example:"spam"`
+}
+
+// ReviewPage is a page of reviews
+type ReviewPage struct {
+	Items      []Rating `json:"items"`
+	NextCursor string   `json:"nextCursor" example:"OQAAAAJzAAYAAABhZG1pbgAHaWQAW72t94LrrAamlajnAA"`
+	Total      int      `json:"total" example:"1"`
+}
@@ -0,0 +1,66 @@
+/*
+ * @File: models.search.go
+ * @Description: Defines the full-text search of the movies
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+import (
+	"../common"
+)
+
+// MovieSearchQuery defines a full-text search of the movies
+type MovieSearchQuery struct {
+	Q      string `form:"q" binding:"required,notblank"`
+	Genre  string `form:"genre"` // genre id or name
+	Year   int    `form:"year" binding:"min=0"`
+	Facets bool   `form:"facets"`
+	Limit  int    `form:"limit" binding:"min=0,max=100"` // common.MaxPageLimit at most
+	Offset int    `form:"offset" binding:"min=0"`
+}
+
+// SetDefaults applies the default limit
+func (s *MovieSearchQuery) SetDefaults() {
+	if s.Limit == 0 {
+		s.Limit = common.DefaultPageLimit
+	}
+}
+
+// MovieHighlights are the fragments of a movie matching a search, the matched words are wrapped in <em> tags
+type MovieHighlights struct {
+	Name        []string `json:"name,omitempty" example:"The <em>Matrix</em>"`
+	Description []string `json:"description,omitempty" example:"...a computer hacker learns about the true nature of the <em>matrix</em>..."`
+}
+
+// MovieSearchHit is a movie matching a search
+type MovieSearchHit struct {
+	Movie      Movie           `json:"movie"`
+	Score      float64         `json:"score" example:"5.5"`
+	Highlights MovieHighlights `json:"highlights"`
+}
+
+// GenreFacet counts the matched movies of a genre
+type GenreFacet struct {
+	ID    ObjectID `bson:"_id" json:"id" example:"5bbdadf782ebac06a695a8e7"`
+	Name  string   `bson:"name" json:"name" example:"Comedy"`
+	Count int      `bson:"count" json:"count" example:"3"`
+}
+
+// YearFacet counts the matched movies of a year
+type YearFacet struct {
+	Year  int `bson:"_id" json:"year" example:"2018"`
+	Count int `bson:"count" json:"count" example:"3"`
+}
+
+// MovieFacets counts the matched movies per genre and per year
+type MovieFacets struct {
+	Genres []GenreFacet `bson:"genres" json:"genres"`
+	Years  []YearFacet  `bson:"years" json:"years"`
+}
+
+// MovieSearchPage is a page of the movies matching a search
+type MovieSearchPage struct {
+	Items  []MovieSearchHit `json:"items"`
+	Total  int              `json:"total" example:"1"`
+	Facets *MovieFacets     `json:"facets,omitempty"`
+}
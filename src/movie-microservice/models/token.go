@@ -7,5 +7,12 @@ package models
 
 // Token string
 type Token struct {
-	Token string `json:"token" example:"5bb3695b82ebac0f76e1cafa"`
+	Token        string `json:"token" example:"5bb3695b82ebac0f76e1cafa"`
+	RefreshToken string `json:"refreshToken" example:"q4TQ3u1lH8Q0pXl2b8x0kYw8m0t2Kc1oM0dJm8d7X5A"`
+}
+
+// TokenRevocation tells if an access token has been revoked
+type TokenRevocation struct {
+	ID      string `json:"id"`
+	Revoked bool   `json:"revoked"`
 }
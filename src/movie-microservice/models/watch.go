@@ -0,0 +1,76 @@
+/*
+ * @File: models.watch.go
+ * @Description: Defines Watchlist and Watch History information will be returned to the clients
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+import (
+	"errors"
+	"time"
+
+	"../common"
+)
+
+// completedRatio is the part of a movie to watch to complete it, the end credits are skipped
+const completedRatio = 0.95
+
+// WatchlistItem is a movie the user wants to watch
+type WatchlistItem struct {
+	ID      ObjectID  `bson:"_id" json:"id" example:"5bbdadf782ebac06a695a8e7"`
+	UserID  string    `bson:"userId" json:"userId" example:"5bbdadf782ebac06a695a8e7"`
+	MovieID ObjectID  `bson:"movieId" json:"movieId" example:"5bbdadf782ebac06a695a8e7"`
+	AddedAt time.Time `bson:"addedAt" json:"addedAt"`
+	Movie   *Movie    `bson:"-" json:"movie,omitempty"`
+}
+
+// AddWatchlistItem information
+type AddWatchlistItem struct {
+	MovieID ObjectID `json:"movieId" binding:"required" example:"5bbdadf782ebac06a695a8e7"`
+}
+
+// WatchlistPage is a page of the watchlist
+type WatchlistPage struct {
+	Items      []WatchlistItem `json:"items"`
+	NextCursor string          `json:"nextCursor" example:"OQAAAAJzAAYAAABhZG1pbgAHaWQAW72t94LrrAamlajnAA"`
+	Total      int             `json:"total" example:"1"`
+}
+
+// Watch is the playback progress of a movie by a user
+type Watch struct {
+	ID        ObjectID  `bson:"_id" json:"id" example:"5bbdadf782ebac06a695a8e7"`
+	UserID    string    `bson:"userId" json:"userId" example:"5bbdadf782ebac06a695a8e7"`
+	MovieID   ObjectID  `bson:"movieId" json:"movieId" example:"5bbdadf782ebac06a695a8e7"`
+	Position  int       `bson:"position" json:"position" example:"1260"` // seconds
+	Duration  int       `bson:"duration" json:"duration" example:"7200"` // seconds
+	Completed bool      `bson:"completed" json:"completed"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+	Movie     *Movie    `bson:"-" json:"movie,omitempty"`
+}
+
+// WatchProgress information
+type WatchProgress struct {
+	Position  int  `json:"position" binding:"min=0" example:"1260"`
+	Duration  int  `json:"duration" binding:"min=0" example:"7200"`
+	Completed bool `json:"completed"`
+}
+
+// Validate watch progress, the movie is completed when its end is reached
+func (w *WatchProgress) Validate() error {
+	if w.Duration > 0 && w.Position > w.Duration {
+		return errors.New(common.ErrPositionInvalid)
+	}
+
+	if w.Duration > 0 && float64(w.Position) >= completedRatio*float64(w.Duration) {
+		w.Completed = true
+	}
+
+	return nil
+}
+
+// WatchPage is a page of the watch history
+type WatchPage struct {
+	Items      []Watch `json:"items"`
+	NextCursor string  `json:"nextCursor" example:"OQAAAAJzAAYAAABhZG1pbgAHaWQAW72t94LrrAamlajnAA"`
+	Total      int     `json:"total" example:"1"`
+}
@@ -0,0 +1,126 @@
+/*
+ * @File: storage.gridfs.go
+ * @Description: Stores the files in MongoDB GridFS
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package storage
+
+import (
+	"bytes"
+	"context"
+
+	"../databases"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFS stores the files in the GridFS collections of the given prefix
+type GridFS struct {
+	Prefix string
+}
+
+// bucket opens the GridFS bucket, its reads and writes stop at the deadline of the context
+func (g *GridFS) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(databases.Database.Client.Database(databases.Database.Databasename), options.GridFSBucket().SetName(g.Prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		bucket.SetReadDeadline(deadline)
+		bucket.SetWriteDeadline(deadline)
+	}
+	return bucket, nil
+}
+
+// Save writes a file, then removes the previous versions of the file
+func (g *GridFS) Save(ctx context.Context, name string, data []byte) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a GridFS to execute the queries against.
+	bucket, err := g.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	id, err := bucket.UploadFromStream(name, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	// Readers open the latest version, the older ones can go
+	cursor, err := bucket.FindContext(ctx, bson.M{"filename": name, "_id": bson.M{"$ne": id}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var older struct {
+		ID interface{} `bson:"_id"`
+	}
+	for cursor.Next(ctx) {
+		if err = cursor.Decode(&older); err != nil {
+			return err
+		}
+		if err = bucket.DeleteContext(ctx, older.ID); err != nil && err != gridfs.ErrFileNotFound {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// Load reads the latest version of a file
+func (g *GridFS) Load(ctx context.Context, name string) ([]byte, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a GridFS to execute the queries against.
+	bucket, err := g.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var data bytes.Buffer
+	_, err = bucket.DownloadToStreamByName(name, &data)
+	if err == gridfs.ErrFileNotFound {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Bytes(), nil
+}
+
+// Delete removes all versions of a file, removing a missing file succeeds
+func (g *GridFS) Delete(ctx context.Context, name string) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a GridFS to execute the queries against.
+	bucket, err := g.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := bucket.FindContext(ctx, bson.M{"filename": name})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var file struct {
+		ID interface{} `bson:"_id"`
+	}
+	for cursor.Next(ctx) {
+		if err = cursor.Decode(&file); err != nil {
+			return err
+		}
+		if err = bucket.DeleteContext(ctx, file.ID); err != nil && err != gridfs.ErrFileNotFound {
+			return err
+		}
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,66 @@
+/*
+ * @File: storage.local.go
+ * @Description: Stores the files in a directory of the local file system
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Local stores the files under a directory
+type Local struct {
+	Dir string
+}
+
+// Save writes a file, the previous file is replaced atomically
+func (l *Local) Save(ctx context.Context, name string, data []byte) error {
+	path := l.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(path), ".upload-")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// Load reads a file
+func (l *Local) Load(ctx context.Context, name string) ([]byte, error) {
+	data, err := ioutil.ReadFile(l.path(name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	return data, err
+}
+
+// Delete removes a file, removing a missing file succeeds
+func (l *Local) Delete(ctx context.Context, name string) error {
+	err := os.Remove(l.path(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// path returns the path of a file, the name can't go out of the directory
+func (l *Local) path(name string) string {
+	return filepath.Join(l.Dir, filepath.FromSlash(filepath.Clean("/"+name)))
+}
@@ -0,0 +1,42 @@
+/*
+ * @File: storage.storage.go
+ * @Description: Defines the storage of the uploaded files
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"../common"
+)
+
+// Storage stores files by their name, saving a file replaces the previous one of the same name
+type Storage interface {
+	Save(ctx context.Context, name string, data []byte) error
+	Load(ctx context.Context, name string) ([]byte, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// ErrNotExist is returned when loading a missing file
+var ErrNotExist = errors.New(common.ErrFileNotFound)
+
+// Covers stores the movie cover images
+var (
+	Covers Storage
+)
+
+// Init creates the storages of the configured kind
+func Init() error {
+	switch common.Config().CoverStorage {
+	case common.StorageLocal:
+		Covers = &Local{common.Config().CoverDir}
+	case common.StorageGridFS:
+		Covers = &GridFS{"covers"}
+	default:
+		return errors.New(common.ErrStorageUnsupported)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+/*
+ * @File: utils.highlight.go
+ * @Description: Highlights the words of the texts matching a search
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	highlightRadius       = 40 // runes of context around the matched words
+	highlightMaxFragments = 3
+)
+
+// wordSpan locates a word in a text by its rune indexes
+type wordSpan struct {
+	start int
+	end   int
+}
+
+// SearchTerms returns the lower case terms of a text search, the excluded (-word) terms are skipped
+func (u *Utils) SearchTerms(search string) []string {
+	var terms []string
+	for _, field := range strings.Fields(search) {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+
+		for _, term := range strings.FieldsFunc(strings.ToLower(field), isNotWordRune) {
+			terms = append(terms, stemTerm(term))
+		}
+	}
+
+	return terms
+}
+
+// Highlight returns the fragments of the text around the words starting with one of the terms,
+// the matched words are wrapped in <em> tags and the rest of the text is HTML escaped
+func (u *Utils) Highlight(text string, terms []string) []string {
+	runes := []rune(text)
+
+	var matches []wordSpan
+	for i := 0; i < len(runes); {
+		if isNotWordRune(runes[i]) {
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(runes) && !isNotWordRune(runes[j]) {
+			j++
+		}
+
+		word := strings.ToLower(string(runes[i:j]))
+		for _, term := range terms {
+			if strings.HasPrefix(word, term) {
+				matches = append(matches, wordSpan{i, j})
+				break
+			}
+		}
+		i = j
+	}
+
+	// Close matches share the same fragment
+	var fragments []string
+	for k := 0; k < len(matches) && len(fragments) < highlightMaxFragments; {
+		start := matches[k].start - highlightRadius
+		if start < 0 {
+			start = 0
+		}
+
+		l := k + 1
+		end := matches[k].end + highlightRadius
+		for l < len(matches) && matches[l].start < end {
+			end = matches[l].end + highlightRadius
+			l++
+		}
+		if end > len(runes) {
+			end = len(runes)
+		}
+
+		fragments = append(fragments, highlightFragment(runes, matches[k:l], start, end))
+		k = l
+	}
+
+	return fragments
+}
+
+// highlightFragment builds the fragment between start and end, cutting neither words nor matches
+func highlightFragment(runes []rune, matches []wordSpan, start int, end int) string {
+	first, last := matches[0], matches[len(matches)-1]
+	for start < first.start && (start > 0 && !isNotWordRune(runes[start-1]) || unicode.IsSpace(runes[start])) {
+		start++
+	}
+	for end > last.end && (end < len(runes) && !isNotWordRune(runes[end]) || unicode.IsSpace(runes[end-1])) {
+		end--
+	}
+
+	var fragment strings.Builder
+	if start > 0 {
+		fragment.WriteString("...")
+	}
+
+	position := start
+	for _, match := range matches {
+		fragment.WriteString(html.EscapeString(string(runes[position:match.start])))
+		fragment.WriteString("<em>")
+		fragment.WriteString(html.EscapeString(string(runes[match.start:match.end])))
+		fragment.WriteString("</em>")
+		position = match.end
+	}
+	fragment.WriteString(html.EscapeString(string(runes[position:end])))
+
+	if end < len(runes) {
+		fragment.WriteString("...")
+	}
+
+	return fragment.String()
+}
+
+// stemTerm removes the common English suffixes, like the text index does,
+// so that the other forms of the word are highlighted too
+func stemTerm(term string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if strings.HasSuffix(term, suffix) && len(term)-len(suffix) >= 3 {
+			term = term[:len(term)-len(suffix)]
+			if n := len(term); term[n-1] == term[n-2] && !strings.ContainsRune("lsz", rune(term[n-1])) {
+				// running -> run
+				term = term[:n-1]
+			}
+			return term
+		}
+	}
+
+	return term
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,146 @@
+/*
+ * @File: utils.image.go
+ * @Description: Decodes the uploaded images and generates their thumbnails
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+
+	// Register the supported image formats
+	_ "image/gif"
+	_ "image/png"
+
+	"../common"
+	"../models"
+)
+
+// maxImagePixels limits the decoded images, a small file may decode to a huge image
+const maxImagePixels = 25000000
+
+// imageTypes are the supported content types of the uploaded images
+var imageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// DecodeImage checks the type and the dimensions of an image then decodes it,
+// the content type is detected from the data
+func (u *Utils) DecodeImage(data []byte) (image.Image, string, error) {
+	contentType := http.DetectContentType(data)
+	if !imageTypes[contentType] {
+		return nil, "", models.NewError(models.KindValidationFailed, common.ErrImageTypeInvalid)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", models.NewError(models.KindValidationFailed, common.ErrImageTypeInvalid)
+	}
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return nil, "", models.NewError(models.KindPayloadTooLarge, common.ErrImageTooManyPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", models.NewError(models.KindValidationFailed, common.ErrImageTypeInvalid)
+	}
+
+	return img, contentType, nil
+}
+
+// pixelReader returns the alpha-premultiplied 8-bit color of a pixel of an image. The pixels of
+// the decoded JPEG, PNG and GIF images are read from their buffers, without allocating a color.
+func pixelReader(src image.Image) func(x, y int) (r, g, b, a uint32) {
+	switch img := src.(type) {
+	case *image.YCbCr:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			yi, ci := img.YOffset(x, y), img.COffset(x, y)
+			r, g, b := color.YCbCrToRGB(img.Y[yi], img.Cb[ci], img.Cr[ci])
+			return uint32(r), uint32(g), uint32(b), 0xff
+		}
+	case *image.RGBA:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			p := img.Pix[img.PixOffset(x, y):]
+			return uint32(p[0]), uint32(p[1]), uint32(p[2]), uint32(p[3])
+		}
+	case *image.NRGBA:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			p := img.Pix[img.PixOffset(x, y):]
+			a := uint32(p[3])
+			return uint32(p[0]) * a / 0xff, uint32(p[1]) * a / 0xff, uint32(p[2]) * a / 0xff, a
+		}
+	case *image.Gray:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			v := uint32(img.Pix[img.PixOffset(x, y)])
+			return v, v, v, 0xff
+		}
+	case *image.Paletted:
+		palette := make([][4]uint32, len(img.Palette))
+		for i, c := range img.Palette {
+			r, g, b, a := c.RGBA()
+			palette[i] = [4]uint32{r >> 8, g >> 8, b >> 8, a >> 8}
+		}
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			i := int(img.Pix[img.PixOffset(x, y)])
+			if i >= len(palette) {
+				return 0, 0, 0, 0
+			}
+			c := palette[i]
+			return c[0], c[1], c[2], c[3]
+		}
+	default:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			r, g, b, a := src.At(x, y).RGBA()
+			return r >> 8, g >> 8, b >> 8, a >> 8
+		}
+	}
+}
+
+// Thumbnail scales an image down to the given width, keeping its aspect ratio,
+// and encodes it as JPEG. The transparent pixels are drawn on a white background.
+func (u *Utils) Thumbnail(src image.Image, width int) ([]byte, error) {
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if width > srcWidth {
+		width = srcWidth
+	}
+	height := srcHeight * width / srcWidth
+	if height < 1 {
+		height = 1
+	}
+
+	// Every pixel of the thumbnail averages the pixels of its area in the image
+	pixel := pixelReader(src)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := bounds.Min.Y+y*srcHeight/height, bounds.Min.Y+(y+1)*srcHeight/height
+		for x := 0; x < width; x++ {
+			x0, x1 := bounds.Min.X+x*srcWidth/width, bounds.Min.X+(x+1)*srcWidth/width
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := pixel(sx, sy)
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+
+			// The colors are alpha-premultiplied, the white shows through the transparency
+			white := 0xff - a/n
+			dst.SetRGBA(x, y, color.RGBA{uint8(r/n + white), uint8(g/n + white), uint8(b/n + white), 0xff})
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
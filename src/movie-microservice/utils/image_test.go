@@ -0,0 +1,69 @@
+/*
+ * @File: utils.image_test.go
+ * @Description: Tests the reading of the pixels of the decoded images
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package utils
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"testing"
+)
+
+func TestPixelReader(t *testing.T) {
+	rect := image.Rect(3, 2, 40, 30)
+	fill := func(img interface{ Set(x, y int, c color.Color) }) {
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				img.Set(x, y, color.NRGBA{uint8(x * 6), uint8(y * 8), uint8(x * y), uint8(255 - x*y/5)})
+			}
+		}
+	}
+
+	ycbcr := image.NewYCbCr(rect, image.YCbCrSubsampleRatio420)
+	for i := range ycbcr.Y {
+		ycbcr.Y[i] = uint8(i)
+	}
+	for i := range ycbcr.Cb {
+		ycbcr.Cb[i], ycbcr.Cr[i] = uint8(i*3), uint8(255-i)
+	}
+	rgba, nrgba, gray := image.NewRGBA(rect), image.NewNRGBA(rect), image.NewGray(rect)
+	paletted := image.NewPaletted(rect, palette.Plan9)
+	gray16 := image.NewGray16(rect)
+	for _, img := range []interface{ Set(x, y int, c color.Color) }{rgba, nrgba, gray, paletted, gray16} {
+		fill(img)
+	}
+
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"ycbcr", ycbcr},
+		{"rgba", rgba},
+		{"nrgba", nrgba},
+		{"gray", gray},
+		{"paletted", paletted},
+		{"other", gray16},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pixel := pixelReader(test.img)
+			for y := rect.Min.Y; y < rect.Max.Y; y++ {
+				for x := rect.Min.X; x < rect.Max.X; x++ {
+					r, g, b, a := pixel(x, y)
+					wr, wg, wb, wa := test.img.At(x, y).RGBA()
+
+					// The premultiplication of the 8-bit colors may round differently
+					got, want := []uint32{r, g, b, a}, []uint32{wr >> 8, wg >> 8, wb >> 8, wa >> 8}
+					for i := range got {
+						if got[i]+1 < want[i] || got[i] > want[i]+1 {
+							t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+						}
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+/*
+ * @File: utils.jwks.go
+ * @Description: Fetches and caches the token verification keys published by the user service
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"../common"
+	"../models"
+	jwt_lib "github.com/dgrijalva/jwt-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// minJWKSRefresh limits how often an unknown kid triggers a new fetch
+const minJWKSRefresh = 10 * time.Second
+
+// JWKS caches the public keys of the JSON Web Key Set at the jwksURL of the current configuration
+// by their kid. The set is fetched again when it gets older than jwksCacheTTL seconds, when the
+// jwksURL changes or when a token is signed by an unknown key, which happens after a key rotation.
+type JWKS struct {
+	client    *http.Client
+	mutex     sync.Mutex
+	keys      map[string]jwksKey
+	fetchedAt time.Time
+	url       string
+}
+
+type jwksKey struct {
+	alg    string
+	public interface{}
+}
+
+// NewJWKS creates a JWKS
+func NewJWKS() *JWKS {
+	return &JWKS{
+		client: &http.Client{Timeout: 5 * time.Second},
+		keys:   make(map[string]jwksKey),
+	}
+}
+
+// Keyfunc returns the public key verifying the given token
+func (j *JWKS) Keyfunc(token *jwt_lib.Token) (interface{}, error) {
+	config := common.Config()
+	return j.publicKey(token, config.JwksURL, time.Duration(config.JwksCacheTTL)*time.Second)
+}
+
+// publicKey returns the public key verifying the given token, the key set at the url is
+// fetched again when the cached one is older than the ttl
+func (j *JWKS) publicKey(token *jwt_lib.Token, url string, ttl time.Duration) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	key, ok := j.keys[kid]
+	age := time.Since(j.fetchedAt)
+	if j.url != url || age > ttl || (!ok && age > minJWKSRefresh) {
+		if err := j.fetch(url); err != nil {
+			log.Debug("[ERROR]: Can't fetch JWKS, go error: ", err)
+			// Keep verifying with the cached keys while the user service is unavailable
+			if len(j.keys) == 0 {
+				return nil, err
+			}
+		}
+		key, ok = j.keys[kid]
+	}
+
+	if !ok || token.Method.Alg() != key.alg {
+		return nil, jwt_lib.ErrSignatureInvalid
+	}
+
+	return key.public, nil
+}
+
+// fetch replaces the cached keys by the ones of the key set at the url, the mutex must be held
+func (j *JWKS) fetch(url string) error {
+	resp, err := j.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(common.ErrAuthUnavailable)
+	}
+
+	var set models.JWKSet
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]jwksKey)
+	for _, jwk := range set.Keys {
+		public, err := parseJWK(jwk)
+		if err != nil {
+			log.Debug("[ERROR]: Ignoring JWK ", jwk.Kid, ", go error: ", err)
+			continue
+		}
+		keys[jwk.Kid] = jwksKey{jwk.Alg, public}
+	}
+
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	j.url = url
+	return nil
+}
+
+// parseJWK decodes the public key of an RSA or EC P-256 JWK
+func parseJWK(jwk models.JWK) (interface{}, error) {
+	switch {
+	case jwk.Kty == "RSA" && jwk.Alg == jwt_lib.SigningMethodRS256.Alg():
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case jwk.Kty == "EC" && jwk.Crv == "P-256" && jwk.Alg == jwt_lib.SigningMethodES256.Alg():
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, errors.New(common.ErrJWKUnsupported)
+	}
+}
@@ -0,0 +1,199 @@
+/*
+ * @File: utils.jwks_test.go
+ * @Description: Tests the token verification keys fetched from the user service
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"../models"
+	jwt_lib "github.com/dgrijalva/jwt-go"
+)
+
+// jwksServer publishes a key set which can be replaced, or be unavailable
+type jwksServer struct {
+	mutex   sync.Mutex
+	set     models.JWKSet
+	down    bool
+	fetches int
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.fetches++
+	if s.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	json.NewEncoder(w).Encode(s.set)
+}
+
+func (s *jwksServer) publish(keys ...models.JWK) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.set = models.JWKSet{Keys: keys}
+}
+
+func (s *jwksServer) setDown(down bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.down = down
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.fetches
+}
+
+func rsaJWK(t *testing.T, kid string) (models.JWK, crypto.PublicKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return models.JWK{
+		Kty: "RSA", Use: "sig", Alg: "RS256", Kid: kid,
+		N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}, &key.PublicKey
+}
+
+func ecJWK(t *testing.T, kid string) (models.JWK, crypto.PublicKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return models.JWK{
+		Kty: "EC", Use: "sig", Alg: "ES256", Kid: kid, Crv: "P-256",
+		X: base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y: base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}, &key.PublicKey
+}
+
+func signedBy(method jwt_lib.SigningMethod, kid string) *jwt_lib.Token {
+	token := jwt_lib.New(method)
+	token.Header["kid"] = kid
+	return token
+}
+
+func samePublicKey(public interface{}, want crypto.PublicKey) bool {
+	key, ok := public.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(want)
+}
+
+func TestJWKSPublicKey(t *testing.T) {
+	rsaKey, rsaPublic := rsaJWK(t, "rsa")
+	ecKey, ecPublic := ecJWK(t, "ec")
+	server := &jwksServer{}
+	server.publish(rsaKey, ecKey, models.JWK{Kty: "oct", Alg: "HS256", Kid: "hmac"})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	jwks := NewJWKS()
+	tests := []struct {
+		name   string
+		token  *jwt_lib.Token
+		public crypto.PublicKey
+	}{
+		{"RSA key", signedBy(jwt_lib.SigningMethodRS256, "rsa"), rsaPublic},
+		{"EC key", signedBy(jwt_lib.SigningMethodES256, "ec"), ecPublic},
+		{"unknown kid", signedBy(jwt_lib.SigningMethodRS256, "unknown"), nil},
+		{"no kid", jwt_lib.New(jwt_lib.SigningMethodRS256), nil},
+		{"algorithm of another key", signedBy(jwt_lib.SigningMethodRS256, "ec"), nil},
+		{"unsupported key", signedBy(jwt_lib.SigningMethodHS256, "hmac"), nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			public, err := jwks.publicKey(test.token, ts.URL, time.Hour)
+			if test.public == nil {
+				if err != jwt_lib.ErrSignatureInvalid {
+					t.Errorf("error = %v, want %v", err, jwt_lib.ErrSignatureInvalid)
+				}
+				return
+			}
+			if err != nil || !samePublicKey(public, test.public) {
+				t.Errorf("public key = %v, %v", public, err)
+			}
+		})
+	}
+
+	// The unknown kids don't fetch the key set again before minJWKSRefresh
+	if fetches := server.fetchCount(); fetches != 1 {
+		t.Errorf("fetches = %d, want 1", fetches)
+	}
+}
+
+func TestJWKSRefresh(t *testing.T) {
+	oldKey, oldPublic := rsaJWK(t, "old")
+	newKey, newPublic := rsaJWK(t, "new")
+	server := &jwksServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	jwks := NewJWKS()
+
+	// The key set can't be fetched and no key is cached
+	server.setDown(true)
+	if _, err := jwks.publicKey(signedBy(jwt_lib.SigningMethodRS256, "old"), ts.URL, time.Hour); err == nil {
+		t.Error("no error without key set")
+	}
+	server.setDown(false)
+
+	tests := []struct {
+		name   string
+		setup  func()
+		kid    string
+		public crypto.PublicKey
+	}{
+		{"first fetch", func() { server.publish(oldKey) }, "old", oldPublic},
+		{"rotated key fetched too soon", func() { server.publish(oldKey, newKey) }, "new", nil},
+		{"rotated key", func() { jwks.fetchedAt = jwks.fetchedAt.Add(-minJWKSRefresh) }, "new", newPublic},
+		{"previous key kept", func() {}, "old", oldPublic},
+		{"cached keys while the service is down", func() {
+			server.setDown(true)
+			jwks.fetchedAt = jwks.fetchedAt.Add(-2 * time.Hour)
+		}, "new", newPublic},
+		{"expired key set", func() {
+			server.setDown(false)
+			server.publish(newKey)
+		}, "old", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.setup()
+			public, err := jwks.publicKey(signedBy(jwt_lib.SigningMethodRS256, test.kid), ts.URL, time.Hour)
+			if test.public == nil {
+				if err != jwt_lib.ErrSignatureInvalid {
+					t.Errorf("error = %v, want %v", err, jwt_lib.ErrSignatureInvalid)
+				}
+				return
+			}
+			if err != nil || !samePublicKey(public, test.public) {
+				t.Errorf("public key = %v, %v", public, err)
+			}
+		})
+	}
+}
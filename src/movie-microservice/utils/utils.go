@@ -6,12 +6,10 @@
 package utils
 
 import (
-	"errors"
-	"time"
-
 	"../common"
+	"../models"
 	jwt_lib "github.com/dgrijalva/jwt-go"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // SdtClaims defines the custom claims
@@ -24,28 +22,35 @@ type SdtClaims struct {
 type Utils struct {
 }
 
-// GenerateJWT generates token from the given information
-func (u *Utils) GenerateJWT(name string, role string) (string, error) {
-	claims := SdtClaims{
-		name,
-		role,
-		jwt_lib.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 1).Unix(),
-			Issuer:    common.Config.Issuer,
-		},
+// ValidateObjectID checks the given ID if it's an object id or not
+func (u *Utils) ValidateObjectID(id string) error {
+	if primitive.IsValidObjectID(id) != true {
+		return models.NewError(models.KindValidationFailed, common.ErrNotObjectIDHex)
 	}
 
-	token := jwt_lib.NewWithClaims(jwt_lib.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(common.Config.JwtSecretPassword))
-
-	return tokenString, err
+	return nil
 }
 
-// ValidateObjectID checks the given ID if it's an object id or not
-func (u *Utils) ValidateObjectID(id string) error {
-	if bson.IsObjectIdHex(id) != true {
-		return errors.New(common.ErrNotObjectIDHex)
+// ObjectIDHex returns the ObjectID of the given hex representation.
+// It panics when the hex representation is not valid, check it with ValidateObjectID first.
+func ObjectIDHex(id string) models.ObjectID {
+	objectID, err := models.ObjectIDFromHex(id)
+	if err != nil {
+		panic(err)
 	}
 
-	return nil
+	return objectID
+}
+
+// roleLevels ranks the roles, a higher level includes the permissions of the lower ones
+var roleLevels = map[string]int{
+	common.RoleViewer: 1,
+	common.RoleEditor: 2,
+	common.RoleAdmin:  3,
+}
+
+// HasRole checks if the granted role includes the permissions of the required role
+func (u *Utils) HasRole(granted string, required string) bool {
+	level, ok := roleLevels[granted]
+	return ok && level >= roleLevels[required]
 }
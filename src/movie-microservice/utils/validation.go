@@ -0,0 +1,104 @@
+/*
+ * @File: utils.validation.go
+ * @Description: Registers the validation rules of the binding tags of the models
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package utils
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+
+	"../models"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var registerValidations sync.Once
+
+// RegisterValidations registers the custom rules of the binding tags, the fields of the
+// validation errors are named like in the requests. It must be called before the requests are bound.
+func RegisterValidations() {
+	registerValidations.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+
+		v.RegisterTagNameFunc(fieldName)
+		v.RegisterValidation("notblank", notBlank)
+		v.RegisterValidation("urlorpath", urlOrPath)
+	})
+}
+
+// FieldErrors describes the invalid fields of a validation error
+func FieldErrors(errs validator.ValidationErrors) []models.FieldError {
+	fields := make([]models.FieldError, 0, len(errs))
+	for _, err := range errs {
+		fields = append(fields, models.FieldError{err.Field(), err.Tag(), fieldMessage(err)})
+	}
+
+	return fields
+}
+
+// fieldName returns the name of a field in the JSON bodies or in the queries
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name := strings.Split(field.Tag.Get(key), ",")[0]
+		if len(name) > 0 && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// fieldMessage explains the rule broken by a field
+func fieldMessage(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "min":
+		if err.Kind() == reflect.String {
+			return "must have at least " + err.Param() + " characters"
+		}
+		return "must be at least " + err.Param()
+	case "max":
+		if err.Kind() == reflect.String {
+			return "must not be longer than " + err.Param() + " characters"
+		}
+		return "must be at most " + err.Param()
+	case "excluded_with":
+		return "can't be used with " + strings.ToLower(err.Param())
+	case "url":
+		return "must be a URL"
+	case "urlorpath":
+		return "must be an http or https URL or an absolute path"
+	default:
+		return "is not valid"
+	}
+}
+
+// notBlank checks that a text has other characters than spaces
+func notBlank(fl validator.FieldLevel) bool {
+	return len(strings.TrimSpace(fl.Field().String())) > 0
+}
+
+// urlOrPath checks that a text is an http or https URL or an absolute path, like the path of an
+// uploaded cover. The other schemes, like javascript: or data:, are rejected.
+func urlOrPath(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+
+	if u.IsAbs() {
+		return (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+	}
+	return strings.HasPrefix(value, "/") && !strings.HasPrefix(value, "//")
+}
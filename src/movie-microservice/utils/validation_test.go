@@ -0,0 +1,44 @@
+/*
+ * @File: utils.validation_test.go
+ * @Description: Tests the validation rules of the binding tags
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package utils
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestURLOrPath(t *testing.T) {
+	v := validator.New()
+	v.RegisterValidation("urlorpath", urlOrPath)
+
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"https://movies.example.com/movie.jpg", true},
+		{"http://movies.example.com:8080/movie.jpg?size=small", true},
+		{"HTTPS://movies.example.com/movie.jpg", true},
+		{"/api/v1/movies/5bbdadf782ebac06a695a8e7/cover", true},
+		{"https:///movie.jpg", false},
+		{"//movies.example.com/movie.jpg", false},
+		{"movie.jpg", false},
+		{"javascript:alert(1)", false},
+		{"JavaScript://movies.example.com/%0Aalert(1)", false},
+		{"data:image/png;base64,iVBORw0KGgo=", false},
+		{"file://movies.example.com/etc/passwd", false},
+		{"ftp://movies.example.com/movie.jpg", false},
+		{"http://movies.example.com/%zz", false},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			err := v.Var(test.value, "urlorpath")
+			if valid := err == nil; valid != test.valid {
+				t.Errorf("valid = %v, want %v: %v", valid, test.valid, err)
+			}
+		})
+	}
+}
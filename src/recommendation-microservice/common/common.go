@@ -0,0 +1,137 @@
+/*
+ * @File: common.common.go
+ * @Description: Defines common information of the service
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package common
+
+// Configuration stores setting values
+type Configuration struct {
+	Port                string `json:"port"`
+	EnableGinConsoleLog bool   `json:"enableGinConsoleLog"`
+	EnableGinFileLog    bool   `json:"enableGinFileLog"`
+
+	ReadTimeout     int `json:"readTimeout"`     // seconds, of reading a request
+	WriteTimeout    int `json:"writeTimeout"`    // seconds, of writing a response
+	IdleTimeout     int `json:"idleTimeout"`     // seconds, of the idle keep-alive connections
+	ShutdownTimeout int `json:"shutdownTimeout"` // seconds, of draining the requests in flight when the service stops
+
+	LogLevel      string `json:"logLevel"` // panic, fatal, error, warn, info, debug or trace
+	LogFilename   string `json:"logFilename"`
+	LogMaxSize    int    `json:"logMaxSize"`
+	LogMaxBackups int    `json:"logMaxBackups"`
+	LogMaxAge     int    `json:"logMaxAge"`
+
+	MgAddrs      string `json:"mgAddrs"`
+	MgDbName     string `json:"mgDbName"`
+	MgDbUsername string `json:"mgDbUsername"`
+	MgDbPassword string `json:"mgDbPassword"`
+
+	MgMaxPoolSize     uint64 `json:"mgMaxPoolSize"`
+	MgMinPoolSize     uint64 `json:"mgMinPoolSize"`
+	MgMaxConnIdleTime int    `json:"mgMaxConnIdleTime"` // seconds, 0 keeps the idle connections
+	MgTimeout         int    `json:"mgTimeout"`         // seconds, of every database call
+	MgReadConcern     string `json:"mgReadConcern"`     // local, available, majority, linearizable or snapshot
+	MgWriteConcern    string `json:"mgWriteConcern"`    // majority or the number of acknowledging nodes
+	MgReadPreference  string `json:"mgReadPreference"`  // primary, primaryPreferred, secondary, secondaryPreferred or nearest
+	MgStartupTimeout  int    `json:"mgStartupTimeout"`  // seconds, the service waits for MongoDB when it starts, 0 fails at once
+
+	Storage       string `json:"storage"`       // mongodb, postgres or sqlite, the storage of the movie service
+	SQLDataSource string `json:"sqlDataSource"` // connection string of the postgres or sqlite database
+	SQLTimeout    int    `json:"sqlTimeout"`    // seconds, of every SQL database call
+
+	AuthAddr           string `json:"authAddr"`
+	JwksURL            string `json:"jwksURL"`
+	JwksCacheTTL       int    `json:"jwksCacheTTL"` // seconds
+	Issuer             string `json:"issuer"`
+	RevocationCacheTTL int    `json:"revocationCacheTTL"` // seconds
+
+	MovieAddr     string `json:"movieAddr"`
+	MovieCacheTTL int    `json:"movieCacheTTL"` // seconds
+}
+
+// COLLECTIONs of the database table, they are written by the movie service
+const (
+	ColRatings      = "ratings"
+	ColWatchHistory = "watch_history"
+)
+
+// Storages of the ratings and the watch history, the ones of the movie service
+const (
+	StorageMongoDB  = "mongodb"
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+)
+
+// Recommendation limits
+const (
+	DefaultRecommendationLimit = 10
+	MaxRecommendationLimit     = 50
+
+	// MaxMoviePageLimit is the largest page of the movie list API of the movie service
+	MaxMoviePageLimit = 100
+)
+
+// Reasons of the recommendations
+const (
+	ReasonSimilarUsers = "similar_users"
+	ReasonGenres       = "genres"
+	ReasonPopular      = "popular"
+)
+
+// Roles of the users, every role includes the permissions of the roles after it
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Statuses of the health probes
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+// Status Text
+const (
+	ErrNotObjectIDHex        = "String is not a valid hex representation of an ObjectId"
+	ErrMovieNotFound         = "Movie not found"
+	ErrMovieUnavailable      = "Movie service is unavailable"
+	ErrTokenInvalid          = "Token is not valid"
+	ErrPermissionDenied      = "Permission denied"
+	ErrTokenRevoked          = "Token has been revoked"
+	ErrAuthUnavailable       = "Authentication service is unavailable"
+	ErrJWKUnsupported        = "Only RS256 and ES256 keys are supported"
+	ErrRecommendLimitInvalid = "Limit must be between 0 and 50"
+
+	ErrMgWriteConcernInvalid   = "Write concern must be majority or a number of nodes"
+	ErrMgReadPreferenceInvalid = "Read preference must be primary, primaryPreferred, secondary, secondaryPreferred or nearest"
+
+	ErrConfigInvalid   = "Configuration is not valid"
+	ErrLogLevelInvalid = "Log level must be panic, fatal, error, warn, info, debug or trace"
+
+	ErrInternal            = "Internal error, see the log of the service"
+	ErrUpstreamUnavailable = "A service or a database is unavailable"
+
+	ErrMgConnecting = "MongoDB is not connected yet"
+
+	ErrStorageDatabaseUnsupported = "Storage must be mongodb, postgres or sqlite"
+	ErrSQLDataSourceEmpty         = "SQL data source is empty"
+)
+
+// Status Code. The codes of the error catalog, see models.ErrorKind, are the ones of the
+// user and movie services and never change.
+const (
+	StatusCodeUnknown = -1
+	StatusCodeOK      = 1000
+
+	StatusMismatch            = 10
+	StatusDuplicate           = 11
+	StatusValidationFailed    = 12
+	StatusNotFound            = 13
+	StatusUnauthorized        = 14
+	StatusForbidden           = 15
+	StatusConflict            = 16
+	StatusUpstreamUnavailable = 17
+	StatusPayloadTooLarge     = 18
+)
@@ -0,0 +1,182 @@
+/*
+ * @File: common.config_test.go
+ * @Description: Tests the layers of the configuration: defaults, config files and environment
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// testConfig is a config file with the settings having no default
+const testConfig = `{
+	"mgAddrs": "127.0.0.1:27017",
+	"mgDbName": "go-microservices",
+	"authAddr": "http://127.0.0.1:8808",
+	"issuer": "seedotech",
+	"movieAddr": "http://127.0.0.1:8809"
+}`
+
+// writeTestFile writes a file in a temporary directory and returns its name
+func writeTestFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	name = filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(name, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestReadConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		env   map[string]string
+		check func(t *testing.T, config *Configuration)
+		err   []string // parts of the error, the configuration is valid when empty
+	}{
+		{
+			name:  "defaults",
+			files: []string{testConfig},
+			check: func(t *testing.T, config *Configuration) {
+				if config.Port != ":8810" || config.MovieCacheTTL != 60 || config.RevocationCacheTTL != 30 || config.Storage != StorageMongoDB {
+					t.Errorf("config = %+v", config)
+				}
+			},
+		},
+		{
+			name:  "later files override the earlier ones",
+			files: []string{testConfig, `{"movieAddr": "http://movies:8809", "movieCacheTTL": 10}`, `{"movieCacheTTL": 20}`},
+			check: func(t *testing.T, config *Configuration) {
+				if config.MovieAddr != "http://movies:8809" || config.MovieCacheTTL != 20 || config.Issuer != "seedotech" {
+					t.Errorf("config = %+v", config)
+				}
+			},
+		},
+		{
+			name:  "environment overrides the files",
+			files: []string{testConfig, `{"movieCacheTTL": 10}`},
+			env: map[string]string{
+				"RECOMMENDATIONSVC_MOVIECACHETTL":       "5",
+				"RECOMMENDATIONSVC_STORAGE":             StoragePostgres,
+				"RECOMMENDATIONSVC_SQLDATASOURCE":       "postgres://127.0.0.1/movies",
+				"RECOMMENDATIONSVC_ENABLEGINCONSOLELOG": "false",
+			},
+			check: func(t *testing.T, config *Configuration) {
+				if config.MovieCacheTTL != 5 || config.Storage != StoragePostgres || config.SQLDataSource != "postgres://127.0.0.1/movies" || config.EnableGinConsoleLog {
+					t.Errorf("config = %+v", config)
+				}
+			},
+		},
+		{
+			name:  "secret read from a file",
+			files: []string{testConfig},
+			env:   map[string]string{"RECOMMENDATIONSVC_MGDBPASSWORD_FILE": "secret\n"},
+			check: func(t *testing.T, config *Configuration) {
+				if config.MgDbPassword != "secret" {
+					t.Errorf("mgDbPassword = %q", config.MgDbPassword)
+				}
+			},
+		},
+		{
+			name:  "unknown setting",
+			files: []string{testConfig, `{"movieURL": "http://movies:8809"}`},
+			err:   []string{"override.json", `unknown field "movieURL"`},
+		},
+		{
+			name:  "invalid variable",
+			files: []string{testConfig},
+			env:   map[string]string{"RECOMMENDATIONSVC_MOVIECACHETTL": "1m"},
+			err:   []string{"RECOMMENDATIONSVC_MOVIECACHETTL"},
+		},
+		{
+			name:  "every problem reported",
+			files: []string{`{"storage": "mysql", "movieCacheTTL": 0, "logLevel": "loud"}`},
+			err: []string{
+				ErrConfigInvalid,
+				"logLevel: " + ErrLogLevelInvalid,
+				"storage: " + ErrStorageDatabaseUnsupported,
+				"authAddr is required",
+				"issuer is required",
+				"movieAddr is required, set it in a config file or with RECOMMENDATIONSVC_MOVIEADDR",
+				"movieCacheTTL must be positive",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The first file is named config.json, the next ones override.json
+			var files []string
+			for i, content := range test.files {
+				name := "override.json"
+				if i == 0 {
+					name = "config.json"
+				}
+				files = append(files, writeTestFile(t, name, content))
+			}
+			for key, value := range test.env {
+				if strings.HasSuffix(key, "_FILE") {
+					value = writeTestFile(t, "secret", value)
+				}
+				t.Setenv(key, value)
+			}
+
+			config, err := readConfig(files)
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				test.check(t, config)
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("config = %+v, want an error", config)
+			}
+			for _, part := range test.err {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("error = %v, want %q", err, part)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	first := writeTestFile(t, "config.json", testConfig)
+	second := writeTestFile(t, "override.json", `{"port": ":9000", "logFilename": "`+filepath.ToSlash(filepath.Join(dir, "server.log"))+`"}`)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		current, configFiles = atomic.Value{}, nil
+	})
+
+	// The files of the environment are read when no file is given
+	t.Setenv("RECOMMENDATIONSVC_CONFIG", first+","+second)
+	if err := LoadConfig(nil); err != nil {
+		t.Fatal(err)
+	}
+	if Config().Port != ":9000" || Config().MovieAddr != "http://127.0.0.1:8809" {
+		t.Errorf("config = %+v", Config())
+	}
+	if len(configFiles) != 2 || configFiles[0] != first || configFiles[1] != second {
+		t.Errorf("config files = %v, they are read again by ReloadConfig", configFiles)
+	}
+
+	// The given files are preferred, an invalid configuration is not stored
+	if err := LoadConfig([]string{first, writeTestFile(t, "invalid.json", `{"movieAddr": ""}`)}); err == nil {
+		t.Fatal("invalid configuration loaded")
+	}
+	if Config().Port != ":9000" {
+		t.Errorf("config = %+v, want the previous one", Config())
+	}
+}
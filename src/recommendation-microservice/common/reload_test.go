@@ -0,0 +1,161 @@
+/*
+ * @File: common.reload_test.go
+ * @Description: Tests the reload of the configuration without restarting the service
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// loadTestConfig loads the configuration of a config file overriding testConfig, the log is written
+// to a temporary directory. The config file is returned, the configuration is unloaded by the cleanup.
+func loadTestConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	logFile := filepath.ToSlash(filepath.Join(t.TempDir(), "server.log"))
+	base := writeTestFile(t, "config.json", strings.Replace(testConfig, "{", `{"logFilename": "`+logFile+`",`, 1))
+	file := writeTestFile(t, "override.json", content)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		current, configFiles = atomic.Value{}, nil
+	})
+
+	if err := LoadConfig([]string{base, file}); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestReloadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		check   func(t *testing.T, config *Configuration)
+		err     bool
+	}{
+		{
+			name:    "reloadable settings",
+			content: `{"logLevel": "warn", "mgTimeout": 5, "movieAddr": "http://movies:8809", "movieCacheTTL": 10}`,
+			check: func(t *testing.T, config *Configuration) {
+				if config.LogLevel != "warn" || config.MgTimeout != 5 || config.MovieAddr != "http://movies:8809" || config.MovieCacheTTL != 10 {
+					t.Errorf("config = %+v", config)
+				}
+				if log.GetLevel() != log.WarnLevel {
+					t.Errorf("log level = %v, want warn", log.GetLevel())
+				}
+			},
+		},
+		{
+			name:    "restart settings kept",
+			content: `{"port": ":9000", "mgDbName": "other", "storage": "sqlite", "sqlDataSource": "movies.db", "mgTimeout": 5}`,
+			check: func(t *testing.T, config *Configuration) {
+				if config.Port != ":8810" || config.MgDbName != "go-microservices" || config.Storage != StorageMongoDB || len(config.SQLDataSource) > 0 {
+					t.Errorf("config = %+v, want the restart settings unchanged", config)
+				}
+				if config.MgTimeout != 5 {
+					t.Errorf("mgTimeout = %d, want the reloadable settings changed", config.MgTimeout)
+				}
+			},
+		},
+		{
+			name: "environment reloaded",
+			env:  map[string]string{"RECOMMENDATIONSVC_MGTIMEOUT": "7", "RECOMMENDATIONSVC_PORT": ":9000"},
+			check: func(t *testing.T, config *Configuration) {
+				if config.MgTimeout != 7 || config.Port != ":8810" {
+					t.Errorf("config = %+v", config)
+				}
+			},
+		},
+		{
+			name:    "invalid configuration",
+			content: `{"logLevel": "loud", "mgTimeout": 5}`,
+			err:     true,
+		},
+		{
+			name:    "unreadable configuration",
+			content: `{"mgTimeout": 5`,
+			err:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := loadTestConfig(t, `{"logLevel": "info"}`)
+			previous := Config()
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			if len(test.content) > 0 {
+				if err := ioutil.WriteFile(file, []byte(test.content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := ReloadConfig()
+			if test.err {
+				if err == nil {
+					t.Fatalf("config = %+v, want an error", Config())
+				}
+				if Config() != previous || Config().MgTimeout != 10 || log.GetLevel() != log.InfoLevel {
+					t.Errorf("config = %+v, want the previous one", Config())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if Config() == previous {
+				t.Error("the new configuration is not swapped in")
+			}
+			if previous.MgTimeout != 10 {
+				t.Errorf("previous config = %+v, it must not be modified", previous)
+			}
+			test.check(t, Config())
+		})
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	file := loadTestConfig(t, `{"mgTimeout": 5}`)
+	t.Cleanup(WatchConfig(10 * time.Millisecond))
+
+	// waitConfig waits for the reload of the configuration
+	waitConfig := func(reloaded func(config *Configuration) bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !reloaded(Config()); time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("config = %+v, not reloaded", Config())
+			}
+		}
+	}
+
+	// A modified config file is reloaded
+	if err := ioutil.WriteFile(file, []byte(`{"mgTimeout": 6}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatal(err)
+	}
+	waitConfig(func(config *Configuration) bool { return config.MgTimeout == 6 })
+
+	// The configuration is reloaded on SIGHUP, the environment isn't watched
+	t.Setenv("RECOMMENDATIONSVC_MGTIMEOUT", "7")
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	waitConfig(func(config *Configuration) bool { return config.MgTimeout == 7 })
+}
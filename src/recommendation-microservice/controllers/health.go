@@ -0,0 +1,105 @@
+/*
+ * @File: controllers.health.go
+ * @Description: Implements the liveness and readiness probes of the service
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"../common"
+	"../models"
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout bounds the checks of the dependencies
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheck checks a dependency of the service, it returns an error when the dependency is unavailable
+type HealthCheck func(ctx context.Context) error
+
+// Health manages the liveness and readiness probes
+type Health struct {
+	checks map[string]HealthCheck
+}
+
+// NewHealth creates the probes, the readiness runs the checks of the named dependencies
+func NewHealth(checks map[string]HealthCheck) *Health {
+	return &Health{checks}
+}
+
+// Live responds with 200 while the service is running.
+// It is served at /healthz, outside of the API base path.
+func (h *Health) Live(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, models.Health{Status: common.HealthOK})
+}
+
+// Ready checks the dependencies of the service concurrently, it responds with 200 when all of them
+// are available and with 503 otherwise. It is served at /readyz, outside of the API base path.
+func (h *Health) Ready(ctx *gin.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), healthCheckTimeout)
+	defer cancel()
+
+	var mutex sync.Mutex
+	var wait sync.WaitGroup
+	health := models.Health{common.HealthOK, make(map[string]models.DependencyHealth)}
+
+	for name, check := range h.checks {
+		wait.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wait.Done()
+
+			start := time.Now()
+			err := check(checkCtx)
+			dependency := models.DependencyHealth{common.HealthOK, time.Since(start).Milliseconds(), ""}
+			if err != nil {
+				dependency.Status, dependency.Error = common.HealthUnavailable, err.Error()
+			}
+
+			mutex.Lock()
+			health.Dependencies[name] = dependency
+			if err != nil {
+				health.Status = common.HealthUnavailable
+			}
+			mutex.Unlock()
+		}(name, check)
+	}
+	wait.Wait()
+
+	if health.Status != common.HealthOK {
+		ctx.JSON(http.StatusServiceUnavailable, health)
+		return
+	}
+	ctx.JSON(http.StatusOK, health)
+}
+
+// HTTPCheck returns a HealthCheck requesting the url returned by the given function, the dependency
+// is available when it responds with a 2xx status
+func HTTPCheck(url func() string) HealthCheck {
+	client := &http.Client{Timeout: healthCheckTimeout}
+
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url(), nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return errors.New(strconv.Itoa(resp.StatusCode) + " " + http.StatusText(resp.StatusCode))
+		}
+
+		return nil
+	}
+}
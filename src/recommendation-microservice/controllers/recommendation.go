@@ -0,0 +1,292 @@
+/*
+ * @File: controllers.recommendation.go
+ * @Description: Implements Recommendation API logic functions
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"../common"
+	"../daos"
+	"../middlewares"
+	"../models"
+	"../utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxHistoryInteractions = 5000 // interactions read per query
+	maxSimilarUsers        = 50
+	maxGenreMovies         = 20 // liked movies defining the favourite genres
+	maxFavouriteGenres     = 3
+
+	genreWeight   = 0.5
+	popularWeight = 0.1
+)
+
+// Recommendation manages the movie recommendations
+type Recommendation struct {
+	utils      utils.Utils
+	historyDAO daos.HistoryRepository
+	movieDAO   *daos.Movie
+}
+
+// NewRecommendation creates the Recommendation APIs reading the ratings and the watches from the given repository
+// and the movies from the movie service
+func NewRecommendation(historyDAO daos.HistoryRepository, movieDAO *daos.Movie) *Recommendation {
+	return &Recommendation{historyDAO: historyDAO, movieDAO: movieDAO}
+}
+
+// candidate is a movie which may be recommended
+type candidate struct {
+	id      models.ObjectID
+	score   float64
+	reasons []string
+}
+
+// GetRecommendations godoc
+// @Summary Recommend movies to a user
+// @Description Recommend the movies liked by the users having the same tastes, then the movies of the favourite genres and the popular movies. The movies already watched or rated are not recommended. Users get their own recommendations, admins get everyone's.
+// @Tags recommendation
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Token"
+// @Param userId path string true "User ID"
+// @Param limit query int false "Number of movies, 10 by default, 50 at most"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Failure 503 {object} models.Error
+// @Success 200 {object} models.Recommendations
+// @Router /recommendations/{userId} [get]
+func (r *Recommendation) GetRecommendations(ctx *gin.Context) {
+	userID := ctx.Params.ByName("userId")
+	if err := r.utils.ValidateObjectID(userID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	claims := middlewares.Claims(ctx)
+	if claims == nil || (claims.Subject != userID && !r.utils.HasRole(claims.Role, common.RoleAdmin)) {
+		ctx.Error(models.NewError(models.KindForbidden, common.ErrPermissionDenied))
+		return
+	}
+
+	limit := common.DefaultRecommendationLimit
+	if value := ctx.Query("limit"); len(value) > 0 {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > common.MaxRecommendationLimit {
+			ctx.Error(models.NewError(models.KindValidationFailed, common.ErrRecommendLimitInvalid))
+			return
+		}
+	}
+
+	recommendations, err := r.recommend(ctx.Request.Context(), userID, limit)
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.Recommendations{userID, recommendations})
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// recommend computes the best movies for a user from the watch and rating history
+func (r *Recommendation) recommend(ctx context.Context, userID string, limit int) ([]models.Recommendation, error) {
+	mine, err := r.historyDAO.GetInteractions(ctx, models.InteractionFilter{UserIDs: []string{userID}}, maxHistoryInteractions)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[models.ObjectID]bool)
+	liked := make(map[models.ObjectID]float64)
+	var seenIDs, likedIDs []models.ObjectID
+	for _, interaction := range mine {
+		seen[interaction.MovieID] = true
+		seenIDs = append(seenIDs, interaction.MovieID)
+		if interaction.Weight > 0 {
+			liked[interaction.MovieID] = interaction.Weight
+			likedIDs = append(likedIDs, interaction.MovieID)
+		}
+	}
+
+	candidates := make(map[models.ObjectID]*candidate)
+	add := func(id models.ObjectID, score float64, reason string) {
+		if seen[id] {
+			return
+		}
+		c, ok := candidates[id]
+		if !ok {
+			c = &candidate{id: id}
+			candidates[id] = c
+		}
+		c.score += score
+		if len(c.reasons) == 0 || c.reasons[len(c.reasons)-1] != reason {
+			c.reasons = append(c.reasons, reason)
+		}
+	}
+
+	// The movies liked by the users who like the same movies
+	if len(likedIDs) > 0 {
+		others, err := r.historyDAO.GetInteractions(ctx, models.InteractionFilter{MovieIDs: likedIDs, ExcludedUserID: userID}, maxHistoryInteractions)
+		if err != nil {
+			return nil, err
+		}
+
+		similarities := similarUsers(liked, others)
+		if len(similarities) > 0 {
+			userIDs := make([]string, 0, len(similarities))
+			for id := range similarities {
+				userIDs = append(userIDs, id)
+			}
+
+			neighbours, err := r.historyDAO.GetInteractions(ctx, models.InteractionFilter{UserIDs: userIDs, ExcludedMovieIDs: seenIDs}, maxHistoryInteractions)
+			if err != nil {
+				return nil, err
+			}
+			for _, interaction := range neighbours {
+				if interaction.Weight > 0 {
+					add(interaction.MovieID, similarities[interaction.UserID]*interaction.Weight, common.ReasonSimilarUsers)
+				}
+			}
+		}
+	}
+
+	// The latest movies of the favourite genres
+	genres, err := r.favouriteGenres(ctx, liked)
+	if err != nil {
+		return nil, err
+	}
+	for _, genre := range genres {
+		movies, err := r.movieDAO.GetByGenre(ctx, genre.id, limit, seen)
+		if err != nil {
+			return nil, err
+		}
+		for _, movie := range movies {
+			add(movie.ID, genreWeight*genre.score, common.ReasonGenres)
+		}
+	}
+
+	// The popular movies fill the remaining places, mostly for the new users
+	if len(candidates) < limit {
+		popular, err := r.historyDAO.GetPopular(ctx, limit+len(seen))
+		if err != nil {
+			return nil, err
+		}
+		for i, id := range popular {
+			if _, ok := candidates[id]; !ok {
+				add(id, popularWeight/float64(i+1), common.ReasonPopular)
+			}
+		}
+	}
+
+	ranked := make([]candidate, 0, len(candidates))
+	for _, c := range candidates {
+		ranked = append(ranked, *c)
+	}
+	sortCandidates(ranked)
+
+	recommendations := make([]models.Recommendation, 0, limit)
+	for _, c := range ranked {
+		if len(recommendations) == limit {
+			break
+		}
+
+		movie, err := r.movieDAO.GetByID(ctx, c.id)
+		if err == daos.ErrMovieNotFound {
+			// The movie has been deleted
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		recommendations = append(recommendations, models.Recommendation{movie, math.Round(c.score*1000) / 1000, c.reasons})
+	}
+
+	return recommendations, nil
+}
+
+// favouriteGenres returns the genres of the most liked movies, weighted from 0 to 1
+func (r *Recommendation) favouriteGenres(ctx context.Context, liked map[models.ObjectID]float64) ([]candidate, error) {
+	movies := make([]candidate, 0, len(liked))
+	for id, weight := range liked {
+		movies = append(movies, candidate{id: id, score: weight})
+	}
+	sortCandidates(movies)
+	if len(movies) > maxGenreMovies {
+		movies = movies[:maxGenreMovies]
+	}
+
+	affinities := make(map[models.ObjectID]float64)
+	for _, c := range movies {
+		movie, err := r.movieDAO.GetByID(ctx, c.id)
+		if err == daos.ErrMovieNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, genreID := range movie.Genres {
+			affinities[genreID] += c.score
+		}
+	}
+
+	genres := make([]candidate, 0, len(affinities))
+	for id, affinity := range affinities {
+		genres = append(genres, candidate{id: id, score: affinity})
+	}
+	sortCandidates(genres)
+	if len(genres) > maxFavouriteGenres {
+		genres = genres[:maxFavouriteGenres]
+	}
+	for i := range genres {
+		genres[i].score /= genres[0].score
+	}
+
+	return genres, nil
+}
+
+// similarUsers weights the other users by the movies they like as much as the user does,
+// only the most similar users are kept
+func similarUsers(liked map[models.ObjectID]float64, others []models.Interaction) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, interaction := range others {
+		if weight, ok := liked[interaction.MovieID]; ok && interaction.Weight > 0 {
+			weights[interaction.UserID] += math.Min(weight, interaction.Weight)
+		}
+	}
+
+	if len(weights) > maxSimilarUsers {
+		users := make([]string, 0, len(weights))
+		for id := range weights {
+			users = append(users, id)
+		}
+		sort.Slice(users, func(i, j int) bool {
+			if weights[users[i]] != weights[users[j]] {
+				return weights[users[i]] > weights[users[j]]
+			}
+			return users[i] < users[j]
+		})
+		for _, id := range users[maxSimilarUsers:] {
+			delete(weights, id)
+		}
+	}
+
+	return weights
+}
+
+// sortCandidates sorts by the descending score then by id
+func sortCandidates(candidates []candidate) {
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].id.Hex() < candidates[j].id.Hex()
+	})
+}
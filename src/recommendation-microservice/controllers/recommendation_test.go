@@ -0,0 +1,256 @@
+/*
+ * @File: controllers.recommendation_test.go
+ * @Description: Tests the Recommendation API logic functions against an in-memory history and a fake movie service
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"../common"
+	"../daos"
+	"../middlewares"
+	"../models"
+	"../utils"
+	"github.com/gin-gonic/gin"
+)
+
+// movieServer serves the movie APIs read by the recommendations. Its list API rejects the pages
+// larger than the ones of the movie service and sorts the movies by their descending id.
+type movieServer struct {
+	mutex    sync.Mutex
+	movies   []models.Movie // by ascending id
+	maxLimit int            // largest page requested
+}
+
+// newMovieServer creates the given number of movies of the given genres
+func newMovieServer(count int, genres ...models.ObjectID) *movieServer {
+	s := &movieServer{}
+	for i := 0; i < count; i++ {
+		s.movies = append(s.movies, models.Movie{ID: models.NewObjectID(), Name: "Movie " + strconv.Itoa(i), Genres: genres})
+	}
+	return s
+}
+
+func (s *movieServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if r.URL.Path != "/api/v1/movies/list" {
+		id := strings.TrimPrefix(r.URL.Path, "/api/v1/movies/")
+		for _, movie := range s.movies {
+			if movie.ID.Hex() == id {
+				json.NewEncoder(w).Encode(movie)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 || limit > common.MaxMoviePageLimit || query.Get("sort") != "-id" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if limit > s.maxLimit {
+		s.maxLimit = limit
+	}
+
+	// The cursor of the fake API is the id of the last movie of the page
+	page := models.MoviePage{Items: []models.Movie{}}
+	for i := len(s.movies) - 1; i >= 0; i-- {
+		movie := s.movies[i]
+		if hasGenre(movie, query.Get("genre")) && (len(query.Get("after")) == 0 || movie.ID.Hex() < query.Get("after")) {
+			page.Items = append(page.Items, movie)
+		}
+	}
+	page.Total = len(page.Items)
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.NextCursor = page.Items[limit-1].ID.Hex()
+	}
+	json.NewEncoder(w).Encode(page)
+}
+
+func hasGenre(movie models.Movie, genreID string) bool {
+	for _, genre := range movie.Genres {
+		if genre.Hex() == genreID {
+			return true
+		}
+	}
+	return false
+}
+
+// newRecommendationRouter routes the Recommendation API like main does, the requests are
+// authenticated with the given claims
+func newRecommendationRouter(t *testing.T, server *movieServer, history daos.HistoryRepository, claims *utils.SdtClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	config := &common.Configuration{MovieAddr: ts.URL, MovieCacheTTL: 60}
+
+	c := NewRecommendation(history, daos.NewMovie(func() *common.Configuration { return config }))
+	router := gin.New()
+	router.Use(middlewares.Errors())
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set(middlewares.ClaimsKey, claims)
+	})
+	router.GET("/recommendations/:userId", c.GetRecommendations)
+
+	return router
+}
+
+// userClaims returns the claims of the token of a user
+func userClaims(userID string, role string) *utils.SdtClaims {
+	claims := &utils.SdtClaims{Role: role}
+	claims.Subject = userID
+	return claims
+}
+
+// getRecommendations requests the recommendations of a user, the test fails when the status is not the expected one
+func getRecommendations(t *testing.T, router *gin.Engine, path string, status int, result interface{}) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body.String())
+	}
+	if result != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), result); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetRecommendations(t *testing.T) {
+	userID := models.NewObjectID().Hex()
+
+	tests := []struct {
+		name    string
+		history func(h *daos.MemoryHistory, movies []models.Movie)
+		movies  []int // indexes of the recommended movies
+		reasons []string
+	}{
+		{"cold start", func(h *daos.MemoryHistory, movies []models.Movie) {
+			for i, count := range []int{2, 0, 3, 1} {
+				for k := 0; k < count; k++ {
+					h.AddWatch(models.Watch{MovieID: movies[i].ID, UserID: models.NewObjectID().Hex(), Completed: true})
+				}
+			}
+		}, []int{2, 0, 3}, []string{common.ReasonPopular}},
+		{"similar users", func(h *daos.MemoryHistory, movies []models.Movie) {
+			other := models.NewObjectID().Hex()
+			h.AddRating(models.Rating{MovieID: movies[0].ID, UserID: userID, Score: 5})
+			h.AddRating(models.Rating{MovieID: movies[0].ID, UserID: other, Score: 5})
+			h.AddRating(models.Rating{MovieID: movies[1].ID, UserID: other, Score: 4})
+			h.AddRating(models.Rating{MovieID: movies[2].ID, UserID: other, Score: 1})
+		}, []int{1}, []string{common.ReasonSimilarUsers}},
+		{"seen movies", func(h *daos.MemoryHistory, movies []models.Movie) {
+			for i, count := range []int{3, 2, 1, 1} {
+				for k := 0; k < count; k++ {
+					h.AddWatch(models.Watch{MovieID: movies[i].ID, UserID: models.NewObjectID().Hex(), Completed: true})
+				}
+			}
+			h.AddRating(models.Rating{MovieID: movies[0].ID, UserID: userID, Score: 1})
+			h.AddWatch(models.Watch{MovieID: movies[2].ID, UserID: userID, Position: 60, Duration: 6000})
+		}, []int{1, 3}, []string{common.ReasonPopular}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newMovieServer(4)
+			history := daos.NewMemoryHistory()
+			test.history(history, server.movies)
+			router := newRecommendationRouter(t, server, history, userClaims(userID, common.RoleViewer))
+
+			var recommendations models.Recommendations
+			getRecommendations(t, router, "/recommendations/"+userID, http.StatusOK, &recommendations)
+
+			if recommendations.UserID != userID || len(recommendations.Items) != len(test.movies) {
+				t.Fatalf("recommendations = %+v, want %d movies", recommendations, len(test.movies))
+			}
+			for k, i := range test.movies {
+				item := recommendations.Items[k]
+				if item.Movie.ID != server.movies[i].ID || strings.Join(item.Reasons, ",") != strings.Join(test.reasons, ",") {
+					t.Errorf("recommendation %d = %+v, want movie %d for %v", k, item, i, test.reasons)
+				}
+			}
+		})
+	}
+}
+
+func TestGetRecommendationsPermissions(t *testing.T) {
+	userID := models.NewObjectID().Hex()
+	otherID := models.NewObjectID().Hex()
+
+	tests := []struct {
+		name   string
+		claims *utils.SdtClaims
+		path   string
+		status int
+	}{
+		{"own recommendations", userClaims(userID, common.RoleViewer), "/recommendations/" + userID, http.StatusOK},
+		{"recommendations of another user", userClaims(otherID, common.RoleEditor), "/recommendations/" + userID, http.StatusForbidden},
+		{"recommendations of another user as admin", userClaims(otherID, common.RoleAdmin), "/recommendations/" + userID, http.StatusOK},
+		{"no token", nil, "/recommendations/" + userID, http.StatusForbidden},
+		{"invalid user id", userClaims(userID, common.RoleViewer), "/recommendations/invalid", http.StatusBadRequest},
+		{"limit too large", userClaims(userID, common.RoleViewer), "/recommendations/" + userID + "?limit=51", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := newRecommendationRouter(t, newMovieServer(1), daos.NewMemoryHistory(), test.claims)
+
+			var failure models.Error
+			if test.status == http.StatusOK {
+				getRecommendations(t, router, test.path, test.status, nil)
+				return
+			}
+			getRecommendations(t, router, test.path, test.status, &failure)
+			if test.status == http.StatusForbidden && failure.Message != common.ErrPermissionDenied {
+				t.Errorf("error = %+v, want %q", failure, common.ErrPermissionDenied)
+			}
+		})
+	}
+}
+
+func TestGetRecommendationsManySeenMovies(t *testing.T) {
+	userID := models.NewObjectID().Hex()
+	genreID := models.NewObjectID()
+
+	// The user liked the latest movies of the genre, more than a page of the movie list API
+	server := newMovieServer(common.MaxMoviePageLimit+60, genreID)
+	history := daos.NewMemoryHistory()
+	seen := make(map[models.ObjectID]bool)
+	for _, movie := range server.movies[10:] {
+		history.AddRating(models.Rating{MovieID: movie.ID, UserID: userID, Score: 5})
+		seen[movie.ID] = true
+	}
+	router := newRecommendationRouter(t, server, history, userClaims(userID, common.RoleViewer))
+
+	var recommendations models.Recommendations
+	getRecommendations(t, router, "/recommendations/"+userID+"?limit=10", http.StatusOK, &recommendations)
+
+	if len(recommendations.Items) != 10 {
+		t.Fatalf("recommendations = %d, want 10", len(recommendations.Items))
+	}
+	for _, item := range recommendations.Items {
+		if seen[item.Movie.ID] || strings.Join(item.Reasons, ",") != common.ReasonGenres {
+			t.Errorf("recommendation = %+v, want an unseen movie of the genre", item)
+		}
+	}
+	if server.maxLimit > common.MaxMoviePageLimit {
+		t.Errorf("page of %d movies requested, the movie service returns %d at most", server.maxLimit, common.MaxMoviePageLimit)
+	}
+}
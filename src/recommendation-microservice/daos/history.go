@@ -0,0 +1,133 @@
+/*
+ * @File: daos.history.go
+ * @Description: Reads the ratings and the watch history of the users from MongoDB
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+
+	"../common"
+	"../databases"
+	"../models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// History reads the ratings and the watch history of the users
+type History struct {
+}
+
+// interactionQuery returns the MongoDB query of an interaction filter
+func interactionQuery(filter models.InteractionFilter) bson.M {
+	userID, movieID := bson.M{}, bson.M{}
+	if len(filter.UserIDs) > 0 {
+		userID["$in"] = filter.UserIDs
+	}
+	if len(filter.ExcludedUserID) > 0 {
+		userID["$ne"] = filter.ExcludedUserID
+	}
+	if len(filter.MovieIDs) > 0 {
+		movieID["$in"] = filter.MovieIDs
+	}
+	if len(filter.ExcludedMovieIDs) > 0 {
+		movieID["$nin"] = filter.ExcludedMovieIDs
+	}
+
+	query := bson.M{}
+	if len(userID) > 0 {
+		query["userId"] = userID
+	}
+	if len(movieID) > 0 {
+		query["movieId"] = movieID
+	}
+	return query
+}
+
+// GetInteractions gets the ratings and the watches matching the filter, at most limit of each
+func (h *History) GetInteractions(ctx context.Context, filter models.InteractionFilter, limit int) ([]models.Interaction, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	query := interactionQuery(filter)
+
+	// Get the collections to execute the queries against.
+	ratingOptions := options.Find().SetProjection(bson.M{"movieId": 1, "userId": 1, "score": 1}).SetLimit(int64(limit))
+	cursor, err := databases.Database.Collection(common.ColRatings).Find(ctx, query, ratingOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var ratings []models.Rating
+	err = cursor.All(ctx, &ratings)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err = databases.Database.Collection(common.ColWatchHistory).Find(ctx, query, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	var watches []models.Watch
+	err = cursor.All(ctx, &watches)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeInteractions(ratings, watches), nil
+}
+
+// mergeInteractions merges the ratings and the watches of the users.
+// A rated movie is weighted by its rating only, whether it has been watched or not.
+func mergeInteractions(ratings []models.Rating, watches []models.Watch) []models.Interaction {
+	rated := make(map[string]bool)
+	interactions := make([]models.Interaction, 0, len(ratings)+len(watches))
+	for _, rating := range ratings {
+		rated[rating.UserID+rating.MovieID.Hex()] = true
+		interactions = append(interactions, models.Interaction{rating.UserID, rating.MovieID, rating.Weight()})
+	}
+	for _, watch := range watches {
+		if !rated[watch.UserID+watch.MovieID.Hex()] {
+			interactions = append(interactions, models.Interaction{watch.UserID, watch.MovieID, watch.Weight()})
+		}
+	}
+
+	return interactions
+}
+
+// GetPopular gets the ids of the most watched Movies
+func (h *History) GetPopular(ctx context.Context, limit int) ([]models.ObjectID, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColWatchHistory)
+
+	pipeline := []bson.M{
+		{"$group": bson.M{"_id": "$movieId", "count": bson.M{"$sum": 1}}},
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$limit": limit},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []struct {
+		ID models.ObjectID `bson:"_id"`
+	}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]models.ObjectID, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,125 @@
+/*
+ * @File: daos.memoryhistory.go
+ * @Description: Reads the ratings and the watch history of the users from memory
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"../models"
+)
+
+// MemoryHistory keeps the ratings and the watch history of the users in memory, it's safe for concurrent use
+type MemoryHistory struct {
+	mutex   sync.RWMutex
+	ratings []models.Rating
+	watches []models.Watch
+}
+
+// NewMemoryHistory creates an empty in-memory HistoryRepository
+func NewMemoryHistory() *MemoryHistory {
+	return &MemoryHistory{}
+}
+
+// AddRating adds the Rating of a movie by a user
+func (h *MemoryHistory) AddRating(rating models.Rating) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.ratings = append(h.ratings, rating)
+}
+
+// AddWatch adds the playback progress of a movie by a user
+func (h *MemoryHistory) AddWatch(watch models.Watch) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.watches = append(h.watches, watch)
+}
+
+// matchInteraction checks if the interaction of a user with a movie is selected by the filter, like interactionQuery does
+func matchInteraction(filter models.InteractionFilter, userID string, movieID models.ObjectID) bool {
+	if len(filter.UserIDs) > 0 && !containsUserID(filter.UserIDs, userID) {
+		return false
+	}
+	if len(filter.ExcludedUserID) > 0 && userID == filter.ExcludedUserID {
+		return false
+	}
+	if len(filter.MovieIDs) > 0 && !containsMovieID(filter.MovieIDs, movieID) {
+		return false
+	}
+
+	return !containsMovieID(filter.ExcludedMovieIDs, movieID)
+}
+
+func containsUserID(ids []string, id string) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsMovieID(ids []models.ObjectID, id models.ObjectID) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetInteractions gets the ratings and the watches matching the filter, at most limit of each
+func (h *MemoryHistory) GetInteractions(ctx context.Context, filter models.InteractionFilter, limit int) ([]models.Interaction, error) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	var ratings []models.Rating
+	for _, rating := range h.ratings {
+		if len(ratings) < limit && matchInteraction(filter, rating.UserID, rating.MovieID) {
+			ratings = append(ratings, rating)
+		}
+	}
+
+	var watches []models.Watch
+	for _, watch := range h.watches {
+		if len(watches) < limit && matchInteraction(filter, watch.UserID, watch.MovieID) {
+			watches = append(watches, watch)
+		}
+	}
+
+	return mergeInteractions(ratings, watches), nil
+}
+
+// GetPopular gets the ids of the most watched Movies
+func (h *MemoryHistory) GetPopular(ctx context.Context, limit int) ([]models.ObjectID, error) {
+	h.mutex.RLock()
+	counts := make(map[models.ObjectID]int)
+	for _, watch := range h.watches {
+		counts[watch.MovieID]++
+	}
+	h.mutex.RUnlock()
+
+	ids := make([]models.ObjectID, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if counts[ids[i]] != counts[ids[j]] {
+			return counts[ids[i]] > counts[ids[j]]
+		}
+		return ids[i].Hex() < ids[j].Hex()
+	})
+
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
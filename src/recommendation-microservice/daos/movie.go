@@ -0,0 +1,186 @@
+/*
+ * @File: daos.movie.go
+ * @Description: Reads the movies from the movie service API
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"../common"
+	"../models"
+)
+
+// Errors of the movie service
+var (
+	ErrMovieNotFound    = errors.New(common.ErrMovieNotFound)
+	ErrMovieUnavailable = models.NewError(models.KindUpstreamUnavailable, common.ErrMovieUnavailable)
+)
+
+const (
+	maxMovieEntries = 10000 // cached movies before the expired ones are pruned
+	maxGenrePages   = 10    // pages of the movie list API read per genre
+)
+
+type movieEntry struct {
+	movie   models.Movie
+	found   bool
+	expires time.Time
+}
+
+// movieCache caches the Movies by their id, the missing ones are cached too
+var movieCache = struct {
+	sync.Mutex
+	entries map[models.ObjectID]movieEntry
+}{entries: make(map[models.ObjectID]movieEntry)}
+
+var movieClient = &http.Client{Timeout: 5 * time.Second}
+
+// Movie reads the Movies from the movie service at the movieAddr of the configuration
+type Movie struct {
+	config func() *common.Configuration
+}
+
+// NewMovie creates a Movie reading the given configuration, which may be reloaded
+func NewMovie(config func() *common.Configuration) *Movie {
+	return &Movie{config}
+}
+
+// GetByID gets a Movie by its id
+func (m *Movie) GetByID(ctx context.Context, id models.ObjectID) (models.Movie, error) {
+	now := time.Now()
+
+	movieCache.Lock()
+	entry, ok := movieCache.entries[id]
+	movieCache.Unlock()
+	if ok && now.Before(entry.expires) {
+		if !entry.found {
+			return models.Movie{}, ErrMovieNotFound
+		}
+		return entry.movie, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.config().MovieAddr+"/api/v1/movies/"+id.Hex(), nil)
+	if err != nil {
+		return models.Movie{}, err
+	}
+
+	resp, err := movieClient.Do(req)
+	if err != nil {
+		return models.Movie{}, err
+	}
+	defer resp.Body.Close()
+
+	var movie models.Movie
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err = json.NewDecoder(resp.Body).Decode(&movie); err != nil {
+			return models.Movie{}, err
+		}
+		m.cache(movie.ID, movie, true)
+		return movie, nil
+	case http.StatusNotFound:
+		m.cache(id, movie, false)
+		return movie, ErrMovieNotFound
+	default:
+		return movie, ErrMovieUnavailable
+	}
+}
+
+// GetByGenre gets the latest Movies of a Genre except the excluded ones. The movie list API returns
+// common.MaxMoviePageLimit Movies at most, the next pages are read until there are enough Movies.
+func (m *Movie) GetByGenre(ctx context.Context, genreID models.ObjectID, limit int, excluded map[models.ObjectID]bool) ([]models.Movie, error) {
+	size := limit + len(excluded)
+	if size > common.MaxMoviePageLimit {
+		size = common.MaxMoviePageLimit
+	}
+
+	movies := []models.Movie{}
+	var after string
+	for pages := 0; pages < maxGenrePages; pages++ {
+		page, err := m.getPage(ctx, genreID, size, after)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, movie := range page.Items {
+			if excluded[movie.ID] {
+				continue
+			}
+			movies = append(movies, movie)
+			if len(movies) == limit {
+				return movies, nil
+			}
+		}
+
+		if len(page.NextCursor) == 0 {
+			break
+		}
+		after = page.NextCursor
+	}
+
+	return movies, nil
+}
+
+// getPage gets a page of the latest Movies of a Genre, after the given cursor
+func (m *Movie) getPage(ctx context.Context, genreID models.ObjectID, limit int, after string) (models.MoviePage, error) {
+	query := url.Values{
+		"genre": {genreID.Hex()},
+		"limit": {strconv.Itoa(limit)},
+		"sort":  {"-id"},
+	}
+	if len(after) > 0 {
+		query.Set("after", after)
+	}
+
+	var page models.MoviePage
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.config().MovieAddr+"/api/v1/movies/list?"+query.Encode(), nil)
+	if err != nil {
+		return page, err
+	}
+
+	resp, err := movieClient.Do(req)
+	if err != nil {
+		return page, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return page, ErrMovieUnavailable
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return page, err
+	}
+
+	for _, movie := range page.Items {
+		m.cache(movie.ID, movie, true)
+	}
+
+	return page, nil
+}
+
+// cache stores a Movie for the configured duration
+func (m *Movie) cache(id models.ObjectID, movie models.Movie, found bool) {
+	now := time.Now()
+
+	movieCache.Lock()
+	defer movieCache.Unlock()
+
+	if len(movieCache.entries) >= maxMovieEntries {
+		for key, value := range movieCache.entries {
+			if now.After(value.expires) {
+				delete(movieCache.entries, key)
+			}
+		}
+	}
+	movieCache.entries[id] = movieEntry{movie, found, now.Add(time.Duration(m.config().MovieCacheTTL) * time.Second)}
+}
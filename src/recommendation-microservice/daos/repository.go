@@ -0,0 +1,26 @@
+/*
+ * @File: daos.repository.go
+ * @Description: Defines the repositories used by the API logic functions
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+
+	"../models"
+)
+
+// HistoryRepository reads the ratings and the watch history of the users, they are written by the movie
+// service. History implements it with MongoDB, SQLHistory with PostgreSQL or SQLite and MemoryHistory
+// keeps them in memory.
+type HistoryRepository interface {
+	GetInteractions(ctx context.Context, filter models.InteractionFilter, limit int) ([]models.Interaction, error)
+	GetPopular(ctx context.Context, limit int) ([]models.ObjectID, error)
+}
+
+var (
+	_ HistoryRepository = (*History)(nil)
+	_ HistoryRepository = (*SQLHistory)(nil)
+	_ HistoryRepository = (*MemoryHistory)(nil)
+)
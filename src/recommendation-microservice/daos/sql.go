@@ -0,0 +1,70 @@
+/*
+ * @File: daos.sql.go
+ * @Description: Implements the helpers shared by the SQL DAOs
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"../models"
+)
+
+// sqlID reads an id column, the ids are stored as the hex strings of the ObjectIds
+type sqlID struct {
+	id *models.ObjectID
+}
+
+// Scan parses the hex string of the id
+func (i sqlID) Scan(src interface{}) error {
+	var err error
+	switch value := src.(type) {
+	case string:
+		*i.id, err = models.ObjectIDFromHex(strings.TrimSpace(value))
+	case []byte:
+		*i.id, err = models.ObjectIDFromHex(strings.TrimSpace(string(value)))
+	default:
+		err = fmt.Errorf("id column can't be read from %T", src)
+	}
+	return err
+}
+
+// sqlQuery builds the WHERE clause and the arguments of a query. The arguments
+// are numbered like in PostgreSQL, SQLite understands them too.
+type sqlQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg adds an argument and returns its placeholder
+func (q *sqlQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// in adds the arguments of an IN list and returns the list
+func (q *sqlQuery) in(values []string) string {
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = q.arg(value)
+	}
+
+	return "(" + strings.Join(placeholders, ", ") + ")"
+}
+
+// where adds a condition
+func (q *sqlQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// clause returns the WHERE clause of the conditions
+func (q *sqlQuery) clause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(q.conditions, " AND ")
+}
@@ -0,0 +1,125 @@
+/*
+ * @File: daos.sqlhistory.go
+ * @Description: Reads the ratings and the watch history of the users from PostgreSQL or SQLite
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+
+	"../databases"
+	"../models"
+)
+
+// SQLHistory reads the ratings and the watch history of the users from the ratings
+// and watch_history tables of the SQL database of the movie service
+type SQLHistory struct {
+	db *databases.SQL
+}
+
+// NewSQLHistory creates a HistoryRepository reading the given database
+func NewSQLHistory(db *databases.SQL) *SQLHistory {
+	return &SQLHistory{db: db}
+}
+
+// hexIDs returns the hex strings of the ids, like they are stored
+func hexIDs(ids []models.ObjectID) []string {
+	hexes := make([]string, len(ids))
+	for i, id := range ids {
+		hexes[i] = id.Hex()
+	}
+	return hexes
+}
+
+// interactionFilter returns the query of an interaction filter, the ratings and the watch_history
+// tables have the same user_id and movie_id columns
+func interactionFilter(filter models.InteractionFilter) *sqlQuery {
+	q := &sqlQuery{}
+	if len(filter.UserIDs) > 0 {
+		q.where("user_id IN " + q.in(filter.UserIDs))
+	}
+	if len(filter.ExcludedUserID) > 0 {
+		q.where("user_id <> " + q.arg(filter.ExcludedUserID))
+	}
+	if len(filter.MovieIDs) > 0 {
+		q.where("movie_id IN " + q.in(hexIDs(filter.MovieIDs)))
+	}
+	if len(filter.ExcludedMovieIDs) > 0 {
+		q.where("movie_id NOT IN " + q.in(hexIDs(filter.ExcludedMovieIDs)))
+	}
+	return q
+}
+
+// GetInteractions gets the ratings and the watches matching the filter, at most limit of each
+func (h *SQLHistory) GetInteractions(ctx context.Context, filter models.InteractionFilter, limit int) ([]models.Interaction, error) {
+	ctx, cancel := h.db.Context(ctx)
+	defer cancel()
+
+	q := interactionFilter(filter)
+	rows, err := h.db.DB.QueryContext(ctx, "SELECT movie_id, user_id, score FROM ratings"+q.clause()+" LIMIT "+q.arg(limit), q.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var ratings []models.Rating
+	for rows.Next() {
+		var rating models.Rating
+		if err = rows.Scan(sqlID{&rating.MovieID}, &rating.UserID, &rating.Score); err != nil {
+			break
+		}
+		ratings = append(ratings, rating)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	// SQLite has a single connection, the rows are closed before the next query
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	q = interactionFilter(filter)
+	rows, err = h.db.DB.QueryContext(ctx, "SELECT movie_id, user_id, position, duration, completed FROM watch_history"+q.clause()+" LIMIT "+q.arg(limit), q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var watches []models.Watch
+	for rows.Next() {
+		var watch models.Watch
+		if err = rows.Scan(sqlID{&watch.MovieID}, &watch.UserID, &watch.Position, &watch.Duration, &watch.Completed); err != nil {
+			return nil, err
+		}
+		watches = append(watches, watch)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return mergeInteractions(ratings, watches), nil
+}
+
+// GetPopular gets the ids of the most watched Movies
+func (h *SQLHistory) GetPopular(ctx context.Context, limit int) ([]models.ObjectID, error) {
+	ctx, cancel := h.db.Context(ctx)
+	defer cancel()
+
+	rows, err := h.db.DB.QueryContext(ctx, "SELECT movie_id FROM watch_history GROUP BY movie_id ORDER BY COUNT(*) DESC, movie_id LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []models.ObjectID{}
+	for rows.Next() {
+		var id models.ObjectID
+		if err = rows.Scan(sqlID{&id}); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
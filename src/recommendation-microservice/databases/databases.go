@@ -0,0 +1,15 @@
+/*
+ * @File: databases.databases.go
+ * @Description: Creates global database instance
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package databases
+
+// Database shares global database instance
+var (
+	// Database stores the ratings and the watch history when the storage is mongodb
+	Database MongoDB
+
+	// SQLDatabase stores them when the storage is postgres or sqlite
+	SQLDatabase SQL
+)
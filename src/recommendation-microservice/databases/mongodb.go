@@ -0,0 +1,164 @@
+/*
+ * @File: databases.mongodb.go
+ * @Description: Handles MongoDB connections
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package databases
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"../common"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// connectTimeout bounds the connection to the MongoDB deployment when the service starts
+const connectTimeout = 60 * time.Second
+
+// maxConnectWait bounds the wait between the connection attempts when the service waits for MongoDB
+const maxConnectWait = 30 * time.Second
+
+// MongoDB manages MongoDB connection
+type MongoDB struct {
+	Client       *mongo.Client
+	Databasename string
+	connected    int32
+}
+
+// Init creates the client of the MongoDB deployment, the client connects in the background
+func (db *MongoDB) Init() error {
+	db.Databasename = common.Config().MgDbName
+
+	clientOptions, err := newClientOptions()
+	if err != nil {
+		return err
+	}
+
+	db.Client, err = mongo.Connect(context.Background(), clientOptions)
+	return err
+}
+
+// Connect waits for the MongoDB deployment, then initializes the database. Ping reports
+// the deployment as unavailable until it is connected.
+func (db *MongoDB) Connect() error {
+	err := db.wait()
+	if err != nil {
+		log.Debug("Can't connect to mongo, go error: ", err)
+		return err
+	}
+
+	atomic.StoreInt32(&db.connected, 1)
+	log.Info("Connected to MongoDB")
+	return nil
+}
+
+// wait pings the MongoDB deployment. When it is unavailable, the ping is retried
+// until mgStartupTimeout seconds are elapsed, so that the service waits for MongoDB.
+func (db *MongoDB) wait() error {
+	deadline := time.Now().Add(time.Duration(common.Config().MgStartupTimeout) * time.Second)
+	wait := time.Second
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+		err := db.Client.Ping(ctx, nil)
+		cancel()
+		if err == nil || time.Now().Add(wait).After(deadline) {
+			return err
+		}
+
+		log.Warn("MongoDB is unavailable, retrying in ", wait, ", go error: ", err)
+		time.Sleep(wait)
+
+		wait *= 2
+		if wait > maxConnectWait {
+			wait = maxConnectWait
+		}
+	}
+}
+
+// newClientOptions returns the connection, pool and concern options of the configuration
+func newClientOptions() (*options.ClientOptions, error) {
+	clientOptions := options.Client().
+		SetHosts(strings.Split(common.Config().MgAddrs, ",")). // Get HOST + PORT
+		SetConnectTimeout(connectTimeout).
+		SetMaxPoolSize(common.Config().MgMaxPoolSize).
+		SetMinPoolSize(common.Config().MgMinPoolSize).
+		SetMaxConnIdleTime(time.Duration(common.Config().MgMaxConnIdleTime) * time.Second)
+
+	if len(common.Config().MgDbUsername) > 0 {
+		// The users are authenticated by the database of the service
+		clientOptions.SetAuth(options.Credential{
+			AuthSource: common.Config().MgDbName,
+			Username:   common.Config().MgDbUsername,
+			Password:   common.Config().MgDbPassword,
+		})
+	}
+
+	// The concerns of the deployment are used when they are not configured
+	if len(common.Config().MgReadConcern) > 0 {
+		clientOptions.SetReadConcern(&readconcern.ReadConcern{Level: common.Config().MgReadConcern})
+	}
+
+	switch w := common.Config().MgWriteConcern; {
+	case len(w) == 0:
+	case w == "majority":
+		clientOptions.SetWriteConcern(writeconcern.Majority())
+	default:
+		nodes, err := strconv.Atoi(w)
+		if err != nil || nodes < 0 {
+			return nil, errors.New(common.ErrMgWriteConcernInvalid)
+		}
+		clientOptions.SetWriteConcern(&writeconcern.WriteConcern{W: nodes})
+	}
+
+	mode, err := readpref.ModeFromString(common.Config().MgReadPreference)
+	if err != nil {
+		return nil, errors.New(common.ErrMgReadPreferenceInvalid)
+	}
+	readPreference, err := readpref.New(mode)
+	if err != nil {
+		return nil, err
+	}
+	clientOptions.SetReadPreference(readPreference)
+
+	return clientOptions, clientOptions.Validate()
+}
+
+// Context returns the context of a database call, it's canceled after the configured timeout
+func (db *MongoDB) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, time.Duration(common.Config().MgTimeout)*time.Second)
+}
+
+// Ping checks that the MongoDB deployment is connected and reachable
+func (db *MongoDB) Ping(ctx context.Context) error {
+	if atomic.LoadInt32(&db.connected) == 0 {
+		return errors.New(common.ErrMgConnecting)
+	}
+
+	ctx, cancel := db.Context(ctx)
+	defer cancel()
+
+	return db.Client.Ping(ctx, nil)
+}
+
+// Collection returns a collection of the database of the service
+func (db *MongoDB) Collection(name string) *mongo.Collection {
+	return db.Client.Database(db.Databasename).Collection(name)
+}
+
+// Close the existing connection
+func (db *MongoDB) Close() {
+	if db.Client != nil {
+		db.Client.Disconnect(context.Background())
+	}
+}
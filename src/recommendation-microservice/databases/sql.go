@@ -0,0 +1,90 @@
+/*
+ * @File: databases.sql.go
+ * @Description: Handles PostgreSQL and SQLite connections
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"../common"
+	_ "github.com/lib/pq" // postgres driver
+	log "github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite" // sqlite driver
+)
+
+// SQL manages a PostgreSQL or SQLite connection. The schema is migrated by the movie service,
+// the recommendation service only reads the database.
+type SQL struct {
+	DB      *sql.DB
+	Storage string
+	Timeout time.Duration // of every database call, 0 for none
+}
+
+// Init connects to the configured database
+func (db *SQL) Init() error {
+	err := db.Open(common.Config().Storage, common.Config().SQLDataSource)
+	if err != nil {
+		log.Debug("Can't connect to ", common.Config().Storage, ", go error: ", err)
+		return err
+	}
+	db.Timeout = time.Duration(common.Config().SQLTimeout) * time.Second
+
+	return nil
+}
+
+// Open connects to a postgres or sqlite database
+func (db *SQL) Open(storage string, dataSource string) error {
+	if storage != common.StoragePostgres && storage != common.StorageSQLite {
+		return errors.New(common.ErrStorageDatabaseUnsupported)
+	}
+	if len(dataSource) == 0 {
+		return errors.New(common.ErrSQLDataSourceEmpty)
+	}
+
+	var err error
+	db.DB, err = sql.Open(storage, dataSource)
+	if err != nil {
+		return err
+	}
+	db.Storage = storage
+
+	if storage == common.StorageSQLite {
+		// SQLite locks the whole database to write, the calls wait for each other
+		// instead of failing when the database is busy
+		db.DB.SetMaxOpenConns(1)
+	}
+
+	err = db.DB.Ping()
+	if err != nil {
+		db.DB.Close()
+	}
+	return err
+}
+
+// Context returns the context of a database call, it's canceled after the configured timeout
+func (db *SQL) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	if db.Timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, db.Timeout)
+}
+
+// Ping checks that the database is reachable
+func (db *SQL) Ping(ctx context.Context) error {
+	ctx, cancel := db.Context(ctx)
+	defer cancel()
+
+	return db.DB.PingContext(ctx)
+}
+
+// Close the existing connection
+func (db *SQL) Close() {
+	if db.DB != nil {
+		db.DB.Close()
+	}
+}
@@ -0,0 +1,195 @@
+/*
+ * @File: main.go
+ * @Description: Creates HTTP server & API groups of the Recommendation Service
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"./common"
+	"./controllers"
+	"./daos"
+	"./databases"
+	"./middlewares"
+	"./utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	_ "./docs"
+	"github.com/swaggo/gin-swagger"
+	"github.com/swaggo/gin-swagger/swaggerFiles"
+)
+
+// Main manages main golang application
+type Main struct {
+	router *gin.Engine
+}
+
+func (m *Main) initServer(configFiles []string) error {
+	var err error
+	// Load the configuration
+	err = common.LoadConfig(configFiles)
+	if err != nil {
+		return err
+	}
+
+	// Reload the configuration on SIGHUP and when the config files are modified
+	common.WatchConfig(common.ConfigWatchInterval)
+
+	// Initialize the database storing the ratings and the watch history
+	if common.Config().Storage == common.StorageMongoDB {
+		err = databases.Database.Init()
+	} else {
+		err = databases.SQLDatabase.Init()
+	}
+	if err != nil {
+		return err
+	}
+
+	// Setting Gin Logger
+	if common.Config().EnableGinFileLog {
+		f, _ := os.Create("logs/gin.log")
+		if common.Config().EnableGinConsoleLog {
+			gin.DefaultWriter = io.MultiWriter(os.Stdout, f)
+		} else {
+			gin.DefaultWriter = io.MultiWriter(f)
+		}
+	} else {
+		if !common.Config().EnableGinConsoleLog {
+			gin.DefaultWriter = io.MultiWriter()
+		}
+	}
+
+	m.router = gin.Default()
+	// Render the errors of the APIs with the error catalog
+	m.router.Use(middlewares.Errors())
+
+	return nil
+}
+
+// serve serves the APIs until the service receives SIGINT or SIGTERM. The server then stops accepting
+// connections and drains the requests in flight, they are canceled after shutdownTimeout seconds.
+// connect runs once the server listens, so that the probes respond while the databases are connecting,
+// the service stops when it fails.
+func (m *Main) serve(connect func() error) error {
+	config := common.Config()
+	server := &http.Server{
+		Addr:         config.Port,
+		Handler:      m.router,
+		ReadTimeout:  time.Duration(config.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(config.WriteTimeout) * time.Second,
+		IdleTimeout:  time.Duration(config.IdleTimeout) * time.Second,
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	listener, err := net.Listen("tcp", config.Port)
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, 2)
+	go func() {
+		log.Info("Listening and serving HTTP on ", config.Port)
+		errs <- server.Serve(listener)
+	}()
+	go func() {
+		if err := connect(); err != nil {
+			errs <- err
+		}
+	}()
+
+	select {
+	case err = <-errs:
+		server.Close()
+		return err
+	case sig := <-stop:
+		log.Info(sig, " received, draining the requests in flight")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(common.Config().ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	err = server.Shutdown(ctx)
+	if err != nil {
+		log.Warn("Requests in flight are canceled, go error: ", err)
+		server.Close()
+	}
+	log.Info("Server is stopped")
+
+	return nil
+}
+
+// @title RecommendationManagement Service API Document
+// @version 1.0
+// @description List APIs of RecommendationManagement Service
+// @termsOfService http://swagger.io/terms/
+
+// @host 107.113.53.47:8810
+// @BasePath /api/v1
+func main() {
+	var configFiles common.ConfigFiles
+	flag.Var(&configFiles, "config", "config file, repeat it to override the settings of the previous files")
+	flag.Parse()
+
+	m := Main{}
+
+	// Initialize server
+	err := m.initServer(configFiles)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to start the service:", err)
+		os.Exit(1)
+	}
+
+	var histories daos.HistoryRepository = &daos.History{}
+	// Probes of the orchestrator and of the load balancer
+	checks := map[string]controllers.HealthCheck{"mongodb": databases.Database.Ping}
+	// MongoDB connects once the server listens, the SQL database is connected by initServer
+	connect := databases.Database.Connect
+	if common.Config().Storage != common.StorageMongoDB {
+		histories = daos.NewSQLHistory(&databases.SQLDatabase)
+		checks = map[string]controllers.HealthCheck{common.Config().Storage: databases.SQLDatabase.Ping}
+		connect = func() error { return nil }
+	}
+
+	r := controllers.NewRecommendation(histories, daos.NewMovie(common.Config))
+
+	// Simple group: v1
+	v1 := m.router.Group("/api/v1")
+	{
+		// APIs need to use token string
+		isRevoked := middlewares.NewRevocationChecker()
+		jwks := utils.NewJWKS()
+		v1.Use(middlewares.Auth(jwks.Keyfunc, isRevoked))
+		v1.GET("/recommendations/:userId", middlewares.RequireRole(common.RoleViewer), r.GetRecommendations)
+	}
+
+	checks["auth"] = controllers.HTTPCheck(func() string { return common.Config().AuthAddr + "/readyz" })
+	checks["movies"] = controllers.HTTPCheck(func() string { return common.Config().MovieAddr + "/healthz" })
+	h := controllers.NewHealth(checks)
+	m.router.GET("/healthz", h.Live)
+	m.router.GET("/readyz", h.Ready)
+
+	m.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Serve the APIs until the service is stopped, then close the databases
+	err = m.serve(connect)
+	databases.SQLDatabase.Close()
+	databases.Database.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to serve the APIs:", err)
+		os.Exit(1)
+	}
+}
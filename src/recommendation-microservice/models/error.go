@@ -0,0 +1,77 @@
+/*
+ * @File: models.error.go
+ * @Description: Defines Error information will be returned to the clients
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+import (
+	"errors"
+	"net/http"
+
+	"../common"
+)
+
+// Error defines the response error, its code and kind come from the error catalog
+// shared with the user and movie services
+type Error struct {
+	Code    int          `json:"code" example:"12"`
+	Kind    string       `json:"kind" example:"validation_failed"`
+	Message string       `json:"message" example:"Error message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError describes an invalid field of a request and the rule it breaks
+type FieldError struct {
+	Field   string `json:"field" example:"limit"`
+	Rule    string `json:"rule" example:"max"`
+	Message string `json:"message" example:"must be 50 or less"`
+}
+
+// ErrorKind is an entry of the error catalog, the errors of a kind have the same code and HTTP status
+type ErrorKind struct {
+	Name   string
+	Code   int
+	Status int
+}
+
+// Error catalog
+var (
+	KindInternal            = ErrorKind{"internal", common.StatusCodeUnknown, http.StatusInternalServerError}
+	KindValidationFailed    = ErrorKind{"validation_failed", common.StatusValidationFailed, http.StatusBadRequest}
+	KindUnauthorized        = ErrorKind{"unauthorized", common.StatusUnauthorized, http.StatusUnauthorized}
+	KindForbidden           = ErrorKind{"forbidden", common.StatusForbidden, http.StatusForbidden}
+	KindNotFound            = ErrorKind{"not_found", common.StatusNotFound, http.StatusNotFound}
+	KindUpstreamUnavailable = ErrorKind{"upstream_unavailable", common.StatusUpstreamUnavailable, http.StatusServiceUnavailable}
+)
+
+// APIError is an error of the catalog, its message is returned to the clients
+type APIError struct {
+	Kind   ErrorKind
+	Err    error
+	Fields []FieldError
+}
+
+// NewError creates an error of the catalog with the given message
+func NewError(kind ErrorKind, message string) *APIError {
+	return &APIError{Kind: kind, Err: errors.New(message)}
+}
+
+// WrapError classifies an error in the catalog, its message is returned to the clients
+func WrapError(kind ErrorKind, err error) *APIError {
+	return &APIError{Kind: kind, Err: err}
+}
+
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the classified error
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Response returns the response of the error
+func (e *APIError) Response() Error {
+	return Error{e.Kind.Code, e.Kind.Name, e.Err.Error(), e.Fields}
+}
@@ -0,0 +1,60 @@
+/*
+ * @File: models.history.go
+ * @Description: Defines the ratings and the watch history of the users
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+import (
+	"time"
+)
+
+// Rating of a movie by a user, from 1 to 5
+type Rating struct {
+	MovieID ObjectID `bson:"movieId"`
+	UserID  string   `bson:"userId"`
+	Score   int      `bson:"score"`
+}
+
+// Watch is the playback progress of a movie by a user
+type Watch struct {
+	MovieID   ObjectID  `bson:"movieId"`
+	UserID    string    `bson:"userId"`
+	Position  int       `bson:"position"` // seconds
+	Duration  int       `bson:"duration"` // seconds
+	Completed bool      `bson:"completed"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+// InteractionFilter selects the ratings and the watches, the empty fields select all of them
+type InteractionFilter struct {
+	UserIDs          []string   // of the users
+	ExcludedUserID   string     // except the ones of this user
+	MovieIDs         []ObjectID // of the movies
+	ExcludedMovieIDs []ObjectID // except the ones of these movies
+}
+
+// Interaction tells how much a user likes a movie, a negative weight means a dislike
+type Interaction struct {
+	UserID  string
+	MovieID ObjectID
+	Weight  float64
+}
+
+// Weight of the rating, from -1 for 1 star to 1 for 5 stars
+func (r Rating) Weight() float64 {
+	return float64(r.Score-3) / 2
+}
+
+// Weight of the watch, from 0.25 when started to 1 when completed
+func (w Watch) Weight() float64 {
+	if w.Completed || w.Duration <= 0 {
+		return 1
+	}
+
+	progress := float64(w.Position) / float64(w.Duration)
+	if progress > 1 {
+		progress = 1
+	}
+	return 0.25 + 0.75*progress
+}
@@ -0,0 +1,24 @@
+/*
+ * @File: models.movie.go
+ * @Description: Defines Movie information read from the movie service
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+// Movie information
+type Movie struct {
+	ID          ObjectID   `json:"id" example:"5bbdadf782ebac06a695a8e7"`
+	Name        string     `json:"name" example:"Movie Name"`
+	URL         string     `json:"url" example:"Movie URL"`
+	CoverImage  string     `json:"coverImage" example:"Movie Cover Image"`
+	Description string     `json:"description" example:"Movie Description"`
+	Year        int        `json:"year" example:"2018"`
+	Genres      []ObjectID `json:"genres"`
+}
+
+// MoviePage is a page of movies
+type MoviePage struct {
+	Items      []Movie `json:"items"`
+	NextCursor string  `json:"nextCursor"`
+	Total      int     `json:"total"`
+}
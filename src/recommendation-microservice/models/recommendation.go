@@ -0,0 +1,19 @@
+/*
+ * @File: models.recommendation.go
+ * @Description: Defines Recommendation information will be returned to the clients
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+// Recommendation is a movie recommended to a user
+type Recommendation struct {
+	Movie   Movie    `json:"movie"`
+	Score   float64  `json:"score" example:"2.5"`
+	Reasons []string `json:"reasons" example:"similar_users,genres"`
+}
+
+// Recommendations of a user, the best ones come first
+type Recommendations struct {
+	UserID string           `json:"userId" example:"5bbdadf782ebac06a695a8e7"`
+	Items  []Recommendation `json:"items"`
+}
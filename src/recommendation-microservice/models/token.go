@@ -0,0 +1,12 @@
+/*
+ * @File: models.token.go
+ * @Description: Defines Token information read from the user service
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+// TokenRevocation tells if an access token has been revoked
+type TokenRevocation struct {
+	ID      string `json:"id"`
+	Revoked bool   `json:"revoked"`
+}
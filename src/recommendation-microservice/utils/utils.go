@@ -0,0 +1,56 @@
+/*
+ * @File: utils.utils.go
+ * @Description: Reusable stuffs for services
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package utils
+
+import (
+	"../common"
+	"../models"
+	jwt_lib "github.com/dgrijalva/jwt-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SdtClaims defines the custom claims
+type SdtClaims struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+	jwt_lib.StandardClaims
+}
+
+type Utils struct {
+}
+
+// ValidateObjectID checks the given ID if it's an object id or not
+func (u *Utils) ValidateObjectID(id string) error {
+	if primitive.IsValidObjectID(id) != true {
+		return models.NewError(models.KindValidationFailed, common.ErrNotObjectIDHex)
+	}
+
+	return nil
+}
+
+// ObjectIDHex returns the ObjectID of the given hex representation.
+// It panics when the hex representation is not valid, check it with ValidateObjectID first.
+func ObjectIDHex(id string) models.ObjectID {
+	objectID, err := models.ObjectIDFromHex(id)
+	if err != nil {
+		panic(err)
+	}
+
+	return objectID
+}
+
+// roleLevels ranks the roles, a higher level includes the permissions of the lower ones
+var roleLevels = map[string]int{
+	common.RoleViewer: 1,
+	common.RoleEditor: 2,
+	common.RoleAdmin:  3,
+}
+
+// HasRole checks if the granted role includes the permissions of the required role
+func (u *Utils) HasRole(granted string, required string) bool {
+	level, ok := roleLevels[granted]
+	return ok && level >= roleLevels[required]
+}
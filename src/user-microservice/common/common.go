@@ -5,86 +5,163 @@
  */
 package common
 
-import (
-	"encoding/json"
-	"os"
-
-	"github.com/natefinch/lumberjack"
-	log "github.com/sirupsen/logrus"
-)
-
 // Configuration stores setting values
 type Configuration struct {
 	Port                string `json:"port"`
 	EnableGinConsoleLog bool   `json:"enableGinConsoleLog"`
 	EnableGinFileLog    bool   `json:"enableGinFileLog"`
 
+	ReadTimeout     int `json:"readTimeout"`     // seconds, of reading a request
+	WriteTimeout    int `json:"writeTimeout"`    // seconds, of writing a response
+	IdleTimeout     int `json:"idleTimeout"`     // seconds, of the idle keep-alive connections
+	ShutdownTimeout int `json:"shutdownTimeout"` // seconds, of draining the requests in flight when the service stops
+
+	LogLevel      string `json:"logLevel"` // panic, fatal, error, warn, info, debug or trace
 	LogFilename   string `json:"logFilename"`
 	LogMaxSize    int    `json:"logMaxSize"`
 	LogMaxBackups int    `json:"logMaxBackups"`
 	LogMaxAge     int    `json:"logMaxAge"`
 
+	TracingExporter    string  `json:"tracingExporter"`    // none, stdout, file or otlp
+	TracingFile        string  `json:"tracingFile"`        // spans file of the file exporter
+	TracingEndpoint    string  `json:"tracingEndpoint"`    // URL of the OTLP/HTTP collector, OTEL_EXPORTER_OTLP_ENDPOINT by default
+	TracingSampleRatio float64 `json:"tracingSampleRatio"` // ratio of the traced requests started by the service, from 0 to 1
+
 	MgAddrs      string `json:"mgAddrs"`
 	MgDbName     string `json:"mgDbName"`
 	MgDbUsername string `json:"mgDbUsername"`
 	MgDbPassword string `json:"mgDbPassword"`
 
-	JwtSecretPassword string `json:"jwtSecretPassword"`
-	Issuer            string `json:"issuer"`
+	MgMaxPoolSize     uint64 `json:"mgMaxPoolSize"`
+	MgMinPoolSize     uint64 `json:"mgMinPoolSize"`
+	MgMaxConnIdleTime int    `json:"mgMaxConnIdleTime"` // seconds, 0 keeps the idle connections
+	MgTimeout         int    `json:"mgTimeout"`         // seconds, of every database call
+	MgReadConcern     string `json:"mgReadConcern"`     // local, available, majority, linearizable or snapshot
+	MgWriteConcern    string `json:"mgWriteConcern"`    // majority or the number of acknowledging nodes
+	MgReadPreference  string `json:"mgReadPreference"`  // primary, primaryPreferred, secondary, secondaryPreferred or nearest
+	MgStartupTimeout  int    `json:"mgStartupTimeout"`  // seconds, the service waits for MongoDB when it starts, 0 fails at once
+
+	Storage       string `json:"storage"`       // mongodb, postgres or sqlite, of the users and their tokens
+	SQLDataSource string `json:"sqlDataSource"` // connection string of the postgres or sqlite database
+	SQLTimeout    int    `json:"sqlTimeout"`    // seconds, of every SQL database call
+
+	JwtSigningKeys  []SigningKeyConfig `json:"jwtSigningKeys"`
+	JwtActiveKid    string             `json:"jwtActiveKid"`
+	Issuer          string             `json:"issuer"`
+	RefreshTokenTTL int                `json:"refreshTokenTTL"` // hours
 }
 
-// Config shares the global configuration
-var (
-	Config *Configuration
-)
+// SigningKeyConfig locates a PEM encoded RSA or EC (P-256) private key signing the tokens
+type SigningKeyConfig struct {
+	Kid            string `json:"kid"`
+	PrivateKeyFile string `json:"privateKeyFile"`
+}
 
 // COLLECTIONs of the database table
 const (
-	ColUsers  = "users"
-	ColMovies = "movies"
+	ColUsers         = "users"
+	ColMovies        = "movies"
+	ColRefreshTokens = "refresh_tokens"
+	ColRevokedTokens = "revoked_tokens"
+)
+
+// Pagination of the list APIs
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Storages of the users and their tokens
+const (
+	StorageMongoDB  = "mongodb"
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+)
+
+// Length of the passwords, they must have a letter and a digit. The minimum is a number of characters,
+// the maximum is a number of bytes since bcrypt only hashes the first 72 bytes of a password.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+// Roles of the users, every role includes the permissions of the roles after it
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Exporters of the traces
+const (
+	TracingNone   = "none"
+	TracingStdout = "stdout"
+	TracingFile   = "file"
+	TracingOTLP   = "otlp"
+)
+
+// Statuses of the health probes
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
 )
 
 // Status Text
 const (
-	ErrNameEmpty      = "Name is empty"
-	ErrPasswordEmpty  = "Password is empty"
-	ErrNotObjectIDHex = "String is not a valid hex representation of an ObjectId"
+	ErrNameEmpty        = "Name is empty"
+	ErrPasswordEmpty    = "Password is empty"
+	ErrNotObjectIDHex   = "String is not a valid hex representation of an ObjectId"
+	ErrLoginFailed      = "Username or password is incorrect"
+	ErrRoleInvalid      = "Role is not valid"
+	ErrTokenInvalid     = "Token is not valid"
+	ErrPermissionDenied = "Permission denied"
+	ErrTokenRevoked     = "Token has been revoked"
+	ErrRefreshInvalid   = "Refresh token is not valid"
+	ErrRefreshEmpty     = "Refresh token is empty"
+
+	ErrSigningKeyMissing = "The active signing key is not configured"
+	ErrSigningKeyInvalid = "Signing key must be an RSA or EC P-256 private key"
+
+	ErrPageSortInvalid   = "Sort field is not supported"
+	ErrPageCursorInvalid = "After cursor is not valid"
+
+	ErrMgWriteConcernInvalid   = "Write concern must be majority or a number of nodes"
+	ErrMgReadPreferenceInvalid = "Read preference must be primary, primaryPreferred, secondary, secondaryPreferred or nearest"
+
+	ErrStorageDatabaseUnsupported = "Storage must be mongodb, postgres or sqlite"
+	ErrSQLDataSourceEmpty         = "SQL data source is empty"
+
+	ErrUserNameTaken      = "User name is already taken"
+	ErrUserNameDuplicated = "User names are duplicated, rename or delete the users reported in the log"
+
+	ErrInternal            = "Internal error, see the log of the service"
+	ErrNotFound            = "Resource not found"
+	ErrDuplicate           = "Resource already exists"
+	ErrUpstreamUnavailable = "A service or a database is unavailable"
+
+	ErrValidationFailed = "Request is not valid, see the invalid fields"
+
+	ErrConfigInvalid   = "Configuration is not valid"
+	ErrLogLevelInvalid = "Log level must be panic, fatal, error, warn, info, debug or trace"
+
+	ErrTracingExporterUnsupported = "Tracing exporter must be none, stdout, file or otlp"
+	ErrTracingSampleRatioInvalid  = "Tracing sample ratio must be between 0 and 1"
+
+	ErrMgConnecting = "MongoDB is not connected yet"
 )
 
-// Status Code
+// Status Code. The codes of the error catalog, see models.ErrorKind, never change.
 const (
 	StatusCodeUnknown = -1
 	StatusCodeOK      = 1000
 
-	StatusMismatch = 10
+	StatusMismatch            = 10
+	StatusDuplicate           = 11
+	StatusValidationFailed    = 12
+	StatusNotFound            = 13
+	StatusUnauthorized        = 14
+	StatusForbidden           = 15
+	StatusConflict            = 16
+	StatusUpstreamUnavailable = 17
+	StatusPayloadTooLarge     = 18
 )
-
-// LoadConfig loads configuration from the config file
-func LoadConfig() error {
-	// Filename is the path to the json config file
-	file, err := os.Open("config/config.json")
-	if err != nil {
-		return err
-	}
-
-	Config = new(Configuration)
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&Config)
-	if err != nil {
-		return err
-	}
-
-	// Setting Service Logger
-	log.SetOutput(&lumberjack.Logger{
-		Filename:   Config.LogFilename,
-		MaxSize:    Config.LogMaxSize,    // megabytes after which new file is created
-		MaxBackups: Config.LogMaxBackups, // number of backups
-		MaxAge:     Config.LogMaxAge,     // days
-	})
-	log.SetLevel(log.DebugLevel)
-
-	// log.SetFormatter(&log.TextFormatter{})
-	log.SetFormatter(&log.JSONFormatter{})
-
-	return nil
-}
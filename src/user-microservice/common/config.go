@@ -0,0 +1,356 @@
+/*
+ * @File: common.config.go
+ * @Description: Loads the configuration of the service from its defaults, config files and environment
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/natefinch/lumberjack"
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvPrefix prefixes the environment variables overriding the settings, like USERSVC_MGADDRS for mgAddrs
+const EnvPrefix = "USERSVC_"
+
+// DefaultConfigFile is read when no config file is given and it exists
+const DefaultConfigFile = "config/config.json"
+
+// current stores the configuration, it is swapped when the configuration is reloaded
+var current atomic.Value
+
+// configFiles are the config files read by LoadConfig, they are read again by ReloadConfig
+var configFiles []string
+
+// logger writes the log of the service, it is replaced when the configuration is reloaded
+var logger *lumberjack.Logger
+
+// Config returns the current configuration, keep the returned configuration
+// to read several settings consistently during a reload
+func Config() *Configuration {
+	config, _ := current.Load().(*Configuration)
+	return config
+}
+
+// ConfigFiles lists the config files given with the repeatable --config flag
+type ConfigFiles []string
+
+// String returns the config files separated by commas
+func (f *ConfigFiles) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set adds a config file
+func (f *ConfigFiles) Set(file string) error {
+	*f = append(*f, file)
+	return nil
+}
+
+// LoadConfig loads the configuration in layers, every layer overrides the settings of the previous ones:
+//   - the defaults
+//   - the given config files in order, or the files of USERSVC_CONFIG separated by commas,
+//     or DefaultConfigFile if it exists
+//   - the environment variables named by EnvPrefix and the upper case setting name. A variable
+//     suffixed by _FILE, like USERSVC_MGDBPASSWORD_FILE, reads the setting from a file, for the secrets.
+//
+// The configuration is validated, all the missing or invalid settings are reported.
+func LoadConfig(files []string) error {
+	if len(files) == 0 {
+		if value := os.Getenv(EnvPrefix + "CONFIG"); len(value) > 0 {
+			files = strings.Split(value, ",")
+		} else if _, err := os.Stat(DefaultConfigFile); err == nil {
+			files = []string{DefaultConfigFile}
+		}
+	}
+
+	config, err := readConfig(files)
+	if err != nil {
+		return err
+	}
+
+	configFiles = files
+	current.Store(config)
+	setupLogger(config)
+
+	return nil
+}
+
+// readConfig reads the layers of the configuration and validates it
+func readConfig(files []string) (*Configuration, error) {
+	config := defaultConfig()
+
+	for _, file := range files {
+		err := config.readFile(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := config.readEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// setupLogger applies the log settings, the log file is reopened with the new rotation settings
+func setupLogger(config *Configuration) {
+	level, _ := log.ParseLevel(config.LogLevel)
+	log.SetLevel(level)
+
+	// Setting Service Logger
+	previous := logger
+	logger = &lumberjack.Logger{
+		Filename:   config.LogFilename,
+		MaxSize:    config.LogMaxSize,    // megabytes after which new file is created
+		MaxBackups: config.LogMaxBackups, // number of backups
+		MaxAge:     config.LogMaxAge,     // days
+	}
+	log.SetOutput(logger)
+	if previous != nil {
+		previous.Close()
+	}
+
+	// log.SetFormatter(&log.TextFormatter{})
+	log.SetFormatter(&log.JSONFormatter{})
+}
+
+// defaultConfig returns the default settings, the ones of the deployments are left empty
+func defaultConfig() *Configuration {
+	return &Configuration{
+		Port:                ":8808",
+		EnableGinConsoleLog: true,
+
+		ReadTimeout:     30,
+		WriteTimeout:    30,
+		IdleTimeout:     120,
+		ShutdownTimeout: 30,
+
+		LogLevel:      "debug",
+		LogFilename:   "logs/server.log",
+		LogMaxSize:    10,
+		LogMaxBackups: 10,
+		LogMaxAge:     30,
+
+		TracingExporter:    TracingNone,
+		TracingFile:        "logs/traces.json",
+		TracingSampleRatio: 1,
+
+		MgMaxPoolSize:    100,
+		MgTimeout:        10,
+		MgReadPreference: "primary",
+
+		Storage:    StorageMongoDB,
+		SQLTimeout: 10,
+
+		RefreshTokenTTL: 24 * 7,
+	}
+}
+
+// readFile reads the settings of a JSON config file, the unknown settings are rejected
+func (c *Configuration) readFile(name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(c)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+
+	return nil
+}
+
+// readEnv reads the settings of the environment variables
+func (c *Configuration) readEnv() error {
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		key := EnvPrefix + strings.ToUpper(name)
+
+		value, ok, err := lookupEnv(key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		err = setConfigField(v.Field(i), value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	}
+
+	return nil
+}
+
+// lookupEnv returns the value of an environment variable, or the content of the file named by its _FILE variable
+func lookupEnv(key string) (string, bool, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true, nil
+	}
+
+	file, ok := os.LookupEnv(key + "_FILE")
+	if !ok {
+		return "", false, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %v", key, err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// setConfigField parses the value of a setting, the lists are given in JSON
+func setConfigField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	default:
+		return json.Unmarshal([]byte(value), field.Addr().Interface())
+	}
+
+	return nil
+}
+
+// Validate checks the configuration, all the missing or invalid settings are reported
+func (c *Configuration) Validate() error {
+	p := &configProblems{}
+	p.required("port", c.Port)
+	p.positive("readTimeout", c.ReadTimeout)
+	p.positive("writeTimeout", c.WriteTimeout)
+	p.positive("idleTimeout", c.IdleTimeout)
+	p.positive("shutdownTimeout", c.ShutdownTimeout)
+	if _, err := log.ParseLevel(c.LogLevel); err != nil {
+		p.add("logLevel: " + ErrLogLevelInvalid)
+	}
+	p.storage(c.Storage, c.SQLDataSource)
+	if c.Storage == StorageMongoDB {
+		p.required("mgAddrs", c.MgAddrs)
+		p.required("mgDbName", c.MgDbName)
+	}
+
+	switch c.TracingExporter {
+	case TracingNone, TracingStdout, TracingOTLP:
+	case TracingFile:
+		p.required("tracingFile", c.TracingFile)
+	default:
+		p.add("tracingExporter: " + ErrTracingExporterUnsupported)
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		p.add("tracingSampleRatio: " + ErrTracingSampleRatioInvalid)
+	}
+
+	p.positive("mgTimeout", c.MgTimeout)
+	p.notNegative("mgStartupTimeout", c.MgStartupTimeout)
+	p.positive("sqlTimeout", c.SQLTimeout)
+
+	if len(c.JwtSigningKeys) == 0 {
+		p.add("jwtSigningKeys is required, set it in a config file or with " + EnvPrefix + "JWTSIGNINGKEYS")
+	}
+	p.required("jwtActiveKid", c.JwtActiveKid)
+	if len(c.JwtActiveKid) > 0 && !c.hasSigningKey(c.JwtActiveKid) {
+		p.add("jwtActiveKid: " + ErrSigningKeyMissing)
+	}
+	p.required("issuer", c.Issuer)
+	p.positive("refreshTokenTTL", c.RefreshTokenTTL)
+
+	if len(p.problems) > 0 {
+		return fmt.Errorf("%s: %s", ErrConfigInvalid, strings.Join(p.problems, "; "))
+	}
+
+	return nil
+}
+
+// hasSigningKey tells if a signing key has the given kid
+func (c *Configuration) hasSigningKey(kid string) bool {
+	for _, key := range c.JwtSigningKeys {
+		if key.Kid == kid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// configProblems collects the problems of a configuration
+type configProblems struct {
+	problems []string
+}
+
+// add adds a problem
+func (p *configProblems) add(problem string) {
+	p.problems = append(p.problems, problem)
+}
+
+// required checks that a setting is given
+func (p *configProblems) required(name string, value string) {
+	if len(strings.TrimSpace(value)) == 0 {
+		p.add(name + " is required, set it in a config file or with " + EnvPrefix + strings.ToUpper(name))
+	}
+}
+
+// notNegative checks that a number setting is positive or 0
+func (p *configProblems) notNegative(name string, value int) {
+	if value < 0 {
+		p.add(name + " must not be negative")
+	}
+}
+
+// positive checks that a number setting is positive
+func (p *configProblems) positive(name string, value int) {
+	if value <= 0 {
+		p.add(name + " must be positive")
+	}
+}
+
+// storage checks the storage of the service and its data source
+func (p *configProblems) storage(storage string, dataSource string) {
+	switch storage {
+	case StorageMongoDB:
+	case StoragePostgres, StorageSQLite:
+		p.required("sqlDataSource", dataSource)
+	default:
+		p.add("storage: " + ErrStorageDatabaseUnsupported)
+	}
+}
@@ -0,0 +1,231 @@
+/*
+ * @File: common.config_test.go
+ * @Description: Tests the layers of the configuration: defaults, config files and environment
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// testConfig is a config file with the settings having no default
+const testConfig = `{
+	"mgAddrs": "127.0.0.1:27017",
+	"mgDbName": "go-microservices",
+	"jwtSigningKeys": [{"kid": "2018-10", "privateKeyFile": "config/keys/2018-10.pem"}],
+	"jwtActiveKid": "2018-10",
+	"issuer": "seedotech"
+}`
+
+// writeTestFile writes a file in a temporary directory and returns its name
+func writeTestFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	name = filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(name, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestReadConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		env   map[string]string
+		check func(t *testing.T, config *Configuration)
+		err   []string // parts of the error, the configuration is valid when empty
+	}{
+		{
+			name:  "defaults",
+			files: []string{testConfig},
+			check: func(t *testing.T, config *Configuration) {
+				if config.Port != ":8808" || config.MgTimeout != 10 || config.Storage != StorageMongoDB || config.RefreshTokenTTL != 24*7 {
+					t.Errorf("config = %+v", config)
+				}
+			},
+		},
+		{
+			name:  "later files override the earlier ones",
+			files: []string{testConfig, `{"port": ":9000", "logLevel": "info"}`, `{"logLevel": "warn"}`},
+			check: func(t *testing.T, config *Configuration) {
+				if config.Port != ":9000" || config.LogLevel != "warn" || config.MgDbName != "go-microservices" {
+					t.Errorf("config = %+v", config)
+				}
+			},
+		},
+		{
+			name:  "environment overrides the files",
+			files: []string{testConfig, `{"port": ":9000", "enableGinConsoleLog": true}`},
+			env: map[string]string{
+				"USERSVC_PORT":                ":9100",
+				"USERSVC_ENABLEGINCONSOLELOG": "false",
+				"USERSVC_MGTIMEOUT":           "5",
+				"USERSVC_MGMAXPOOLSIZE":       "20",
+				"USERSVC_TRACINGSAMPLERATIO":  "0.5",
+				"USERSVC_JWTSIGNINGKEYS":      `[{"kid": "2019-01", "privateKeyFile": "keys/2019-01.pem"}]`,
+				"USERSVC_JWTACTIVEKID":        "2019-01",
+			},
+			check: func(t *testing.T, config *Configuration) {
+				if config.Port != ":9100" || config.EnableGinConsoleLog || config.MgTimeout != 5 || config.MgMaxPoolSize != 20 {
+					t.Errorf("config = %+v", config)
+				}
+				if config.TracingSampleRatio != 0.5 || len(config.JwtSigningKeys) != 1 || config.JwtSigningKeys[0].Kid != "2019-01" {
+					t.Errorf("config = %+v", config)
+				}
+			},
+		},
+		{
+			name:  "environment only",
+			files: nil,
+			env: map[string]string{
+				"USERSVC_STORAGE":        StorageSQLite,
+				"USERSVC_SQLDATASOURCE":  "users.db",
+				"USERSVC_JWTSIGNINGKEYS": `[{"kid": "2018-10", "privateKeyFile": "keys/2018-10.pem"}]`,
+				"USERSVC_JWTACTIVEKID":   "2018-10",
+				"USERSVC_ISSUER":         "seedotech",
+			},
+			check: func(t *testing.T, config *Configuration) {
+				if config.Storage != StorageSQLite || config.SQLDataSource != "users.db" || len(config.MgAddrs) > 0 {
+					t.Errorf("config = %+v", config)
+				}
+			},
+		},
+		{
+			name:  "secret read from a file",
+			files: []string{testConfig, `{"mgDbPassword": "in the file"}`},
+			env:   map[string]string{"USERSVC_MGDBPASSWORD_FILE": "secret\r\n"},
+			check: func(t *testing.T, config *Configuration) {
+				if config.MgDbPassword != "secret" {
+					t.Errorf("mgDbPassword = %q", config.MgDbPassword)
+				}
+			},
+		},
+		{
+			name:  "variable preferred to its file",
+			files: []string{testConfig},
+			env:   map[string]string{"USERSVC_MGDBPASSWORD": "variable", "USERSVC_MGDBPASSWORD_FILE": "file"},
+			check: func(t *testing.T, config *Configuration) {
+				if config.MgDbPassword != "variable" {
+					t.Errorf("mgDbPassword = %q", config.MgDbPassword)
+				}
+			},
+		},
+		{
+			name:  "unknown setting",
+			files: []string{testConfig, `{"prot": ":9000"}`},
+			err:   []string{"override.json", `unknown field "prot"`},
+		},
+		{
+			name:  "invalid JSON",
+			files: []string{`{"port": 9000}`},
+			err:   []string{"config.json", "port"},
+		},
+		{
+			name:  "invalid variable",
+			files: []string{testConfig},
+			env:   map[string]string{"USERSVC_MGTIMEOUT": "ten"},
+			err:   []string{"USERSVC_MGTIMEOUT"},
+		},
+		{
+			name:  "invalid list variable",
+			files: []string{testConfig},
+			env:   map[string]string{"USERSVC_JWTSIGNINGKEYS": "2018-10"},
+			err:   []string{"USERSVC_JWTSIGNINGKEYS"},
+		},
+		{
+			name:  "every problem reported",
+			files: []string{`{"mgAddrs": " ", "readTimeout": 0, "logLevel": "loud", "storage": "postgres", "refreshTokenTTL": -1}`},
+			err: []string{
+				ErrConfigInvalid,
+				"readTimeout must be positive",
+				"logLevel: " + ErrLogLevelInvalid,
+				"sqlDataSource is required, set it in a config file or with USERSVC_SQLDATASOURCE",
+				"jwtSigningKeys is required",
+				"jwtActiveKid is required",
+				"issuer is required",
+				"refreshTokenTTL must be positive",
+			},
+		},
+		{
+			name:  "active key missing",
+			files: []string{testConfig, `{"jwtActiveKid": "2019-01"}`},
+			err:   []string{"jwtActiveKid: " + ErrSigningKeyMissing},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The first file is named config.json, the next ones override.json
+			var files []string
+			for i, content := range test.files {
+				name := "override.json"
+				if i == 0 {
+					name = "config.json"
+				}
+				files = append(files, writeTestFile(t, name, content))
+			}
+			for key, value := range test.env {
+				if strings.HasSuffix(key, "_FILE") {
+					value = writeTestFile(t, "secret", value)
+				}
+				t.Setenv(key, value)
+			}
+
+			config, err := readConfig(files)
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				test.check(t, config)
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("config = %+v, want an error", config)
+			}
+			for _, part := range test.err {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("error = %v, want %q", err, part)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	first := writeTestFile(t, "config.json", testConfig)
+	second := writeTestFile(t, "override.json", `{"port": ":9000", "logFilename": "`+filepath.ToSlash(filepath.Join(dir, "server.log"))+`"}`)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		current, configFiles = atomic.Value{}, nil
+	})
+
+	// The files of the environment are read when no file is given
+	t.Setenv("USERSVC_CONFIG", first+","+second)
+	if err := LoadConfig(nil); err != nil {
+		t.Fatal(err)
+	}
+	if Config().Port != ":9000" || Config().MgDbName != "go-microservices" {
+		t.Errorf("config = %+v", Config())
+	}
+	if len(configFiles) != 2 || configFiles[0] != first || configFiles[1] != second {
+		t.Errorf("config files = %v, they are read again by ReloadConfig", configFiles)
+	}
+
+	// The given files are preferred, an invalid configuration is not stored
+	if err := LoadConfig([]string{first, writeTestFile(t, "invalid.json", `{"logLevel": "loud"}`)}); err == nil {
+		t.Fatal("invalid configuration loaded")
+	}
+	if Config().Port != ":9000" {
+		t.Errorf("config = %+v, want the previous one", Config())
+	}
+}
@@ -0,0 +1,156 @@
+/*
+ * @File: controllers.token.go
+ * @Description: Implements token refresh and revocation API logic functions
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"../common"
+	"../middlewares"
+	"../models"
+	"../utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// RefreshToken godoc
+// @Summary Refresh the access token
+// @Description Exchange a refresh token for a new access token and refresh token, the given refresh token can be used only once
+// @Tags admin
+// @Accept  multipart/form-data
+// @Param refreshToken formData string true "Refresh token"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Token
+// @Router /admin/token/refresh [post]
+func (u *User) RefreshToken(ctx *gin.Context) {
+	refreshTokenString := ctx.PostForm("refreshToken")
+	if len(refreshTokenString) == 0 {
+		ctx.Error(models.NewError(models.KindValidationFailed, common.ErrRefreshEmpty))
+		return
+	}
+
+	refreshToken, err := u.tokenDAO.ConsumeRefreshToken(ctx.Request.Context(), refreshTokenString)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Use the current name and role of the user, it may have been changed or removed
+	user, err := u.userDAO.GetByID(ctx.Request.Context(), refreshToken.UserID.Hex())
+	if err != nil {
+		ctx.Error(models.NewError(models.KindUnauthorized, common.ErrRefreshInvalid))
+		log.Debug("[ERROR]: ", err)
+		return
+	}
+
+	u.issueToken(ctx, user, refreshToken.Family)
+}
+
+// Logout godoc
+// @Summary Log out of the service
+// @Description Revoke the access token and the whole family of the given refresh token
+// @Tags admin
+// @Accept  multipart/form-data
+// @Param Authorization header string true "Token"
+// @Param refreshToken formData string true "Refresh token"
+// @Failure 400 {object} models.Error
+// @Failure 401 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.Message
+// @Router /admin/logout [post]
+func (u *User) Logout(ctx *gin.Context) {
+	claims := middlewares.Claims(ctx)
+
+	refreshTokenString := ctx.PostForm("refreshToken")
+	if len(refreshTokenString) == 0 {
+		ctx.Error(models.NewError(models.KindValidationFailed, common.ErrRefreshEmpty))
+		return
+	}
+
+	refreshToken, err := u.tokenDAO.FindRefreshToken(ctx.Request.Context(), refreshTokenString)
+	if err == nil && refreshToken.UserID.Hex() != claims.Subject {
+		err = errors.New(common.ErrRefreshInvalid)
+	}
+	if err != nil {
+		ctx.Error(models.NewError(models.KindUnauthorized, common.ErrRefreshInvalid))
+		return
+	}
+
+	err = u.tokenDAO.RevokeFamily(ctx.Request.Context(), refreshToken.Family)
+	if err == nil {
+		err = u.tokenDAO.RevokeAccessToken(ctx.Request.Context(), claims.Id, time.Unix(claims.ExpiresAt, 0))
+	}
+
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.Message{"Successfully"})
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// GetTokenRevocation godoc
+// @Summary Check if an access token has been revoked
+// @Description Check if an access token has been revoked by its id (jti), used by the resource services
+// @Tags admin
+// @Produce  json
+// @Param id path string true "Token ID (jti)"
+// @Failure 500 {object} models.Error
+// @Success 200 {object} models.TokenRevocation
+// @Router /admin/token/revocations/{id} [get]
+func (u *User) GetTokenRevocation(ctx *gin.Context) {
+	id := ctx.Params.ByName("id")
+	revoked, err := u.tokenDAO.IsRevoked(ctx.Request.Context(), id)
+
+	if err == nil {
+		ctx.JSON(http.StatusOK, models.TokenRevocation{id, revoked})
+	} else {
+		ctx.Error(err)
+	}
+}
+
+// GetJWKS publishes the public keys verifying the tokens as a JSON Web Key Set.
+// It is served at /.well-known/jwks.json, outside of the API base path.
+func (u *User) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, utils.Keys.JWKS())
+}
+
+// IsTokenRevoked checks if an access token has been revoked, used by the auth middleware
+func (u *User) IsTokenRevoked(ctx context.Context, id string) (bool, error) {
+	return u.tokenDAO.IsRevoked(ctx, id)
+}
+
+// issueToken returns a new access token and a refresh token of the given family to the client
+func (u *User) issueToken(ctx *gin.Context, user models.User, family models.ObjectID) {
+	// Users created before roles existed are viewers
+	role := user.Role
+	if len(role) == 0 {
+		role = common.RoleViewer
+	}
+
+	// Generate token string
+	tokenString, err := u.utils.GenerateJWT(user.ID.Hex(), user.Name, role)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(common.Config().RefreshTokenTTL) * time.Hour)
+	refreshTokenString, err := u.tokenDAO.CreateRefreshToken(ctx.Request.Context(), user.ID, family, expiresAt)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	token := models.Token{tokenString, refreshTokenString}
+	// Return token string to the client
+	ctx.JSON(http.StatusOK, token)
+}
@@ -10,17 +10,23 @@ import (
 
 	"../common"
 	"../daos"
+	"../metrics"
 	"../models"
 	"../utils"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/mgo.v2/bson"
 )
 
 // User manages
 type User struct {
-	utils   utils.Utils
-	userDAO daos.User
+	utils    utils.Utils
+	userDAO  daos.UserRepository
+	tokenDAO daos.TokenRepository
+}
+
+// NewUser creates the User APIs storing the Users and their tokens in the given repositories
+func NewUser(userDAO daos.UserRepository, tokenDAO daos.TokenRepository) *User {
+	return &User{userDAO: userDAO, tokenDAO: tokenDAO}
 }
 
 // Authenticate godoc
@@ -39,25 +45,21 @@ func (u *User) Authenticate(ctx *gin.Context) {
 	username := ctx.PostForm("user")
 	password := ctx.PostForm("password")
 
-	// var user models.User
+	var user models.User
 	var err error
-	_, err = u.userDAO.Login(username, password)
+	user, err = u.userDAO.Login(ctx.Request.Context(), username, password)
 
 	if err == nil {
-		var tokenString string
-		// Generate token string
-		tokenString, err = u.utils.GenerateJWT(username, "")
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, models.Error{common.StatusCodeUnknown, err.Error()})
-			log.Debug("[ERROR]: ", err)
-			return
-		}
-
-		token := models.Token{tokenString}
-		// Return token string to the client
-		ctx.JSON(http.StatusOK, token)
+		metrics.Logins.WithLabelValues(metrics.LoginSuccess).Inc()
+		// Start a new refresh token family for this login
+		u.issueToken(ctx, user, models.NewObjectID())
 	} else {
-		ctx.JSON(http.StatusUnauthorized, models.Error{common.StatusCodeUnknown, err.Error()})
+		if err == daos.ErrLoginFailed {
+			metrics.Logins.WithLabelValues(metrics.LoginFailure).Inc()
+		} else {
+			metrics.Logins.WithLabelValues(metrics.LoginError).Inc()
+		}
+		ctx.Error(err)
 	}
 }
 
@@ -71,51 +73,75 @@ func (u *User) Authenticate(ctx *gin.Context) {
 // @Param user body models.AddUser true "Add user"
 // @Failure 500 {object} models.Error
 // @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error
 // @Success 200 {object} models.Message
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
 // @Router /users [post]
 func (u *User) AddUser(ctx *gin.Context) {
 	var addUser models.AddUser
 	if err := ctx.ShouldBindJSON(&addUser); err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.Error{common.StatusCodeUnknown, err.Error()})
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
 		return
 	}
 
-	if err := addUser.Validate(); err != nil {
-		ctx.JSON(http.StatusBadRequest, models.Error{common.StatusCodeUnknown, err.Error()})
+	addUser.Name = u.utils.NormalizeUserName(addUser.Name)
+	if len(addUser.Role) == 0 {
+		addUser.Role = common.RoleViewer
+	}
+
+	// Every submitted password is hashed, even one looking like a hash
+	hash, err := u.utils.HashPassword(string(addUser.Password))
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	user := models.User{bson.NewObjectId(), addUser.Name, addUser.Password}
-	err := u.userDAO.Insert(user)
+	user := models.User{ID: models.NewObjectID(), Name: addUser.Name, Password: models.Secret(hash), Role: addUser.Role}
+	err = u.userDAO.Insert(ctx.Request.Context(), user)
 	if err == nil {
 		ctx.JSON(http.StatusOK, models.Message{"Successfully"})
-		log.Debug("Registered a new user = " + user.Name + ", password = " + user.Password)
+		log.Debug("Registered a new user = " + user.Name)
 	} else {
-		ctx.JSON(http.StatusInternalServerError, models.Error{common.StatusCodeUnknown, err.Error()})
-		log.Debug("[ERROR]: ", err)
+		ctx.Error(err)
 	}
 }
 
 // ListUsers godoc
-// @Summary List all existing users
-// @Description List all existing users
+// @Summary List existing users
+// @Description List a page of the existing users, the next page starts after the returned nextCursor
 // @Tags user
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string true "Token"
+// @Param limit query int false "Page size, 20 by default, 100 at most"
+// @Param after query string false "Cursor of the previous page"
+// @Param offset query int false "Number of users to skip, can't be used with after"
+// @Param sort query string false "Sort field: id, name, createdAt or updatedAt, prefixed by - for the descending order"
+// @Param name query string false "Part of the user name"
+// @Param role query string false "User role"
+// @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
-// @Success 200 {array} models.User
+// @Success 200 {object} models.UserPage
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
 // @Router /users/list [get]
 func (u *User) ListUsers(ctx *gin.Context) {
-	var users []models.User
-	var err error
-	users, err = u.userDAO.GetAll()
+	var query models.PageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
 
+	query.SetDefaults()
+
+	filter := models.UserFilter{Name: ctx.Query("name"), Role: ctx.Query("role")}
+
+	users, next, total, err := u.userDAO.GetPage(ctx.Request.Context(), filter, query)
 	if err == nil {
-		ctx.JSON(http.StatusOK, users)
+		ctx.JSON(http.StatusOK, models.UserPage{models.NewUserInfos(users), next, total})
 	} else {
-		ctx.JSON(http.StatusInternalServerError, models.Error{common.StatusCodeUnknown, err.Error()})
-		log.Debug("[ERROR]: ", err)
+		ctx.Error(err)
 	}
 }
 
@@ -127,20 +153,23 @@ func (u *User) ListUsers(ctx *gin.Context) {
 // @Produce  json
 // @Param Authorization header string true "Token"
 // @Param id path string true "User ID"
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
-// @Success 200 {object} models.User
+// @Success 200 {object} models.UserInfo
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
 // @Router /users/detail/{id} [get]
 func (u *User) GetUserByID(ctx *gin.Context) {
 	var user models.User
 	var err error
 	id := ctx.Params.ByName("id")
-	user, err = u.userDAO.GetByID(id)
+	user, err = u.userDAO.GetByID(ctx.Request.Context(), id)
 
 	if err == nil {
-		ctx.JSON(http.StatusOK, user)
+		ctx.JSON(http.StatusOK, user.Info())
 	} else {
-		ctx.JSON(http.StatusInternalServerError, models.Error{common.StatusCodeUnknown, err.Error()})
-		log.Debug("[ERROR]: ", err)
+		ctx.Error(err)
 	}
 }
 
@@ -152,20 +181,26 @@ func (u *User) GetUserByID(ctx *gin.Context) {
 // @Produce  json
 // @Param Authorization header string true "Token"
 // @Param id query string true "User ID"
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
-// @Success 200 {object} models.User
+// @Success 200 {object} models.UserInfo
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
 // @Router /users [get]
 func (u *User) GetUserByParams(ctx *gin.Context) {
-	var user models.User
-	var err error
-	id := ctx.Request.URL.Query()["id"][0]
-	user, err = u.userDAO.GetByID(id)
+	var query models.UserQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
+		return
+	}
+
+	user, err := u.userDAO.GetByID(ctx.Request.Context(), query.ID)
 
 	if err == nil {
-		ctx.JSON(http.StatusOK, user)
+		ctx.JSON(http.StatusOK, user.Info())
 	} else {
-		ctx.JSON(http.StatusInternalServerError, models.Error{common.StatusCodeUnknown, err.Error()})
-		log.Debug("[ERROR]: ", err)
+		ctx.Error(err)
 	}
 }
 
@@ -177,18 +212,21 @@ func (u *User) GetUserByParams(ctx *gin.Context) {
 // @Produce  json
 // @Param Authorization header string true "Token"
 // @Param id path string true "User ID"
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
 // @Success 200 {object} models.Message
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
 // @Router /users/{id} [delete]
 func (u *User) DeleteUserByID(ctx *gin.Context) {
 	id := ctx.Params.ByName("id")
-	err := u.userDAO.DeleteByID(id)
+	err := u.userDAO.DeleteByID(ctx.Request.Context(), id)
 
 	if err == nil {
 		ctx.JSON(http.StatusOK, models.Message{"Successfully"})
 	} else {
-		ctx.JSON(http.StatusInternalServerError, models.Error{common.StatusCodeUnknown, err.Error()})
-		log.Debug("[ERROR]: ", err)
+		ctx.Error(err)
 	}
 }
 
@@ -199,23 +237,49 @@ func (u *User) DeleteUserByID(ctx *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param Authorization header string true "Token"
-// @Param user body models.User true "User ID"
+// @Param user body models.UpdateUser true "Update user"
 // @Failure 500 {object} models.Error
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
 // @Success 200 {object} models.Message
+// @Failure 401 {object} models.Error
+// @Failure 403 {object} models.Error
 // @Router /users [patch]
 func (u *User) UpdateUser(ctx *gin.Context) {
-	var user models.User
-	if err := ctx.ShouldBindJSON(&user); err != nil {
-		ctx.JSON(http.StatusBadRequest, models.Error{common.StatusCodeUnknown, err.Error()})
+	var updateUser models.UpdateUser
+	if err := ctx.ShouldBindJSON(&updateUser); err != nil {
+		ctx.Error(models.WrapError(models.KindValidationFailed, err))
 		return
 	}
 
-	err := u.userDAO.Update(user)
+	user, err := u.userDAO.GetByID(ctx.Request.Context(), updateUser.ID.Hex())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if name := u.utils.NormalizeUserName(updateUser.Name); len(name) > 0 {
+		user.Name = name
+	}
+	if len(updateUser.Password) > 0 {
+		var hash string
+		hash, err = u.utils.HashPassword(string(updateUser.Password))
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		user.Password = models.Secret(hash)
+	}
+	if len(updateUser.Role) > 0 {
+		user.Role = updateUser.Role
+	}
+
+	err = u.userDAO.Update(ctx.Request.Context(), user)
 	if err == nil {
 		ctx.JSON(http.StatusOK, models.Message{"Successfully"})
-		log.Debug("Registered a new user = " + user.Name + ", password = " + user.Password)
+		log.Debug("Updated the user = " + user.Name)
 	} else {
-		ctx.JSON(http.StatusInternalServerError, models.Error{common.StatusCodeUnknown, err.Error()})
-		log.Debug("[ERROR]: ", err)
+		ctx.Error(err)
 	}
 }
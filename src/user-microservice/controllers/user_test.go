@@ -0,0 +1,370 @@
+/*
+ * @File: controllers.user_test.go
+ * @Description: Tests the User API logic functions against an in-memory or a SQL repository
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"../common"
+	"../daos"
+	"../databases"
+	"../middlewares"
+	"../models"
+	"../utils"
+	"github.com/gin-gonic/gin"
+)
+
+// The tests run against the in-memory repository by default. With -storage=sqlite they run against
+// a temporary SQLite database, with -storage=postgres against the -dataSource PostgreSQL database,
+// whose users are deleted.
+var (
+	testStorage    = flag.String("storage", "memory", "storage of the tested repository: memory, sqlite or postgres")
+	testDataSource = flag.String("dataSource", "", "data source of the tested SQL database")
+)
+
+// newUserRouter routes the User APIs like main does, without the authentication
+func newUserRouter(t *testing.T, users ...models.User) (*gin.Engine, daos.UserRepository) {
+	gin.SetMode(gin.TestMode)
+	utils.RegisterValidations()
+
+	var repository daos.UserRepository
+	if *testStorage == "memory" {
+		repository = daos.NewMemoryUser()
+	} else {
+		repository = daos.NewSQLUser(openTestDatabase(t, "users"))
+	}
+	for _, user := range users {
+		if err := repository.Insert(context.Background(), user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewUser(repository, daos.NewMemoryToken())
+	router := gin.New()
+	router.Use(middlewares.Errors())
+	router.POST("/users", c.AddUser)
+	router.GET("/users/list", c.ListUsers)
+	router.GET("/users/detail/:id", c.GetUserByID)
+	router.GET("/users", c.GetUserByParams)
+	router.DELETE("/users/:id", c.DeleteUserByID)
+	router.PATCH("/users", c.UpdateUser)
+
+	return router, repository
+}
+
+// openTestDatabase opens the SQL database of the tests, the rows of the given tables are deleted
+func openTestDatabase(t *testing.T, tables ...string) *databases.SQL {
+	t.Helper()
+
+	dataSource := *testDataSource
+	if *testStorage == common.StorageSQLite && len(dataSource) == 0 {
+		dataSource = filepath.Join(t.TempDir(), "test.db")
+	}
+
+	db := &databases.SQL{}
+	if err := db.Open(*testStorage, dataSource); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+
+	for _, table := range tables {
+		if _, err := db.DB.Exec("DELETE FROM " + table); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+// serve performs a request, the body is encoded to JSON.
+// The bodies are maps since the Secret fields of the models are redacted in JSON.
+func serve(router *gin.Engine, method string, path string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body == nil {
+		reader = bytes.NewReader(nil)
+	} else {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decode decodes a JSON response, the test fails when the status is not the expected one
+func decode(t *testing.T, w *httptest.ResponseRecorder, status int, result interface{}) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body.String())
+	}
+	if result != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), result); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAddUser(t *testing.T) {
+	router, repository := newUserRouter(t)
+
+	w := serve(router, http.MethodPost, "/users", gin.H{"name": "raycad", "password": "secret12"})
+	decode(t, w, http.StatusOK, nil)
+
+	user, err := repository.Login(context.Background(), "raycad", "secret12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != common.RoleViewer {
+		t.Errorf("role = %q, want %q", user.Role, common.RoleViewer)
+	}
+	if string(user.Password) == "secret12" {
+		t.Error("password is stored in plaintext")
+	}
+
+	// A password looking like a hash is hashed too, it's the one to log in with
+	hash, err := (&utils.Utils{}).HashPassword("secret12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decode(t, serve(router, http.MethodPost, "/users", gin.H{"name": "hashed", "password": hash}), http.StatusOK, nil)
+	if _, err = repository.Login(context.Background(), "hashed", "secret12"); err != daos.ErrLoginFailed {
+		t.Errorf("login with the planted hash: %v", err)
+	}
+	if _, err = repository.Login(context.Background(), "hashed", hash); err != nil {
+		t.Errorf("login with the submitted password: %v", err)
+	}
+
+	var conflict models.Error
+	w = serve(router, http.MethodPost, "/users", gin.H{"name": " RayCad ", "password": "other123"})
+	decode(t, w, http.StatusConflict, &conflict)
+	if conflict.Code != common.StatusDuplicate || conflict.Kind != models.KindDuplicate.Name {
+		t.Errorf("error = %+v, want code %d", conflict, common.StatusDuplicate)
+	}
+
+	if _, err = repository.Login(context.Background(), "RAYCAD", "secret12"); err != nil {
+		t.Errorf("login ignoring the case: %v", err)
+	}
+
+	w = serve(router, http.MethodPost, "/users", gin.H{"name": "raycad"})
+	decode(t, w, http.StatusBadRequest, nil)
+
+	w = serve(router, http.MethodPost, "/users", gin.H{"name": "  ", "password": "secret12"})
+	decode(t, w, http.StatusBadRequest, nil)
+
+	w = serve(router, http.MethodPost, "/users", gin.H{"name": "raycad", "password": "secret12", "role": "owner"})
+	decode(t, w, http.StatusBadRequest, nil)
+
+	var invalid models.Error
+	w = serve(router, http.MethodPost, "/users", gin.H{"name": strings.Repeat("a", 65), "password": "secret"})
+	decode(t, w, http.StatusBadRequest, &invalid)
+	if invalid.Code != common.StatusValidationFailed || len(invalid.Fields) != 2 {
+		t.Fatalf("error = %+v, want the name and password fields", invalid)
+	}
+	if invalid.Fields[0].Field != "name" || invalid.Fields[0].Rule != "max" || invalid.Fields[1].Field != "password" || invalid.Fields[1].Rule != "password" {
+		t.Errorf("fields = %+v", invalid.Fields)
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	router, _ := newUserRouter(t,
+		models.User{ID: models.NewObjectID(), Name: "carol", Password: "secret", Role: common.RoleViewer},
+		models.User{ID: models.NewObjectID(), Name: "alice", Password: "secret", Role: common.RoleAdmin},
+		models.User{ID: models.NewObjectID(), Name: "bob", Password: "secret", Role: common.RoleViewer},
+	)
+
+	var page models.UserPage
+	decode(t, serve(router, http.MethodGet, "/users/list?limit=2&sort=name", nil), http.StatusOK, &page)
+	if page.Total != 3 || len(page.Items) != 2 || page.Items[0].Name != "alice" || page.Items[1].Name != "bob" {
+		t.Fatalf("first page = %+v", page)
+	}
+	if len(page.NextCursor) == 0 {
+		t.Fatal("first page has no next cursor")
+	}
+
+	decode(t, serve(router, http.MethodGet, "/users/list?limit=2&sort=name&after="+page.NextCursor, nil), http.StatusOK, &page)
+	if len(page.Items) != 1 || page.Items[0].Name != "carol" || len(page.NextCursor) != 0 {
+		t.Fatalf("last page = %+v", page)
+	}
+
+	decode(t, serve(router, http.MethodGet, "/users/list?sort=-name&offset=1", nil), http.StatusOK, &page)
+	if len(page.Items) != 2 || page.Items[0].Name != "bob" || page.Items[1].Name != "alice" {
+		t.Fatalf("descending page = %+v", page)
+	}
+
+	decode(t, serve(router, http.MethodGet, "/users/list?role=viewer&name=O", nil), http.StatusOK, &page)
+	if page.Total != 2 || len(page.Items) != 2 {
+		t.Fatalf("filtered page = %+v", page)
+	}
+
+	decode(t, serve(router, http.MethodGet, "/users/list?sort=password", nil), http.StatusBadRequest, nil)
+	decode(t, serve(router, http.MethodGet, "/users/list?after=invalid", nil), http.StatusBadRequest, nil)
+	decode(t, serve(router, http.MethodGet, "/users/list?limit=1000", nil), http.StatusBadRequest, nil)
+}
+
+func TestGetUserByID(t *testing.T) {
+	user := models.User{ID: models.NewObjectID(), Name: "raycad", Password: "secret", Role: common.RoleEditor}
+	router, _ := newUserRouter(t, user)
+
+	w := serve(router, http.MethodGet, "/users/detail/"+user.ID.Hex(), nil)
+	var info models.UserInfo
+	decode(t, w, http.StatusOK, &info)
+	if info.ID != user.ID || info.Name != user.Name || info.Role != user.Role {
+		t.Errorf("user = %+v", info)
+	}
+	if bytes.Contains(w.Body.Bytes(), []byte("password")) {
+		t.Error("password is returned")
+	}
+
+	var notFound models.Error
+	decode(t, serve(router, http.MethodGet, "/users/detail/"+models.NewObjectID().Hex(), nil), http.StatusNotFound, &notFound)
+	if notFound.Code != common.StatusNotFound || notFound.Message != common.ErrNotFound {
+		t.Errorf("error = %+v, want code %d", notFound, common.StatusNotFound)
+	}
+
+	var invalid models.Error
+	decode(t, serve(router, http.MethodGet, "/users/detail/invalid", nil), http.StatusBadRequest, &invalid)
+	if invalid.Code != common.StatusValidationFailed || invalid.Kind != models.KindValidationFailed.Name {
+		t.Errorf("error = %+v, want code %d", invalid, common.StatusValidationFailed)
+	}
+}
+
+func TestGetUserByParams(t *testing.T) {
+	user := models.User{ID: models.NewObjectID(), Name: "raycad", Password: "secret", Role: common.RoleEditor}
+	router, _ := newUserRouter(t, user)
+
+	tests := []struct {
+		query  string
+		status int
+		code   int
+	}{
+		{"?id=" + user.ID.Hex(), http.StatusOK, 0},
+		{"", http.StatusBadRequest, common.StatusValidationFailed},
+		{"?id=", http.StatusBadRequest, common.StatusValidationFailed},
+		{"?name=raycad", http.StatusBadRequest, common.StatusValidationFailed},
+		{"?id=invalid", http.StatusBadRequest, common.StatusValidationFailed},
+		{"?id=" + models.NewObjectID().Hex(), http.StatusNotFound, common.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			w := serve(router, http.MethodGet, "/users"+test.query, nil)
+			if test.status == http.StatusOK {
+				var info models.UserInfo
+				decode(t, w, test.status, &info)
+				if info.ID != user.ID || info.Name != user.Name {
+					t.Errorf("user = %+v", info)
+				}
+				return
+			}
+
+			var invalid models.Error
+			decode(t, w, test.status, &invalid)
+			if invalid.Code != test.code {
+				t.Errorf("error = %+v, want code %d", invalid, test.code)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	router, repository := newUserRouter(t)
+
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{"minimum", "secret12", true},
+		{"too short", "secret1", false},
+		{"no digit", "secretsecret", false},
+		{"no letter", "12345678", false},
+		{"maximum", "a1" + strings.Repeat("x", common.MaxPasswordLength-2), true},
+		{"too long", "a1" + strings.Repeat("x", common.MaxPasswordLength-1), false},
+		// The characters are counted for the minimum and the bytes for the maximum
+		{"multibyte minimum", "\u00e9t\u00e9 2019", true},
+		{"multibyte too long", "a1" + strings.Repeat("\u00e9", common.MaxPasswordLength/2), false},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := fmt.Sprintf("user%d", i)
+			w := serve(router, http.MethodPost, "/users", gin.H{"name": name, "password": test.password})
+			if test.valid {
+				decode(t, w, http.StatusOK, nil)
+				if _, err := repository.Login(context.Background(), name, test.password); err != nil {
+					t.Errorf("login: %v", err)
+				}
+				return
+			}
+
+			var invalid models.Error
+			decode(t, w, http.StatusBadRequest, &invalid)
+			if len(invalid.Fields) != 1 || invalid.Fields[0].Field != "password" || invalid.Fields[0].Rule != "password" {
+				t.Fatalf("error = %+v, want the password field", invalid)
+			}
+			want := fmt.Sprintf("must have at least %d characters and %d bytes at most, with a letter and a digit", common.MinPasswordLength, common.MaxPasswordLength)
+			if invalid.Fields[0].Message != want {
+				t.Errorf("message = %q, want %q", invalid.Fields[0].Message, want)
+			}
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	user := models.User{ID: models.NewObjectID(), Name: "raycad", Password: "secret", Role: common.RoleViewer}
+	other := models.User{ID: models.NewObjectID(), Name: "alice", Password: "secret", Role: common.RoleViewer}
+	router, repository := newUserRouter(t, user, other)
+
+	w := serve(router, http.MethodPatch, "/users", gin.H{"id": user.ID, "password": "changed12", "role": common.RoleEditor})
+	decode(t, w, http.StatusOK, nil)
+
+	updated, err := repository.Login(context.Background(), "raycad", "changed12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Role != common.RoleEditor {
+		t.Errorf("role = %q, want %q", updated.Role, common.RoleEditor)
+	}
+	if updated.UpdatedAt.Before(updated.CreatedAt) {
+		t.Errorf("updatedAt %v is before createdAt %v", updated.UpdatedAt, updated.CreatedAt)
+	}
+
+	w = serve(router, http.MethodPatch, "/users", gin.H{"id": user.ID, "role": "owner"})
+	decode(t, w, http.StatusBadRequest, nil)
+
+	w = serve(router, http.MethodPatch, "/users", gin.H{"id": user.ID, "password": "weak"})
+	decode(t, w, http.StatusBadRequest, nil)
+
+	w = serve(router, http.MethodPatch, "/users", gin.H{"id": user.ID, "name": "Alice"})
+	decode(t, w, http.StatusConflict, nil)
+
+	w = serve(router, http.MethodPatch, "/users", gin.H{"id": models.NewObjectID(), "name": "unknown"})
+	decode(t, w, http.StatusNotFound, nil)
+}
+
+func TestDeleteUserByID(t *testing.T) {
+	user := models.User{ID: models.NewObjectID(), Name: "raycad", Password: "secret", Role: common.RoleViewer}
+	router, repository := newUserRouter(t, user)
+
+	decode(t, serve(router, http.MethodDelete, "/users/"+user.ID.Hex(), nil), http.StatusOK, nil)
+
+	if _, err := repository.GetByID(context.Background(), user.ID.Hex()); err == nil {
+		t.Error("user is not deleted")
+	}
+
+	decode(t, serve(router, http.MethodDelete, "/users/"+user.ID.Hex(), nil), http.StatusNotFound, nil)
+}
@@ -0,0 +1,28 @@
+/*
+ * @File: daos.daos.go
+ * @Description: Implements the helpers shared by the DAOs
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// updated returns mongo.ErrNoDocuments when an update or a replacement matched no document
+func updated(result *mongo.UpdateResult, err error) error {
+	if err == nil && result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return err
+}
+
+// deleted returns mongo.ErrNoDocuments when a delete removed no document
+func deleted(result *mongo.DeleteResult, err error) error {
+	if err == nil && result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return err
+}
@@ -0,0 +1,112 @@
+/*
+ * @File: daos.memorytoken.go
+ * @Description: Implements refresh token and token revocation functions in memory
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"../models"
+	"../utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryToken manages refresh tokens and revoked access tokens in memory, it's safe for concurrent use
+type MemoryToken struct {
+	utils         *utils.Utils
+	mutex         sync.Mutex
+	refreshTokens map[string]models.RefreshToken // by hash
+	revokedTokens map[string]time.Time           // expiration by id
+}
+
+// NewMemoryToken creates an empty in-memory TokenRepository
+func NewMemoryToken() *MemoryToken {
+	return &MemoryToken{refreshTokens: make(map[string]models.RefreshToken), revokedTokens: make(map[string]time.Time)}
+}
+
+// CreateRefreshToken issues a new refresh token of the given family for the User, valid until it expires
+func (t *MemoryToken) CreateRefreshToken(ctx context.Context, userID models.ObjectID, family models.ObjectID, expiresAt time.Time) (string, error) {
+	token, refreshToken, err := newRefreshToken(t.utils, userID, family, expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.refreshTokens[refreshToken.ID] = refreshToken
+	return token, nil
+}
+
+// ConsumeRefreshToken marks a refresh token as used and returns it.
+// Presenting a token which has already been used revokes its whole family.
+func (t *MemoryToken) ConsumeRefreshToken(ctx context.Context, token string) (models.RefreshToken, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	refreshToken, ok := t.refreshTokens[t.utils.HashRefreshToken(token)]
+	if !ok || refreshToken.Revoked || !refreshToken.ExpiresAt.After(time.Now()) {
+		return models.RefreshToken{}, ErrRefreshInvalid
+	}
+	if refreshToken.Used {
+		t.revokeFamily(refreshToken.Family)
+		return models.RefreshToken{}, ErrRefreshInvalid
+	}
+
+	refreshToken.Used = true
+	t.refreshTokens[refreshToken.ID] = refreshToken
+	return refreshToken, nil
+}
+
+// FindRefreshToken finds a refresh token without consuming it
+func (t *MemoryToken) FindRefreshToken(ctx context.Context, token string) (models.RefreshToken, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	refreshToken, ok := t.refreshTokens[t.utils.HashRefreshToken(token)]
+	if !ok {
+		return models.RefreshToken{}, mongo.ErrNoDocuments
+	}
+	return refreshToken, nil
+}
+
+// RevokeFamily revokes all refresh tokens of the given family
+func (t *MemoryToken) RevokeFamily(ctx context.Context, family models.ObjectID) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.revokeFamily(family)
+	return nil
+}
+
+// revokeFamily revokes the refresh tokens of a family, the mutex must be locked
+func (t *MemoryToken) revokeFamily(family models.ObjectID) {
+	for hash, refreshToken := range t.refreshTokens {
+		if refreshToken.Family == family {
+			refreshToken.Revoked = true
+			t.refreshTokens[hash] = refreshToken
+		}
+	}
+}
+
+// RevokeAccessToken revokes an access token by its id until it expires
+func (t *MemoryToken) RevokeAccessToken(ctx context.Context, id string, expiresAt time.Time) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.revokedTokens[id] = expiresAt
+	return nil
+}
+
+// IsRevoked checks if an access token has been revoked
+func (t *MemoryToken) IsRevoked(ctx context.Context, id string) (bool, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	expiresAt, ok := t.revokedTokens[id]
+	return ok && expiresAt.After(time.Now()), nil
+}
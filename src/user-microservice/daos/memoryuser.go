@@ -0,0 +1,185 @@
+/*
+ * @File: daos.memoryuser.go
+ * @Description: Implements User CRUD functions in memory
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"../models"
+	"../utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryUser manages User CRUD in memory, it's safe for concurrent use
+type MemoryUser struct {
+	utils *utils.Utils
+	mutex sync.RWMutex
+	users map[models.ObjectID]models.User
+}
+
+// NewMemoryUser creates an empty in-memory UserRepository
+func NewMemoryUser() *MemoryUser {
+	return &MemoryUser{users: make(map[models.ObjectID]models.User)}
+}
+
+// matchUser checks if a User is selected by the filter, like userQuery does
+func matchUser(user models.User, filter models.UserFilter) bool {
+	if len(filter.Name) > 0 && !strings.Contains(strings.ToLower(user.Name), strings.ToLower(filter.Name)) {
+		return false
+	}
+
+	return len(filter.Role) == 0 || user.Role == filter.Role
+}
+
+// store adds or replaces a User, the names are unique like they are in the databases.
+// The mutex must be held.
+func (u *MemoryUser) store(user models.User) error {
+	for id, other := range u.users {
+		if id != user.ID && other.Name == user.Name {
+			return ErrUserNameTaken
+		}
+	}
+
+	u.users[user.ID] = user
+	return nil
+}
+
+// GetPage gets a page of the Users matching the filter, with the cursor of the next page and the total count
+func (u *MemoryUser) GetPage(ctx context.Context, filter models.UserFilter, query models.PageQuery) ([]models.User, string, int, error) {
+	u.mutex.RLock()
+	users := []models.User{}
+	for _, user := range u.users {
+		if matchUser(user, filter) {
+			users = append(users, user)
+		}
+	}
+	u.mutex.RUnlock()
+
+	indexes, field, err := memoryPage(len(users), func(i int, field string) (models.ObjectID, interface{}) {
+		switch field {
+		case "name":
+			return users[i].ID, users[i].Name
+		case "createdAt":
+			return users[i].ID, users[i].CreatedAt
+		default:
+			return users[i].ID, users[i].UpdatedAt
+		}
+	}, query, userSortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	page := make([]models.User, len(indexes))
+	for k, i := range indexes {
+		page[k] = users[i]
+	}
+
+	var next string
+	if len(page) > query.Limit {
+		page = page[:query.Limit]
+		next, err = nextPageCursor(page[len(page)-1], field)
+	}
+
+	return page, next, len(users), err
+}
+
+// GetByID finds a User by its id
+func (u *MemoryUser) GetByID(ctx context.Context, id string) (models.User, error) {
+	err := u.utils.ValidateObjectID(id)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+
+	user, ok := u.users[utils.ObjectIDHex(id)]
+	if !ok {
+		return models.User{}, mongo.ErrNoDocuments
+	}
+	return user, nil
+}
+
+// DeleteByID removes a User by its id
+func (u *MemoryUser) DeleteByID(ctx context.Context, id string) error {
+	err := u.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	return u.Delete(ctx, models.User{ID: utils.ObjectIDHex(id)})
+}
+
+// Login finds the User matching the given credentials.
+// Legacy plaintext passwords are upgraded to a hash on a successful login.
+func (u *MemoryUser) Login(ctx context.Context, name string, password string) (models.User, error) {
+	name = u.utils.NormalizeUserName(name)
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	for _, user := range u.users {
+		if user.Name != name || !u.utils.ComparePassword(string(user.Password), password) {
+			continue
+		}
+
+		if !u.utils.IsPasswordHashed(string(user.Password)) {
+			hash, err := u.utils.HashPassword(password)
+			if err != nil {
+				return models.User{}, err
+			}
+
+			user.Password = models.Secret(hash)
+			u.users[user.ID] = user
+		}
+
+		return user, nil
+	}
+
+	return models.User{}, ErrLoginFailed
+}
+
+// Insert adds a new User
+func (u *MemoryUser) Insert(ctx context.Context, user models.User) error {
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if _, ok := u.users[user.ID]; ok {
+		return duplicateIDError(user.ID)
+	}
+	return u.store(user)
+}
+
+// Delete remove an existing User
+func (u *MemoryUser) Delete(ctx context.Context, user models.User) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if _, ok := u.users[user.ID]; !ok {
+		return mongo.ErrNoDocuments
+	}
+	delete(u.users, user.ID)
+	return nil
+}
+
+// Update modifies an existing User
+func (u *MemoryUser) Update(ctx context.Context, user models.User) error {
+	user.UpdatedAt = time.Now()
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if _, ok := u.users[user.ID]; !ok {
+		return mongo.ErrNoDocuments
+	}
+	return u.store(user)
+}
@@ -0,0 +1,43 @@
+/*
+ * @File: daos.metered.go
+ * @Description: Times and traces the repository calls
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"time"
+
+	"../metrics"
+	"../tracing"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// daoCall is a repository call being timed and traced
+type daoCall struct {
+	repository string
+	operation  string
+	start      time.Time
+	span       trace.Span
+}
+
+// startCall starts a repository call, its span is a child of the span of the context
+func startCall(ctx context.Context, repository string, operation string) (context.Context, *daoCall) {
+	ctx, span := tracing.Start(ctx, repository+"."+operation,
+		trace.WithAttributes(attribute.String("dao.repository", repository), attribute.String("dao.operation", operation)))
+
+	return ctx, &daoCall{repository, operation, time.Now(), span}
+}
+
+// end records the result of the call, a missing document doesn't fail the span
+func (c *daoCall) end(err error) {
+	metrics.ObserveDAOCall(c.repository, c.operation, c.start, err)
+
+	if err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	tracing.End(c.span, err)
+}
@@ -0,0 +1,78 @@
+/*
+ * @File: daos.metereduser.go
+ * @Description: Times and traces the calls of a UserRepository
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+
+	"../models"
+)
+
+// MeteredUser times and traces the calls of a UserRepository, see metrics.DAOCallDuration
+type MeteredUser struct {
+	repository UserRepository
+}
+
+// NewMeteredUser creates a UserRepository timing and tracing the calls of the given one
+func NewMeteredUser(repository UserRepository) *MeteredUser {
+	return &MeteredUser{repository}
+}
+
+// GetPage times and traces the GetPage call of the repository
+func (u *MeteredUser) GetPage(ctx context.Context, filter models.UserFilter, query models.PageQuery) ([]models.User, string, int, error) {
+	ctx, call := startCall(ctx, "user", "GetPage")
+	users, next, total, err := u.repository.GetPage(ctx, filter, query)
+	call.end(err)
+	return users, next, total, err
+}
+
+// GetByID times and traces the GetByID call of the repository
+func (u *MeteredUser) GetByID(ctx context.Context, id string) (models.User, error) {
+	ctx, call := startCall(ctx, "user", "GetByID")
+	user, err := u.repository.GetByID(ctx, id)
+	call.end(err)
+	return user, err
+}
+
+// DeleteByID times and traces the DeleteByID call of the repository
+func (u *MeteredUser) DeleteByID(ctx context.Context, id string) error {
+	ctx, call := startCall(ctx, "user", "DeleteByID")
+	err := u.repository.DeleteByID(ctx, id)
+	call.end(err)
+	return err
+}
+
+// Login times and traces the Login call of the repository
+func (u *MeteredUser) Login(ctx context.Context, name string, password string) (models.User, error) {
+	ctx, call := startCall(ctx, "user", "Login")
+	user, err := u.repository.Login(ctx, name, password)
+	call.end(err)
+	return user, err
+}
+
+// Insert times and traces the Insert call of the repository
+func (u *MeteredUser) Insert(ctx context.Context, user models.User) error {
+	ctx, call := startCall(ctx, "user", "Insert")
+	err := u.repository.Insert(ctx, user)
+	call.end(err)
+	return err
+}
+
+// Delete times and traces the Delete call of the repository
+func (u *MeteredUser) Delete(ctx context.Context, user models.User) error {
+	ctx, call := startCall(ctx, "user", "Delete")
+	err := u.repository.Delete(ctx, user)
+	call.end(err)
+	return err
+}
+
+// Update times and traces the Update call of the repository
+func (u *MeteredUser) Update(ctx context.Context, user models.User) error {
+	ctx, call := startCall(ctx, "user", "Update")
+	err := u.repository.Update(ctx, user)
+	call.end(err)
+	return err
+}
@@ -0,0 +1,158 @@
+/*
+ * @File: daos.page.go
+ * @Description: Implements cursor and offset pagination of the collections
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"../common"
+	"../models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Errors of the page queries, they are caused by the clients
+var (
+	ErrPageSortInvalid   = models.NewError(models.KindValidationFailed, common.ErrPageSortInvalid)
+	ErrPageCursorInvalid = models.NewError(models.KindValidationFailed, common.ErrPageCursorInvalid)
+)
+
+// pageCursor locates the last document of a page by its sort value and id
+type pageCursor struct {
+	Value interface{}     `bson:"v"`
+	ID    models.ObjectID `bson:"id"`
+}
+
+// findPage finds a page of the documents matching the filter.
+// The documents are sorted by the requested field then by id, so that the
+// cursor of the last document is unique. Only the id and the given fields can be sorted.
+func findPage(ctx context.Context, collection *mongo.Collection, filter bson.M, query models.PageQuery, sortFields []string) ([]bson.Raw, string, int, error) {
+	field, descending := query.Sort, strings.HasPrefix(query.Sort, "-")
+	if descending {
+		field = field[1:]
+	}
+	switch {
+	case len(field) == 0 || field == "id":
+		field = "_id"
+	case !containsString(sortFields, field):
+		return nil, "", 0, ErrPageSortInvalid
+	}
+
+	direction, operator := 1, "$gt"
+	if descending {
+		direction, operator = -1, "$lt"
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	pageFilter := filter
+	if len(query.After) > 0 {
+		var cursor pageCursor
+		cursor, err = decodePageCursor(query.After)
+		if err != nil {
+			return nil, "", 0, err
+		}
+
+		pageFilter = bson.M{"$and": []bson.M{filter, afterCursor(field, operator, cursor)}}
+	}
+
+	sort := bson.D{{Key: field, Value: direction}}
+	if field != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: direction})
+	}
+
+	// Get one more document to know if there is a next page
+	findOptions := options.Find().SetSort(sort).SetSkip(int64(query.Offset)).SetLimit(int64(query.Limit + 1))
+	cursor, err := collection.Find(ctx, pageFilter, findOptions)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var raws []bson.Raw
+	err = cursor.All(ctx, &raws)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var next string
+	if len(raws) > query.Limit {
+		raws = raws[:query.Limit]
+		next, err = encodePageCursor(raws[len(raws)-1], field)
+	}
+
+	return raws, next, int(total), err
+}
+
+// afterCursor returns the filter of the documents after the cursor, in the order of the operator.
+// The documents missing the sort field are sorted like null values, before all other values.
+func afterCursor(field string, operator string, cursor pageCursor) bson.M {
+	id := bson.M{operator: cursor.ID}
+	switch {
+	case field == "_id":
+		return bson.M{"_id": id}
+	case cursor.Value == nil && operator == "$gt":
+		return bson.M{"$or": []bson.M{{field: nil, "_id": id}, {field: bson.M{"$ne": nil}}}}
+	case cursor.Value == nil:
+		return bson.M{field: nil, "_id": id}
+	}
+
+	after := []bson.M{
+		{field: bson.M{operator: cursor.Value}},
+		{field: cursor.Value, "_id": id},
+	}
+	if operator == "$lt" {
+		after = append(after, bson.M{field: nil})
+	}
+	return bson.M{"$or": after}
+}
+
+// encodePageCursor returns the cursor of the given document
+func encodePageCursor(raw bson.Raw, field string) (string, error) {
+	var document bson.M
+	if err := bson.Unmarshal(raw, &document); err != nil {
+		return "", err
+	}
+
+	id, _ := document["_id"].(primitive.ObjectID)
+	data, err := bson.Marshal(pageCursor{document[field], models.ObjectID(id)})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodePageCursor parses a cursor returned by encodePageCursor
+func decodePageCursor(after string) (pageCursor, error) {
+	var cursor pageCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(after)
+	if err == nil {
+		err = bson.Unmarshal(data, &cursor)
+	}
+	if err != nil || cursor.ID.IsZero() {
+		return pageCursor{}, ErrPageCursorInvalid
+	}
+
+	return cursor, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
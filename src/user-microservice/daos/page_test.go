@@ -0,0 +1,114 @@
+/*
+ * @File: daos.page_test.go
+ * @Description: Tests the pagination of the MongoDB collections
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"flag"
+	"testing"
+	"time"
+
+	"../models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The MongoDB tests run with -mongo against the given deployment, in a temporary database
+var testMongo = flag.String("mongo", "", "uri of the MongoDB deployment of the tests, like mongodb://localhost:27017")
+
+// openTestCollection returns a collection of a temporary database, the test is skipped without -mongo
+func openTestCollection(t *testing.T) *mongo.Collection {
+	t.Helper()
+
+	if len(*testMongo) == 0 {
+		t.Skip("no MongoDB deployment, set it with -mongo")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(*testMongo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := client.Database("test_" + models.NewObjectID().Hex())
+	t.Cleanup(func() {
+		database.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+
+	return database.Collection("items")
+}
+
+func TestFindPageMissingField(t *testing.T) {
+	collection := openTestCollection(t)
+	ctx := context.Background()
+
+	// The ids are increasing, two documents miss the sort field and two share their value
+	day := time.Date(2018, 10, 26, 0, 0, 0, 0, time.UTC)
+	ids := make([]models.ObjectID, 5)
+	documents := []bson.M{
+		{"createdAt": day.Add(48 * time.Hour)},
+		{},
+		{"createdAt": day},
+		{},
+		{"createdAt": day},
+	}
+	for i, document := range documents {
+		ids[i] = models.NewObjectID()
+		document["_id"] = primitive.ObjectID(ids[i])
+		if _, err := collection.InsertOne(ctx, document); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		sort  string
+		limit int
+		order []int
+	}{
+		{"ascending pages of one", "createdAt", 1, []int{1, 3, 2, 4, 0}},
+		{"ascending pages of two", "createdAt", 2, []int{1, 3, 2, 4, 0}},
+		{"descending pages of one", "-createdAt", 1, []int{0, 4, 2, 3, 1}},
+		{"descending pages of two", "-createdAt", 2, []int{0, 4, 2, 3, 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var order []models.ObjectID
+			query := models.PageQuery{Sort: test.sort, Limit: test.limit}
+			for {
+				raws, next, total, err := findPage(ctx, collection, bson.M{}, query, []string{"createdAt"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if total != len(documents) {
+					t.Errorf("total = %d, want %d", total, len(documents))
+				}
+
+				for _, raw := range raws {
+					order = append(order, models.ObjectID(raw.Lookup("_id").ObjectID()))
+				}
+				if len(next) == 0 || len(order) > len(documents) {
+					break
+				}
+				query.After = next
+			}
+
+			if len(order) != len(test.order) {
+				t.Fatalf("paged %d documents, want %d", len(order), len(test.order))
+			}
+			for k, i := range test.order {
+				if order[k] != ids[i] {
+					t.Errorf("document %d = %s, want %s", k, order[k].Hex(), ids[i].Hex())
+				}
+			}
+		})
+	}
+}
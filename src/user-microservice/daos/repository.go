@@ -0,0 +1,48 @@
+/*
+ * @File: daos.repository.go
+ * @Description: Defines the repositories used by the API logic functions
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"time"
+
+	"../models"
+)
+
+// UserRepository stores the Users. User implements it with MongoDB, SQLUser
+// with PostgreSQL or SQLite and MemoryUser keeps the Users in memory.
+// The passwords are stored as given, the callers hash them.
+type UserRepository interface {
+	GetPage(ctx context.Context, filter models.UserFilter, query models.PageQuery) ([]models.User, string, int, error)
+	GetByID(ctx context.Context, id string) (models.User, error)
+	DeleteByID(ctx context.Context, id string) error
+	Login(ctx context.Context, name string, password string) (models.User, error)
+	Insert(ctx context.Context, user models.User) error
+	Delete(ctx context.Context, user models.User) error
+	Update(ctx context.Context, user models.User) error
+}
+
+// TokenRepository stores the refresh tokens and the revoked access tokens. Token implements it
+// with MongoDB, SQLToken with PostgreSQL or SQLite and MemoryToken keeps the tokens in memory.
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, userID models.ObjectID, family models.ObjectID, expiresAt time.Time) (string, error)
+	ConsumeRefreshToken(ctx context.Context, token string) (models.RefreshToken, error)
+	FindRefreshToken(ctx context.Context, token string) (models.RefreshToken, error)
+	RevokeFamily(ctx context.Context, family models.ObjectID) error
+	RevokeAccessToken(ctx context.Context, id string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, id string) (bool, error)
+}
+
+var (
+	_ UserRepository = (*User)(nil)
+	_ UserRepository = (*SQLUser)(nil)
+	_ UserRepository = (*MemoryUser)(nil)
+	_ UserRepository = (*MeteredUser)(nil)
+
+	_ TokenRepository = (*Token)(nil)
+	_ TokenRepository = (*SQLToken)(nil)
+	_ TokenRepository = (*MemoryToken)(nil)
+)
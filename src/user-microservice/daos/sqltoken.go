@@ -0,0 +1,142 @@
+/*
+ * @File: daos.sqltoken.go
+ * @Description: Implements refresh token and token revocation functions for PostgreSQL and SQLite
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"../databases"
+	"../models"
+	"../utils"
+)
+
+// SQLToken manages the refresh tokens and the revoked access tokens in the refresh_tokens
+// and revoked_tokens tables of a SQL database. The expired tokens are deleted when new ones
+// are stored, like the TTL indexes of MongoDB do.
+type SQLToken struct {
+	utils *utils.Utils
+	db    *databases.SQL
+}
+
+// NewSQLToken creates a TokenRepository storing the tokens in the given database
+func NewSQLToken(db *databases.SQL) *SQLToken {
+	return &SQLToken{db: db}
+}
+
+// refreshTokenColumns are the columns read by scanRefreshToken
+const refreshTokenColumns = "id, family, user_id, used, revoked, created_at, expires_at"
+
+// scanRefreshToken reads a RefreshToken from a row of refreshTokenColumns
+func scanRefreshToken(row interface{ Scan(...interface{}) error }) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := row.Scan(&token.ID, sqlID{&token.Family}, sqlID{&token.UserID}, &token.Used, &token.Revoked,
+		sqlTime{&token.CreatedAt}, sqlTime{&token.ExpiresAt})
+	return token, err
+}
+
+// CreateRefreshToken issues a new refresh token of the given family for the User, valid until it expires
+func (t *SQLToken) CreateRefreshToken(ctx context.Context, userID models.ObjectID, family models.ObjectID, expiresAt time.Time) (string, error) {
+	token, refreshToken, err := newRefreshToken(t.utils, userID, family, expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := t.db.Context(ctx)
+	defer cancel()
+
+	now := time.Now()
+	err = inTx(ctx, t.db.DB, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE expires_at <= $1", sqlTime{&now})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO refresh_tokens ("+refreshTokenColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7)",
+			refreshToken.ID, refreshToken.Family.Hex(), refreshToken.UserID.Hex(), false, false,
+			sqlTime{&refreshToken.CreatedAt}, sqlTime{&refreshToken.ExpiresAt})
+		return err
+	})
+	return token, err
+}
+
+// ConsumeRefreshToken marks a refresh token as used and returns it.
+// Presenting a token which has already been used revokes its whole family.
+func (t *SQLToken) ConsumeRefreshToken(ctx context.Context, token string) (models.RefreshToken, error) {
+	ctx, cancel := t.db.Context(ctx)
+	defer cancel()
+
+	hash := t.utils.HashRefreshToken(token)
+	now := time.Now()
+	refreshToken, err := scanRefreshToken(t.db.DB.QueryRowContext(ctx,
+		"UPDATE refresh_tokens SET used = $1 WHERE id = $2 AND used = $3 AND revoked = $3 AND expires_at > $4 RETURNING "+refreshTokenColumns,
+		true, hash, false, sqlTime{&now}))
+	if err == nil {
+		return refreshToken, nil
+	}
+	if err != sql.ErrNoRows {
+		return models.RefreshToken{}, err
+	}
+
+	// The token is unknown, expired, revoked or reused. A reused token means
+	// it may have been stolen, so the whole family is revoked.
+	refreshToken, err = scanRefreshToken(t.db.DB.QueryRowContext(ctx, "SELECT "+refreshTokenColumns+" FROM refresh_tokens WHERE id = $1", hash))
+	if err == nil && refreshToken.Used {
+		if err = t.RevokeFamily(ctx, refreshToken.Family); err != nil {
+			return models.RefreshToken{}, err
+		}
+	}
+
+	return models.RefreshToken{}, ErrRefreshInvalid
+}
+
+// FindRefreshToken finds a refresh token without consuming it
+func (t *SQLToken) FindRefreshToken(ctx context.Context, token string) (models.RefreshToken, error) {
+	ctx, cancel := t.db.Context(ctx)
+	defer cancel()
+
+	refreshToken, err := scanRefreshToken(t.db.DB.QueryRowContext(ctx, "SELECT "+refreshTokenColumns+" FROM refresh_tokens WHERE id = $1", t.utils.HashRefreshToken(token)))
+	return refreshToken, sqlFound(err)
+}
+
+// RevokeFamily revokes all refresh tokens of the given family
+func (t *SQLToken) RevokeFamily(ctx context.Context, family models.ObjectID) error {
+	ctx, cancel := t.db.Context(ctx)
+	defer cancel()
+
+	_, err := t.db.DB.ExecContext(ctx, "UPDATE refresh_tokens SET revoked = $1 WHERE family = $2", true, family.Hex())
+	return err
+}
+
+// RevokeAccessToken revokes an access token by its id until it expires
+func (t *SQLToken) RevokeAccessToken(ctx context.Context, id string, expiresAt time.Time) error {
+	ctx, cancel := t.db.Context(ctx)
+	defer cancel()
+
+	now := time.Now()
+	return inTx(ctx, t.db.DB, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= $1", sqlTime{&now})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO revoked_tokens (id, expires_at) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET expires_at = excluded.expires_at",
+			id, sqlTime{&expiresAt})
+		return err
+	})
+}
+
+// IsRevoked checks if an access token has been revoked
+func (t *SQLToken) IsRevoked(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := t.db.Context(ctx)
+	defer cancel()
+
+	now := time.Now()
+	var count int
+	err := t.db.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM revoked_tokens WHERE id = $1 AND expires_at > $2", id, sqlTime{&now}).Scan(&count)
+	return count > 0, err
+}
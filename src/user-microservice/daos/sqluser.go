@@ -0,0 +1,217 @@
+/*
+ * @File: daos.sqluser.go
+ * @Description: Implements User CRUD functions for PostgreSQL and SQLite
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"../databases"
+	"../models"
+	"../utils"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLUser manages User CRUD in the users table of a SQL database
+type SQLUser struct {
+	utils *utils.Utils
+	db    *databases.SQL
+}
+
+// NewSQLUser creates a UserRepository storing the Users in the given database
+func NewSQLUser(db *databases.SQL) *SQLUser {
+	return &SQLUser{db: db}
+}
+
+// userColumns maps the sorted fields of the Users to the columns of the users table
+var userColumns = map[string]string{
+	"_id":       "id",
+	"name":      "name",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+// userSelect selects the columns read by scanUser
+const userSelect = "SELECT id, name, password, role, created_at, updated_at FROM users"
+
+// scanUser reads a User from a row of userSelect
+func scanUser(row interface{ Scan(...interface{}) error }) (models.User, error) {
+	var user models.User
+	err := row.Scan(sqlID{&user.ID}, &user.Name, &user.Password, &user.Role, sqlTime{&user.CreatedAt}, sqlTime{&user.UpdatedAt})
+	return user, err
+}
+
+// GetPage gets a page of the Users matching the filter, with the cursor of the next page and the total count
+func (u *SQLUser) GetPage(ctx context.Context, filter models.UserFilter, query models.PageQuery) ([]models.User, string, int, error) {
+	ctx, cancel := u.db.Context(ctx)
+	defer cancel()
+
+	q := &sqlQuery{}
+	if len(filter.Name) > 0 {
+		q.where("LOWER(name) LIKE " + q.arg("%"+escapeLike(strings.ToLower(filter.Name))+"%") + ` ESCAPE '\'`)
+	}
+	if len(filter.Role) > 0 {
+		q.where("role = " + q.arg(filter.Role))
+	}
+
+	total, err := q.count(ctx, u.db.DB, "users")
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	order, field, err := q.page(query, userColumns, userSortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	users, err := u.find(ctx, userSelect+q.clause()+order, q.args...)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var next string
+	if len(users) > query.Limit {
+		users = users[:query.Limit]
+		next, err = nextPageCursor(users[len(users)-1], field)
+	}
+
+	return users, next, total, err
+}
+
+// find reads the Users selected by a query
+func (u *SQLUser) find(ctx context.Context, query string, args ...interface{}) ([]models.User, error) {
+	rows, err := u.db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		user, err = scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// GetByID finds a User by its id
+func (u *SQLUser) GetByID(ctx context.Context, id string) (models.User, error) {
+	err := u.utils.ValidateObjectID(id)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	ctx, cancel := u.db.Context(ctx)
+	defer cancel()
+
+	user, err := scanUser(u.db.DB.QueryRowContext(ctx, userSelect+" WHERE id = $1", id))
+	return user, sqlFound(err)
+}
+
+// DeleteByID removes a User by its id
+func (u *SQLUser) DeleteByID(ctx context.Context, id string) error {
+	err := u.utils.ValidateObjectID(id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := u.db.Context(ctx)
+	defer cancel()
+
+	return sqlAffected(u.db.DB.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id))
+}
+
+// Login finds the User matching the given credentials.
+// Legacy plaintext passwords are upgraded to a hash on a successful login.
+func (u *SQLUser) Login(ctx context.Context, name string, password string) (models.User, error) {
+	ctx, cancel := u.db.Context(ctx)
+	defer cancel()
+
+	users, err := u.find(ctx, userSelect+" WHERE name = $1", u.utils.NormalizeUserName(name))
+	if err != nil {
+		return models.User{}, err
+	}
+
+	for _, user := range users {
+		if !u.utils.ComparePassword(string(user.Password), password) {
+			continue
+		}
+
+		if !u.utils.IsPasswordHashed(string(user.Password)) {
+			var hash string
+			hash, err = u.utils.HashPassword(password)
+			if err != nil {
+				return models.User{}, err
+			}
+
+			_, err = u.db.DB.ExecContext(ctx, "UPDATE users SET password = $1 WHERE id = $2", hash, user.ID.Hex())
+			if err != nil {
+				return models.User{}, err
+			}
+			user.Password = models.Secret(hash)
+		}
+
+		return user, nil
+	}
+
+	return models.User{}, ErrLoginFailed
+}
+
+// Insert adds a new User into database
+func (u *SQLUser) Insert(ctx context.Context, user models.User) error {
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+
+	ctx, cancel := u.db.Context(ctx)
+	defer cancel()
+
+	_, err := u.db.DB.ExecContext(ctx, "INSERT INTO users (id, name, password, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		user.ID.Hex(), user.Name, string(user.Password), user.Role, sqlTime{&user.CreatedAt}, sqlTime{&user.UpdatedAt})
+	return sqlUserNameTaken(err)
+}
+
+// Delete remove an existing User
+func (u *SQLUser) Delete(ctx context.Context, user models.User) error {
+	ctx, cancel := u.db.Context(ctx)
+	defer cancel()
+
+	return sqlAffected(u.db.DB.ExecContext(ctx, "DELETE FROM users WHERE id = $1", user.ID.Hex()))
+}
+
+// Update modifies an existing User
+func (u *SQLUser) Update(ctx context.Context, user models.User) error {
+	user.UpdatedAt = time.Now()
+
+	ctx, cancel := u.db.Context(ctx)
+	defer cancel()
+
+	return sqlUserNameTaken(sqlAffected(u.db.DB.ExecContext(ctx, "UPDATE users SET name = $1, password = $2, role = $3, created_at = $4, updated_at = $5 WHERE id = $6",
+		user.Name, string(user.Password), user.Role, sqlTime{&user.CreatedAt}, sqlTime{&user.UpdatedAt}, user.ID.Hex())))
+}
+
+// sqlUserNameTaken returns ErrUserNameTaken for the unique constraint violations, like userNameTaken does
+func sqlUserNameTaken(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrUserNameTaken
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY) {
+		return ErrUserNameTaken
+	}
+
+	return err
+}
@@ -0,0 +1,145 @@
+/*
+ * @File: daos.token.go
+ * @Description: Implements refresh token and token revocation functions for MongoDB
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"time"
+
+	"../common"
+	"../databases"
+	"../models"
+	"../utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrRefreshInvalid is returned for the unknown, expired, revoked or reused refresh tokens
+var ErrRefreshInvalid = models.NewError(models.KindUnauthorized, common.ErrRefreshInvalid)
+
+// Token manages refresh tokens and revoked access tokens
+type Token struct {
+	utils *utils.Utils
+}
+
+// newRefreshToken generates a new refresh token of the given family for the User, the token
+// is returned to the client and the RefreshToken is stored
+func newRefreshToken(u *utils.Utils, userID models.ObjectID, family models.ObjectID, expiresAt time.Time) (string, models.RefreshToken, error) {
+	token, hash, err := u.GenerateRefreshToken()
+	if err != nil {
+		return "", models.RefreshToken{}, err
+	}
+
+	refreshToken := models.RefreshToken{
+		ID:        hash,
+		Family:    family,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	return token, refreshToken, nil
+}
+
+// CreateRefreshToken issues a new refresh token of the given family for the User, valid until it expires
+func (t *Token) CreateRefreshToken(ctx context.Context, userID models.ObjectID, family models.ObjectID, expiresAt time.Time) (string, error) {
+	token, refreshToken, err := newRefreshToken(t.utils, userID, family, expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColRefreshTokens)
+
+	_, err = collection.InsertOne(ctx, &refreshToken)
+	return token, err
+}
+
+// ConsumeRefreshToken marks a refresh token as used and returns it.
+// Presenting a token which has already been used revokes its whole family.
+func (t *Token) ConsumeRefreshToken(ctx context.Context, token string) (models.RefreshToken, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColRefreshTokens)
+
+	hash := t.utils.HashRefreshToken(token)
+	filter := bson.M{"_id": hash, "used": false, "revoked": false, "expiresAt": bson.M{"$gt": time.Now()}}
+	update := bson.M{"$set": bson.M{"used": true}}
+
+	var refreshToken models.RefreshToken
+	err := collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&refreshToken)
+	if err == nil {
+		return refreshToken, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return models.RefreshToken{}, err
+	}
+
+	// The token is unknown, expired, revoked or reused. A reused token means
+	// it may have been stolen, so the whole family is revoked.
+	err = collection.FindOne(ctx, bson.M{"_id": hash}).Decode(&refreshToken)
+	if err == nil && refreshToken.Used {
+		if err = t.RevokeFamily(ctx, refreshToken.Family); err != nil {
+			return models.RefreshToken{}, err
+		}
+	}
+
+	return models.RefreshToken{}, ErrRefreshInvalid
+}
+
+// FindRefreshToken finds a refresh token without consuming it
+func (t *Token) FindRefreshToken(ctx context.Context, token string) (models.RefreshToken, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColRefreshTokens)
+
+	var refreshToken models.RefreshToken
+	err := collection.FindOne(ctx, bson.M{"_id": t.utils.HashRefreshToken(token)}).Decode(&refreshToken)
+	return refreshToken, err
+}
+
+// RevokeFamily revokes all refresh tokens of the given family
+func (t *Token) RevokeFamily(ctx context.Context, family models.ObjectID) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColRefreshTokens)
+
+	_, err := collection.UpdateMany(ctx, bson.M{"family": family}, bson.M{"$set": bson.M{"revoked": true}})
+	return err
+}
+
+// RevokeAccessToken revokes an access token by its id until it expires
+func (t *Token) RevokeAccessToken(ctx context.Context, id string, expiresAt time.Time) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColRevokedTokens)
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": id}, &models.RevokedToken{id, expiresAt}, options.Replace().SetUpsert(true))
+	return err
+}
+
+// IsRevoked checks if an access token has been revoked
+func (t *Token) IsRevoked(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
+
+	// Get a collection to execute the query against.
+	collection := databases.Database.Collection(common.ColRevokedTokens)
+
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": id}, options.Count().SetLimit(1))
+	return count > 0, err
+}
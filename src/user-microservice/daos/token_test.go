@@ -0,0 +1,136 @@
+/*
+ * @File: daos.token_test.go
+ * @Description: Tests the rotation of the refresh tokens and the token revocation
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package daos
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"../common"
+	"../databases"
+	"../models"
+)
+
+// tokenRepositories returns the TokenRepositories tested without an external database
+func tokenRepositories(t *testing.T) map[string]TokenRepository {
+	db := &databases.SQL{}
+	if err := db.Open(common.StorageSQLite, filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+
+	return map[string]TokenRepository{
+		"memory": NewMemoryToken(),
+		"sqlite": NewSQLToken(db),
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	userID := models.NewObjectID()
+	expiresAt := time.Now().Add(time.Hour)
+
+	for name, repository := range tokenRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			family := models.NewObjectID()
+			first, err := repository.CreateRefreshToken(ctx, userID, family, expiresAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			consumed, err := repository.ConsumeRefreshToken(ctx, first)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if consumed.Family != family || consumed.UserID != userID || !consumed.Used {
+				t.Errorf("consumed token = %+v", consumed)
+			}
+
+			// The next token of the family is revoked with it when the first one is reused
+			second, err := repository.CreateRefreshToken(ctx, userID, family, expiresAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			other, err := repository.CreateRefreshToken(ctx, userID, models.NewObjectID(), expiresAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tests := []struct {
+				name  string
+				token string
+				err   error
+			}{
+				{"reused token", first, ErrRefreshInvalid},
+				{"token of the reused family", second, ErrRefreshInvalid},
+				{"unknown token", "unknown", ErrRefreshInvalid},
+				{"token of another family", other, nil},
+			}
+			for _, test := range tests {
+				if _, err = repository.ConsumeRefreshToken(ctx, test.token); err != test.err {
+					t.Errorf("%s: error = %v, want %v", test.name, err, test.err)
+				}
+			}
+
+			found, err := repository.FindRefreshToken(ctx, second)
+			if err != nil || !found.Revoked {
+				t.Errorf("revoked token = %+v, %v", found, err)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenExpiration(t *testing.T) {
+	ctx := context.Background()
+
+	for name, repository := range tokenRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			token, err := repository.CreateRefreshToken(ctx, models.NewObjectID(), models.NewObjectID(), time.Now().Add(-time.Second))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = repository.ConsumeRefreshToken(ctx, token); err != ErrRefreshInvalid {
+				t.Errorf("error = %v, want %v", err, ErrRefreshInvalid)
+			}
+		})
+	}
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	ctx := context.Background()
+
+	for name, repository := range tokenRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			tests := []struct {
+				id        string
+				expiresAt time.Time
+				revoked   bool
+			}{
+				{"revoked", time.Now().Add(time.Hour), true},
+				{"expired", time.Now().Add(-time.Hour), false},
+			}
+			for _, test := range tests {
+				if err := repository.RevokeAccessToken(ctx, test.id, test.expiresAt); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tests = append(tests, struct {
+				id        string
+				expiresAt time.Time
+				revoked   bool
+			}{"unknown", time.Time{}, false})
+
+			for _, test := range tests {
+				revoked, err := repository.IsRevoked(ctx, test.id)
+				if err != nil || revoked != test.revoked {
+					t.Errorf("%s: revoked = %v, %v, want %v", test.id, revoked, err, test.revoked)
+				}
+			}
+		})
+	}
+}
@@ -6,11 +6,17 @@
 package daos
 
 import (
+	"context"
+	"regexp"
+	"time"
+
 	"../common"
 	"../databases"
 	"../models"
 	"../utils"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // User manages User CRUD
@@ -18,101 +24,182 @@ type User struct {
 	utils *utils.Utils
 }
 
-// GetAll gets the list of Users
-func (u *User) GetAll() ([]models.User, error) {
-	sessionCopy := databases.Database.MgDbSession.Copy()
-	defer sessionCopy.Close()
+// Errors of the Users, they are caused by the clients
+var (
+	ErrUserNameTaken = models.NewError(models.KindDuplicate, common.ErrUserNameTaken)
+	ErrLoginFailed   = models.NewError(models.KindUnauthorized, common.ErrLoginFailed)
+)
+
+// userSortFields are the fields of the Users which can be sorted
+var userSortFields = []string{"name", "createdAt", "updatedAt"}
+
+// userQuery returns the MongoDB query of a User filter
+func userQuery(filter models.UserFilter) bson.M {
+	query := bson.M{}
+	if len(filter.Name) > 0 {
+		query["name"] = primitive.Regex{regexp.QuoteMeta(filter.Name), "i"}
+	}
+	if len(filter.Role) > 0 {
+		query["role"] = filter.Role
+	}
+
+	return query
+}
+
+// GetPage gets a page of the Users matching the filter, with the cursor of the next page and the total count
+func (u *User) GetPage(ctx context.Context, filter models.UserFilter, query models.PageQuery) ([]models.User, string, int, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
 
 	// Get a collection to execute the query against.
-	collection := sessionCopy.DB(databases.Database.Databasename).C(common.ColUsers)
+	collection := databases.Database.Collection(common.ColUsers)
 
-	var users []models.User
-	err := collection.Find(bson.M{}).All(&users)
-	return users, err
+	raws, next, total, err := findPage(ctx, collection, userQuery(filter), query, userSortFields)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	users, err := unmarshalUsers(raws)
+	return users, next, total, err
+}
+
+// unmarshalUsers unmarshals the Users of a page
+func unmarshalUsers(raws []bson.Raw) ([]models.User, error) {
+	users := make([]models.User, len(raws))
+	for i, raw := range raws {
+		if err := bson.Unmarshal(raw, &users[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return users, nil
 }
 
 // GetByID finds a User by its id
-func (u *User) GetByID(id string) (models.User, error) {
+func (u *User) GetByID(ctx context.Context, id string) (models.User, error) {
 	var err error
 	err = u.utils.ValidateObjectID(id)
 	if err != nil {
 		return models.User{}, err
 	}
 
-	sessionCopy := databases.Database.MgDbSession.Copy()
-	defer sessionCopy.Close()
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
 
 	// Get a collection to execute the query against.
-	collection := sessionCopy.DB(databases.Database.Databasename).C(common.ColUsers)
+	collection := databases.Database.Collection(common.ColUsers)
 
 	var user models.User
-	err = collection.FindId(bson.ObjectIdHex(id)).One(&user)
+	err = collection.FindOne(ctx, bson.M{"_id": utils.ObjectIDHex(id)}).Decode(&user)
 	return user, err
 }
 
 // DeleteByID finds a User by its id
-func (u *User) DeleteByID(id string) error {
+func (u *User) DeleteByID(ctx context.Context, id string) error {
 	var err error
 	err = u.utils.ValidateObjectID(id)
 	if err != nil {
 		return err
 	}
 
-	sessionCopy := databases.Database.MgDbSession.Copy()
-	defer sessionCopy.Close()
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
 
 	// Get a collection to execute the query against.
-	collection := sessionCopy.DB(databases.Database.Databasename).C(common.ColUsers)
+	collection := databases.Database.Collection(common.ColUsers)
 
-	err = collection.Remove(bson.M{"_id": bson.ObjectIdHex(id)})
-	return err
+	return deleted(collection.DeleteOne(ctx, bson.M{"_id": utils.ObjectIDHex(id)}))
 }
 
-// Login User
-func (u *User) Login(name string, password string) (models.User, error) {
-	sessionCopy := databases.Database.MgDbSession.Copy()
-	defer sessionCopy.Close()
+// Login finds the User matching the given credentials.
+// Legacy plaintext passwords are upgraded to a hash on a successful login.
+func (u *User) Login(ctx context.Context, name string, password string) (models.User, error) {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
 
 	// Get a collection to execute the query against.
-	collection := sessionCopy.DB(databases.Database.Databasename).C(common.ColUsers)
+	collection := databases.Database.Collection(common.ColUsers)
 
-	var user models.User
-	err := collection.Find(bson.M{"$and": []bson.M{bson.M{"name": name}, bson.M{"password": password}}}).One(&user)
-	return user, err
+	cursor, err := collection.Find(ctx, bson.M{"name": u.utils.NormalizeUserName(name)})
+	if err != nil {
+		return models.User{}, err
+	}
+
+	var users []models.User
+	err = cursor.All(ctx, &users)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	for _, user := range users {
+		if !u.utils.ComparePassword(string(user.Password), password) {
+			continue
+		}
+
+		if !u.utils.IsPasswordHashed(string(user.Password)) {
+			var hash string
+			hash, err = u.utils.HashPassword(password)
+			if err != nil {
+				return models.User{}, err
+			}
+
+			_, err = collection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"password": hash}})
+			if err != nil {
+				return models.User{}, err
+			}
+			user.Password = models.Secret(hash)
+		}
+
+		return user, nil
+	}
+
+	return models.User{}, ErrLoginFailed
 }
 
 // Insert adds a new User into database'
-func (u *User) Insert(user models.User) error {
-	sessionCopy := databases.Database.MgDbSession.Copy()
-	defer sessionCopy.Close()
+func (u *User) Insert(ctx context.Context, user models.User) error {
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
 
 	// Get a collection to execute the query against.
-	collection := sessionCopy.DB(databases.Database.Databasename).C(common.ColUsers)
+	collection := databases.Database.Collection(common.ColUsers)
 
-	err := collection.Insert(&user)
-	return err
+	_, err := collection.InsertOne(ctx, &user)
+	return userNameTaken(err)
 }
 
 // Delete remove an existing User
-func (u *User) Delete(user models.User) error {
-	sessionCopy := databases.Database.MgDbSession.Copy()
-	defer sessionCopy.Close()
+func (u *User) Delete(ctx context.Context, user models.User) error {
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
 
 	// Get a collection to execute the query against.
-	collection := sessionCopy.DB(databases.Database.Databasename).C(common.ColUsers)
+	collection := databases.Database.Collection(common.ColUsers)
 
-	err := collection.Remove(&user)
-	return err
+	return deleted(collection.DeleteOne(ctx, bson.M{"_id": user.ID}))
 }
 
 // Update modifies an existing User
-func (u *User) Update(user models.User) error {
-	sessionCopy := databases.Database.MgDbSession.Copy()
-	defer sessionCopy.Close()
+func (u *User) Update(ctx context.Context, user models.User) error {
+	user.UpdatedAt = time.Now()
+
+	ctx, cancel := databases.Database.Context(ctx)
+	defer cancel()
 
 	// Get a collection to execute the query against.
-	collection := sessionCopy.DB(databases.Database.Databasename).C(common.ColUsers)
+	collection := databases.Database.Collection(common.ColUsers)
+
+	return userNameTaken(updated(collection.ReplaceOne(ctx, bson.M{"_id": user.ID}, &user)))
+}
+
+// userNameTaken returns ErrUserNameTaken for the duplicate key errors, the name is the only unique field of the Users
+func userNameTaken(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrUserNameTaken
+	}
 
-	err := collection.UpdateId(user.ID, &user)
 	return err
 }
@@ -5,7 +5,55 @@
  */
 package databases
 
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"../common"
+	"../utils"
+	log "github.com/sirupsen/logrus"
+)
+
 // Database shares global database instance
 var (
+	// Database stores the users and their tokens when the storage is mongodb
 	Database MongoDB
+
+	// SQLDatabase stores them when the storage is postgres or sqlite
+	SQLDatabase SQL
 )
+
+// normalizeUserNames returns the new names of the users, by id, whose stored names are not normalized
+// like the APIs normalize them. The names used by several users, ignoring the case, are reported and
+// have to be fixed before the service starts.
+func normalizeUserNames(names map[string]string) (map[string]string, error) {
+	var u utils.Utils
+	renamed := make(map[string]string)
+	users := make(map[string][]string)
+	for id, name := range names {
+		normalized := u.NormalizeUserName(name)
+		if normalized != name {
+			renamed[id] = normalized
+		}
+		users[normalized] = append(users[normalized], id)
+	}
+
+	duplicated := make([]string, 0)
+	for name, ids := range users {
+		if len(ids) > 1 {
+			duplicated = append(duplicated, name)
+		}
+	}
+	if len(duplicated) == 0 {
+		return renamed, nil
+	}
+
+	sort.Strings(duplicated)
+	for _, name := range duplicated {
+		ids := users[name]
+		sort.Strings(ids)
+		log.Error("User name ", name, " is used by the users ", strings.Join(ids, ", "))
+	}
+	return nil, errors.New(common.ErrUserNameDuplicated)
+}
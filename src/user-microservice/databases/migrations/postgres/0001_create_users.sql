@@ -0,0 +1,11 @@
+-- The ids are the hex strings of the ObjectIds, like in MongoDB
+CREATE TABLE users (
+    id         CHAR(24) PRIMARY KEY,
+    name       TEXT NOT NULL,
+    password   TEXT NOT NULL,
+    role       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX users_name ON users (name);
@@ -0,0 +1,4 @@
+-- The user names are normalized in Go before this migration, see databases.normalizeUserNamesStep.
+-- The index may exist already, it was created by the service when it started.
+DROP INDEX IF EXISTS users_name;
+CREATE UNIQUE INDEX IF NOT EXISTS users_name_unique ON users (name);
@@ -0,0 +1,21 @@
+-- The refresh tokens are stored by their hash, the expired ones are deleted by the service
+CREATE TABLE refresh_tokens (
+    id         TEXT PRIMARY KEY,
+    family     CHAR(24) NOT NULL,
+    user_id    CHAR(24) NOT NULL,
+    used       BOOLEAN NOT NULL,
+    revoked    BOOLEAN NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX refresh_tokens_family ON refresh_tokens (family);
+CREATE INDEX refresh_tokens_expires_at ON refresh_tokens (expires_at);
+
+-- The access tokens revoked before their expiration, by their id (jti)
+CREATE TABLE revoked_tokens (
+    id         TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX revoked_tokens_expires_at ON revoked_tokens (expires_at);
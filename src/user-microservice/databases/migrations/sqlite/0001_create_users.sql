@@ -0,0 +1,12 @@
+-- The ids are the hex strings of the ObjectIds, like in MongoDB.
+-- The times are stored as text, see databases.SQLTimeLayout.
+CREATE TABLE users (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    password   TEXT NOT NULL,
+    role       TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+CREATE INDEX users_name ON users (name);
@@ -0,0 +1,22 @@
+-- The refresh tokens are stored by their hash, the expired ones are deleted by the service.
+-- The booleans are stored as 0 or 1.
+CREATE TABLE refresh_tokens (
+    id         TEXT PRIMARY KEY,
+    family     TEXT NOT NULL,
+    user_id    TEXT NOT NULL,
+    used       INTEGER NOT NULL,
+    revoked    INTEGER NOT NULL,
+    created_at TEXT NOT NULL,
+    expires_at TEXT NOT NULL
+);
+
+CREATE INDEX refresh_tokens_family ON refresh_tokens (family);
+CREATE INDEX refresh_tokens_expires_at ON refresh_tokens (expires_at);
+
+-- The access tokens revoked before their expiration, by their id (jti)
+CREATE TABLE revoked_tokens (
+    id         TEXT PRIMARY KEY,
+    expires_at TEXT NOT NULL
+);
+
+CREATE INDEX revoked_tokens_expires_at ON revoked_tokens (expires_at);
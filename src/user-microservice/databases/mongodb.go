@@ -6,73 +6,298 @@
 package databases
 
 import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"../common"
+	"../metrics"
 	"../models"
+	"../utils"
 	log "github.com/sirupsen/logrus"
-	mgo "gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
+// connectTimeout bounds the connection to the MongoDB deployment when the service starts
+const connectTimeout = 60 * time.Second
+
+// maxConnectWait bounds the wait between the connection attempts when the service waits for MongoDB
+const maxConnectWait = 30 * time.Second
+
 // MongoDB manages MongoDB connection
 type MongoDB struct {
-	MgDbSession  *mgo.Session
+	Client       *mongo.Client
 	Databasename string
+	connected    int32
+	utils        utils.Utils
 }
 
-// Init initializes mongo database
+// Init creates the client of the MongoDB deployment, the client connects in the background
 func (db *MongoDB) Init() error {
-	db.Databasename = common.Config.MgDbName
+	db.Databasename = common.Config().MgDbName
 
-	// DialInfo holds options for establishing a session with a MongoDB cluster.
-	dialInfo := &mgo.DialInfo{
-		Addrs:    []string{common.Config.MgAddrs}, // Get HOST + PORT
-		Timeout:  60 * time.Second,
-		Database: db.Databasename,            // Database name
-		Username: common.Config.MgDbUsername, // Username
-		Password: common.Config.MgDbPassword, // Password
+	clientOptions, err := newClientOptions()
+	if err != nil {
+		return err
 	}
 
-	// Create a session which maintains a pool of socket connections
-	// to the DB MongoDB database.
-	var err error
-	db.MgDbSession, err = mgo.DialWithInfo(dialInfo)
+	db.Client, err = mongo.Connect(context.Background(), clientOptions)
+	return err
+}
 
+// Connect waits for the MongoDB deployment, then initializes the database. Ping reports
+// the deployment as unavailable until it is connected.
+func (db *MongoDB) Connect() error {
+	err := db.wait()
 	if err != nil {
 		log.Debug("Can't connect to mongo, go error: ", err)
 		return err
 	}
 
-	return db.initData()
+	err = db.initIndexes()
+	if err != nil {
+		return err
+	}
+
+	err = db.initData()
+	if err != nil {
+		return err
+	}
+
+	atomic.StoreInt32(&db.connected, 1)
+	log.Info("Connected to MongoDB")
+	return nil
+}
+
+// wait pings the MongoDB deployment. When it is unavailable, the ping is retried
+// until mgStartupTimeout seconds are elapsed, so that the service waits for MongoDB.
+func (db *MongoDB) wait() error {
+	deadline := time.Now().Add(time.Duration(common.Config().MgStartupTimeout) * time.Second)
+	wait := time.Second
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+		err := db.Client.Ping(ctx, nil)
+		cancel()
+		if err == nil || time.Now().Add(wait).After(deadline) {
+			return err
+		}
+
+		log.Warn("MongoDB is unavailable, retrying in ", wait, ", go error: ", err)
+		time.Sleep(wait)
+
+		wait *= 2
+		if wait > maxConnectWait {
+			wait = maxConnectWait
+		}
+	}
+}
+
+// newClientOptions returns the connection, pool and concern options of the configuration
+func newClientOptions() (*options.ClientOptions, error) {
+	clientOptions := options.Client().
+		SetHosts(strings.Split(common.Config().MgAddrs, ",")). // Get HOST + PORT
+		SetConnectTimeout(connectTimeout).
+		SetMaxPoolSize(common.Config().MgMaxPoolSize).
+		SetMinPoolSize(common.Config().MgMinPoolSize).
+		SetMaxConnIdleTime(time.Duration(common.Config().MgMaxConnIdleTime) * time.Second).
+		SetPoolMonitor(metrics.PoolMonitor())
+
+	if len(common.Config().MgDbUsername) > 0 {
+		// The users are authenticated by the database of the service
+		clientOptions.SetAuth(options.Credential{
+			AuthSource: common.Config().MgDbName,
+			Username:   common.Config().MgDbUsername,
+			Password:   common.Config().MgDbPassword,
+		})
+	}
+
+	// The concerns of the deployment are used when they are not configured
+	if len(common.Config().MgReadConcern) > 0 {
+		clientOptions.SetReadConcern(&readconcern.ReadConcern{Level: common.Config().MgReadConcern})
+	}
+
+	switch w := common.Config().MgWriteConcern; {
+	case len(w) == 0:
+	case w == "majority":
+		clientOptions.SetWriteConcern(writeconcern.Majority())
+	default:
+		nodes, err := strconv.Atoi(w)
+		if err != nil || nodes < 0 {
+			return nil, errors.New(common.ErrMgWriteConcernInvalid)
+		}
+		clientOptions.SetWriteConcern(&writeconcern.WriteConcern{W: nodes})
+	}
+
+	mode, err := readpref.ModeFromString(common.Config().MgReadPreference)
+	if err != nil {
+		return nil, errors.New(common.ErrMgReadPreferenceInvalid)
+	}
+	readPreference, err := readpref.New(mode)
+	if err != nil {
+		return nil, err
+	}
+	clientOptions.SetReadPreference(readPreference)
+
+	return clientOptions, clientOptions.Validate()
+}
+
+// Context returns the context of a database call, it's canceled after the configured timeout
+func (db *MongoDB) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, time.Duration(common.Config().MgTimeout)*time.Second)
+}
+
+// Ping checks that the MongoDB deployment is connected and reachable
+func (db *MongoDB) Ping(ctx context.Context) error {
+	if atomic.LoadInt32(&db.connected) == 0 {
+		return errors.New(common.ErrMgConnecting)
+	}
+
+	ctx, cancel := db.Context(ctx)
+	defer cancel()
+
+	return db.Client.Ping(ctx, nil)
+}
+
+// Collection returns a collection of the database of the service
+func (db *MongoDB) Collection(name string) *mongo.Collection {
+	return db.Client.Database(db.Databasename).Collection(name)
+}
+
+// initIndexes creates the indexes of the collections
+func (db *MongoDB) initIndexes() error {
+	ctx, cancel := db.Context(context.Background())
+	defer cancel()
+
+	// Refresh tokens and revoked access tokens are removed once they expire
+	for _, name := range []string{common.ColRefreshTokens, common.ColRevokedTokens} {
+		_, err := db.Collection(name).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(1),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := db.Collection(common.ColRefreshTokens).Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "family", Value: 1}}})
+	if err != nil {
+		return err
+	}
+
+	return db.initUserNames(ctx)
+}
+
+// initUserNames normalizes the user names and creates their unique index. The names are
+// normalized in Go, $toLower of MongoDB only folds the ASCII letters.
+func (db *MongoDB) initUserNames(ctx context.Context) error {
+	collection := db.Collection(common.ColUsers)
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"name": 1}))
+	if err != nil {
+		return err
+	}
+
+	var users []struct {
+		ID   models.ObjectID `bson:"_id"`
+		Name string          `bson:"name"`
+	}
+	err = cursor.All(ctx, &users)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]string, len(users))
+	for _, user := range users {
+		names[user.ID.Hex()] = user.Name
+	}
+	renamed, err := normalizeUserNames(names)
+	if err != nil {
+		return err
+	}
+
+	for id, name := range renamed {
+		objectID, _ := models.ObjectIDFromHex(id)
+		_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"name": name}})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)})
+	return err
+}
+
+// initUserTimes sets the creation and update times of the users stored before they had them, or
+// updated since then, to the time of their id, so that the users can be paged by these times
+func (db *MongoDB) initUserTimes(ctx context.Context) error {
+	var zero time.Time
+	filter := bson.M{"$or": []bson.M{
+		{"createdAt": bson.M{"$not": bson.M{"$gt": zero}}},
+		{"updatedAt": bson.M{"$not": bson.M{"$gt": zero}}},
+	}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"createdAt": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$createdAt", zero}}, "$createdAt", bson.M{"$toDate": "$_id"}}}}}},
+		{{Key: "$set", Value: bson.M{"updatedAt": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$updatedAt", zero}}, "$updatedAt", "$createdAt"}}}}},
+	}
+
+	result, err := db.Collection(common.ColUsers).UpdateMany(ctx, filter, update)
+	if err == nil && result.ModifiedCount > 0 {
+		log.Info("Set the creation and update times of ", result.ModifiedCount, " users")
+	}
+	return err
 }
 
 // InitData initializes default data
 func (db *MongoDB) initData() error {
-	var err error
-	var count int
+	ctx, cancel := db.Context(context.Background())
+	defer cancel()
+
+	err := db.initUserTimes(ctx)
+	if err != nil {
+		return err
+	}
 
 	// Check if user collection has at least one document
-	sessionCopy := db.MgDbSession.Copy()
-	defer sessionCopy.Close()
+	collection := db.Collection(common.ColUsers)
+	count, err := collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
 
-	// Get a collection to execute the query against.
-	collection := sessionCopy.DB(db.Databasename).C(common.ColUsers)
-	count, err = collection.Find(bson.M{}).Count()
+	if count > 0 {
+		// The existing users keep their roles, an admin must grant the admin role
+		return nil
+	}
 
-	if count < 1 {
-		// Create admin/admin account
-		var user models.User
-		user = models.User{bson.NewObjectId(), "admin", "admin"}
-		err = collection.Insert(&user)
+	// Create admin/admin account
+	var hash string
+	hash, err = db.utils.HashPassword("admin")
+	if err != nil {
+		return err
 	}
 
+	now := time.Now()
+	var user models.User
+	user = models.User{models.NewObjectID(), "admin", models.Secret(hash), common.RoleAdmin, now, now}
+	_, err = collection.InsertOne(ctx, &user)
+	if err == nil {
+		log.Warn("Created the default admin account, change its password")
+	}
 	return err
 }
 
 // Close the existing connection
 func (db *MongoDB) Close() {
-	if db.MgDbSession != nil {
-		db.MgDbSession.Close()
+	if db.Client != nil {
+		db.Client.Disconnect(context.Background())
 	}
 }
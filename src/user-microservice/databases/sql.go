@@ -0,0 +1,245 @@
+/*
+ * @File: databases.sql.go
+ * @Description: Handles PostgreSQL and SQLite connections and schema migrations
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"../common"
+	"../utils"
+	_ "github.com/lib/pq" // postgres driver
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	_ "modernc.org/sqlite" // sqlite driver
+)
+
+// SQLTimeLayout formats the stored times in UTC with a fixed width, so that they sort as text too.
+// The times are stored to the millisecond like in MongoDB.
+const SQLTimeLayout = "2006-01-02 15:04:05.000Z07:00"
+
+// migrations holds the schema migrations of each storage, they are applied in the order of their numbers
+//
+//go:embed migrations
+var migrations embed.FS
+
+// migrationSteps run in Go before the scripts of their migration versions, in their transactions
+var migrationSteps = map[int]func(tx *sql.Tx) error{
+	2: normalizeUserNamesStep,
+}
+
+// SQL manages a PostgreSQL or SQLite connection
+type SQL struct {
+	DB      *sql.DB
+	Storage string
+	Timeout time.Duration // of every database call, 0 for none
+	utils   utils.Utils
+}
+
+// Init connects to the configured database and migrates its schema
+func (db *SQL) Init() error {
+	err := db.Open(common.Config().Storage, common.Config().SQLDataSource)
+	if err != nil {
+		log.Debug("Can't connect to ", common.Config().Storage, ", go error: ", err)
+		return err
+	}
+	db.Timeout = time.Duration(common.Config().SQLTimeout) * time.Second
+
+	return db.initData()
+}
+
+// Open connects to a postgres or sqlite database and migrates its schema
+func (db *SQL) Open(storage string, dataSource string) error {
+	if storage != common.StoragePostgres && storage != common.StorageSQLite {
+		return errors.New(common.ErrStorageDatabaseUnsupported)
+	}
+	if len(dataSource) == 0 {
+		return errors.New(common.ErrSQLDataSourceEmpty)
+	}
+
+	var err error
+	db.DB, err = sql.Open(storage, dataSource)
+	if err != nil {
+		return err
+	}
+	db.Storage = storage
+
+	if storage == common.StorageSQLite {
+		// SQLite locks the whole database to write, the calls wait for each other
+		// instead of failing when the database is busy
+		db.DB.SetMaxOpenConns(1)
+	}
+
+	err = db.DB.Ping()
+	if err == nil {
+		err = db.migrate()
+	}
+	if err != nil {
+		db.DB.Close()
+	}
+	return err
+}
+
+// migrate applies the migrations of the storage which haven't been applied yet
+func (db *SQL) migrate() error {
+	_, err := db.DB.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL)")
+	if err != nil {
+		return err
+	}
+
+	// The names are sorted, they start with the zero-padded version
+	names, err := fs.Glob(migrations, "migrations/"+db.Storage+"/*.sql")
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		name = path.Base(name)
+		var version int
+		version, err = strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		if err != nil {
+			return err
+		}
+
+		var count int
+		err = db.DB.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = $1", version).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		var script []byte
+		script, err = migrations.ReadFile("migrations/" + db.Storage + "/" + name)
+		if err != nil {
+			return err
+		}
+
+		// A migration is applied completely or not at all
+		var tx *sql.Tx
+		tx, err = db.DB.Begin()
+		if err != nil {
+			return err
+		}
+		if step, ok := migrationSteps[version]; ok {
+			err = step(tx)
+		}
+		if err == nil {
+			_, err = tx.Exec(string(script))
+		}
+		if err == nil {
+			_, err = tx.Exec("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", version, name)
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+
+		log.Info("Applied migration ", name)
+	}
+
+	return nil
+}
+
+// normalizeUserNamesStep normalizes the user names before the 0002 migration creates their unique
+// index. The names are normalized in Go, LOWER of SQLite only folds the ASCII letters.
+func normalizeUserNamesStep(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT id, name FROM users")
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		err = rows.Scan(&id, &name)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		names[strings.TrimSpace(id)] = name
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	renamed, err := normalizeUserNames(names)
+	if err != nil {
+		return err
+	}
+
+	for id, name := range renamed {
+		_, err = tx.Exec("UPDATE users SET name = $1 WHERE id = $2", name, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Context returns the context of a database call, it's canceled after the configured timeout
+func (db *SQL) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	if db.Timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, db.Timeout)
+}
+
+// initData initializes default data
+func (db *SQL) initData() error {
+	ctx, cancel := db.Context(context.Background())
+	defer cancel()
+
+	// Check if user table has at least one row
+	var count int
+	err := db.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	// Create admin/admin account
+	hash, err := db.utils.HashPassword("admin")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(SQLTimeLayout)
+	_, err = db.DB.ExecContext(ctx, "INSERT INTO users (id, name, password, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $5)",
+		primitive.NewObjectID().Hex(), "admin", hash, common.RoleAdmin, now)
+	if err == nil {
+		log.Warn("Created the default admin account, change its password")
+	}
+	return err
+}
+
+// Ping checks that the database is reachable
+func (db *SQL) Ping(ctx context.Context) error {
+	ctx, cancel := db.Context(ctx)
+	defer cancel()
+
+	return db.DB.PingContext(ctx)
+}
+
+// Close the existing connection
+func (db *SQL) Close() {
+	if db.DB != nil {
+		db.DB.Close()
+	}
+}
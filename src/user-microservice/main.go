@@ -6,14 +6,27 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"./common"
 	"./controllers"
+	"./daos"
 	"./databases"
-	"github.com/gin-gonic/contrib/jwt"
+	"./middlewares"
+	"./tracing"
+	"./utils"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
 
 	_ "./docs"
 	"github.com/swaggo/gin-swagger"
@@ -25,35 +38,118 @@ type Main struct {
 	router *gin.Engine
 }
 
-func (m *Main) initServer() error {
+func (m *Main) initServer(configFiles []string) error {
 	var err error
-	// Load config file
-	err = common.LoadConfig()
+	// Load the configuration
+	err = common.LoadConfig(configFiles)
 	if err != nil {
 		return err
 	}
 
-	// Initialize User database
-	err = databases.Database.Init()
+	// Reload the configuration on SIGHUP and when the config files are modified
+	common.WatchConfig(common.ConfigWatchInterval)
+
+	// Trace the requests
+	err = tracing.Init()
+	if err != nil {
+		return err
+	}
+
+	// Load the token signing keys
+	err = utils.LoadKeys()
+	if err != nil {
+		return err
+	}
+	common.OnReload(utils.ReloadKeys)
+
+	// Initialize the database storing the users and their tokens
+	if common.Config().Storage == common.StorageMongoDB {
+		err = databases.Database.Init()
+	} else {
+		err = databases.SQLDatabase.Init()
+	}
 	if err != nil {
 		return err
 	}
 
 	// Setting Gin Logger
-	if common.Config.EnableGinFileLog {
+	if common.Config().EnableGinFileLog {
 		f, _ := os.Create("logs/gin.log")
-		if common.Config.EnableGinConsoleLog {
+		if common.Config().EnableGinConsoleLog {
 			gin.DefaultWriter = io.MultiWriter(os.Stdout, f)
 		} else {
 			gin.DefaultWriter = io.MultiWriter(f)
 		}
 	} else {
-		if !common.Config.EnableGinConsoleLog {
+		if !common.Config().EnableGinConsoleLog {
 			gin.DefaultWriter = io.MultiWriter()
 		}
 	}
 
+	// Register the validation rules of the request models
+	utils.RegisterValidations()
+
 	m.router = gin.Default()
+	// Trace the requests, the spans of the handlers are the children of their span
+	m.router.Use(middlewares.Tracing())
+	// Record the metrics of the requests, with the status codes of the errors
+	m.router.Use(middlewares.Metrics())
+	// Render the errors of the APIs with the error catalog
+	m.router.Use(middlewares.Errors())
+
+	return nil
+}
+
+// serve serves the APIs until the service receives SIGINT or SIGTERM. The server then stops accepting
+// connections and drains the requests in flight, they are canceled after shutdownTimeout seconds.
+// connect runs once the server listens, so that the probes respond while the databases are connecting,
+// the service stops when it fails.
+func (m *Main) serve(connect func() error) error {
+	config := common.Config()
+	server := &http.Server{
+		Addr:         config.Port,
+		Handler:      m.router,
+		ReadTimeout:  time.Duration(config.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(config.WriteTimeout) * time.Second,
+		IdleTimeout:  time.Duration(config.IdleTimeout) * time.Second,
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	listener, err := net.Listen("tcp", config.Port)
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, 2)
+	go func() {
+		log.Info("Listening and serving HTTP on ", config.Port)
+		errs <- server.Serve(listener)
+	}()
+	go func() {
+		if err := connect(); err != nil {
+			errs <- err
+		}
+	}()
+
+	select {
+	case err = <-errs:
+		server.Close()
+		return err
+	case sig := <-stop:
+		log.Info(sig, " received, draining the requests in flight")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(common.Config().ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	err = server.Shutdown(ctx)
+	if err != nil {
+		log.Warn("Requests in flight are canceled, go error: ", err)
+		server.Close()
+	}
+	log.Info("Server is stopped")
 
 	return nil
 }
@@ -66,39 +162,81 @@ func (m *Main) initServer() error {
 // @host 107.113.53.47:8808
 // @BasePath /api/v1
 func main() {
+	var configFiles common.ConfigFiles
+	flag.Var(&configFiles, "config", "config file, repeat it to override the settings of the previous files")
+	flag.Parse()
+
 	m := Main{}
 
 	// Initialize server
-	if m.initServer() != nil {
-		return
+	err := m.initServer(configFiles)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to start the service:", err)
+		os.Exit(1)
 	}
 
-	defer databases.Database.Close()
+	var users daos.UserRepository = &daos.User{}
+	var tokens daos.TokenRepository = &daos.Token{}
+	// Probes of the orchestrator and of the load balancer
+	checks := map[string]controllers.HealthCheck{"mongodb": databases.Database.Ping}
+	// MongoDB connects once the server listens, the SQL database is connected by initServer
+	connect := databases.Database.Connect
+	if common.Config().Storage != common.StorageMongoDB {
+		users = daos.NewSQLUser(&databases.SQLDatabase)
+		tokens = daos.NewSQLToken(&databases.SQLDatabase)
+		checks = map[string]controllers.HealthCheck{common.Config().Storage: databases.SQLDatabase.Ping}
+		connect = func() error { return nil }
+	}
+	// Time the calls of the repository
+	users = daos.NewMeteredUser(users)
 
-	c := controllers.User{}
+	c := controllers.NewUser(users, tokens)
 	// Simple group: v1
 	v1 := m.router.Group("/api/v1")
 	{
+		auth := middlewares.Auth(utils.Keys.Keyfunc, c.IsTokenRevoked)
+
 		admin := v1.Group("/admin")
 		{
 			admin.POST("/auth", c.Authenticate)
+			admin.POST("/token/refresh", c.RefreshToken)
+			admin.GET("/token/revocations/:id", c.GetTokenRevocation)
+			admin.POST("/logout", auth, c.Logout)
 		}
 
 		user := v1.Group("/users")
 
 		// APIs need to be authenticated
-		user.Use(jwt.Auth(common.Config.JwtSecretPassword))
+		user.Use(auth)
 		{
-			user.POST("", c.AddUser)
-			user.GET("/list", c.ListUsers)
-			user.GET("detail/:id", c.GetUserByID)
-			user.GET("/", c.GetUserByParams)
-			user.DELETE(":id", c.DeleteUserByID)
-			user.PATCH("", c.UpdateUser)
+			user.POST("", middlewares.RequireRole(common.RoleAdmin), c.AddUser)
+			user.GET("/list", middlewares.RequireRole(common.RoleViewer), c.ListUsers)
+			user.GET("detail/:id", middlewares.RequireRole(common.RoleViewer), c.GetUserByID)
+			user.GET("/", middlewares.RequireRole(common.RoleViewer), c.GetUserByParams)
+			user.DELETE(":id", middlewares.RequireRole(common.RoleAdmin), c.DeleteUserByID)
+			user.PATCH("", middlewares.RequireRole(common.RoleAdmin), c.UpdateUser)
 		}
 	}
 
+	// Public keys verifying the tokens
+	m.router.GET("/.well-known/jwks.json", c.GetJWKS)
+
+	h := controllers.NewHealth(checks)
+	m.router.GET("/healthz", h.Live)
+	m.router.GET("/readyz", h.Ready)
+
+	// Prometheus metrics
+	m.router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	m.router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	m.router.Run(common.Config.Port)
+	// Serve the APIs until the service is stopped, then close the databases
+	err = m.serve(connect)
+	databases.SQLDatabase.Close()
+	databases.Database.Close()
+	tracing.Shutdown()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to serve the APIs:", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,105 @@
+/*
+ * @File: metrics.metrics.go
+ * @Description: Defines the Prometheus metrics of the service, they are served at /metrics
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UnmatchedRoute labels the requests matching no route, so that their paths don't add label values
+const UnmatchedRoute = "unmatched"
+
+// Results of the DAO calls
+const (
+	ResultOK       = "ok"
+	ResultNotFound = "not_found"
+	ResultError    = "error"
+)
+
+// Results of the logins
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+	LoginError   = "error"
+)
+
+// Metrics of the service
+var (
+	// HTTPRequests counts the requests by route, method and status code
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestDuration observes the latency of the requests by route and method
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of the HTTP requests by route and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// Logins counts the logins of the users by result: success, failure (wrong credentials) or error
+	Logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_logins_total",
+		Help: "Number of logins by result.",
+	}, []string{"result"})
+
+	// DAOCallDuration observes the latency of the repository calls by repository, operation and result
+	DAOCallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dao_call_duration_seconds",
+		Help:    "Latency of the repository calls by repository, operation and result.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"repository", "operation", "result"})
+
+	// MongoPoolConnections counts the connections of the MongoDB pool by state: open or in_use
+	MongoPoolConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mongodb_pool_connections",
+		Help: "Number of connections of the MongoDB pool by state.",
+	}, []string{"state"})
+
+	// MongoPoolCheckoutFailures counts the failed checkouts of a connection from the MongoDB pool
+	MongoPoolCheckoutFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "mongodb_pool_checkout_failures_total",
+		Help: "Number of failed checkouts of a connection from the MongoDB pool.",
+	})
+)
+
+// ObserveDAOCall records a repository call started at start, a missing document is not an error
+func ObserveDAOCall(repository string, operation string, start time.Time, err error) {
+	result := ResultOK
+	if err == mongo.ErrNoDocuments {
+		result = ResultNotFound
+	} else if err != nil {
+		result = ResultError
+	}
+
+	DAOCallDuration.WithLabelValues(repository, operation, result).Observe(time.Since(start).Seconds())
+}
+
+// PoolMonitor returns the monitor of the MongoDB pool updating the pool metrics
+func PoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				MongoPoolConnections.WithLabelValues("open").Inc()
+			case event.ConnectionClosed:
+				MongoPoolConnections.WithLabelValues("open").Dec()
+			case event.GetSucceeded:
+				MongoPoolConnections.WithLabelValues("in_use").Inc()
+			case event.ConnectionReturned:
+				MongoPoolConnections.WithLabelValues("in_use").Dec()
+			case event.GetFailed:
+				MongoPoolCheckoutFailures.Inc()
+			}
+		},
+	}
+}
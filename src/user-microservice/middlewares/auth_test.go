@@ -0,0 +1,59 @@
+/*
+ * @File: middlewares.auth_test.go
+ * @Description: Tests the role permissions of the APIs
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"../common"
+	"../utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		claims   *utils.SdtClaims
+		required string
+		status   int
+	}{
+		{"no token", nil, common.RoleViewer, http.StatusForbidden},
+		{"no role", &utils.SdtClaims{}, common.RoleViewer, http.StatusForbidden},
+		{"unknown role", &utils.SdtClaims{Role: "owner"}, common.RoleViewer, http.StatusForbidden},
+		{"viewer as viewer", &utils.SdtClaims{Role: common.RoleViewer}, common.RoleViewer, http.StatusOK},
+		{"viewer as editor", &utils.SdtClaims{Role: common.RoleViewer}, common.RoleEditor, http.StatusForbidden},
+		{"editor as viewer", &utils.SdtClaims{Role: common.RoleEditor}, common.RoleViewer, http.StatusOK},
+		{"editor as editor", &utils.SdtClaims{Role: common.RoleEditor}, common.RoleEditor, http.StatusOK},
+		{"editor as admin", &utils.SdtClaims{Role: common.RoleEditor}, common.RoleAdmin, http.StatusForbidden},
+		{"admin as editor", &utils.SdtClaims{Role: common.RoleAdmin}, common.RoleEditor, http.StatusOK},
+		{"admin as admin", &utils.SdtClaims{Role: common.RoleAdmin}, common.RoleAdmin, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(Errors())
+			router.Use(func(ctx *gin.Context) {
+				if test.claims != nil {
+					ctx.Set(ClaimsKey, test.claims)
+				}
+			})
+			router.GET("/", RequireRole(test.required), func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != test.status {
+				t.Errorf("status = %d, want %d", w.Code, test.status)
+			}
+		})
+	}
+}
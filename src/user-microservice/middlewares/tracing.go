@@ -0,0 +1,48 @@
+/*
+ * @File: middlewares.tracing.go
+ * @Description: Traces the HTTP requests
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+
+	"../metrics"
+	"../tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing runs every request in a server span, continuing the trace of its traceparent header.
+// The handlers get the span from the context of the request.
+func Tracing() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		if len(route) == 0 {
+			route = metrics.UnmatchedRoute
+		}
+
+		parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		spanCtx, span := tracing.Start(parent, ctx.Request.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("http.request.method", ctx.Request.Method), attribute.String("http.route", route)))
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			if len(ctx.Errors) > 0 {
+				span.RecordError(ctx.Errors.Last())
+			}
+			span.SetStatus(codes.Error, strconv.Itoa(status))
+		}
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * @File: models.objectid.go
+ * @Description: Defines the ObjectID of the documents
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ObjectID is the id of a document. It is stored as a MongoDB ObjectId and returned to
+// the clients as its hex string, the unset id is returned as "" like the ids of mgo were.
+type ObjectID primitive.ObjectID
+
+// NewObjectID generates a new ObjectID
+func NewObjectID() ObjectID {
+	return ObjectID(primitive.NewObjectID())
+}
+
+// ObjectIDFromHex returns the ObjectID of the given hex representation
+func ObjectIDFromHex(id string) (ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	return ObjectID(objectID), err
+}
+
+// Hex returns the hex representation of the ObjectID
+func (id ObjectID) Hex() string {
+	return primitive.ObjectID(id).Hex()
+}
+
+// IsZero checks if the ObjectID is unset
+func (id ObjectID) IsZero() bool {
+	return primitive.ObjectID(id).IsZero()
+}
+
+// String returns the ObjectID like the ObjectIds of MongoDB are printed
+func (id ObjectID) String() string {
+	return primitive.ObjectID(id).String()
+}
+
+// MarshalJSON returns the hex representation, or "" when the ObjectID is unset
+func (id ObjectID) MarshalJSON() ([]byte, error) {
+	if id.IsZero() {
+		return json.Marshal("")
+	}
+
+	return json.Marshal(id.Hex())
+}
+
+// UnmarshalJSON reads the hex representation, "" and null read as the unset ObjectID
+func (id *ObjectID) UnmarshalJSON(data []byte) error {
+	return (*primitive.ObjectID)(id).UnmarshalJSON(data)
+}
+
+// MarshalBSONValue stores the ObjectID as a MongoDB ObjectId
+func (id ObjectID) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(primitive.ObjectID(id))
+}
+
+// UnmarshalBSONValue reads a MongoDB ObjectId, null reads as the unset ObjectID
+func (id *ObjectID) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.ObjectID:
+		return bson.RawValue{Type: t, Value: data}.Unmarshal((*primitive.ObjectID)(id))
+	case bsontype.Null, bsontype.Undefined:
+		*id = ObjectID{}
+		return nil
+	default:
+		return fmt.Errorf("ObjectID can't be read from a BSON %s", t)
+	}
+}
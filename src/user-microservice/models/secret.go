@@ -0,0 +1,28 @@
+/*
+ * @File: models.secret.go
+ * @Description: Defines Secret values which are never printed nor returned to the clients
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package models
+
+// Redacted replaces secret values when they are formatted
+const Redacted = "******"
+
+// Secret is a string which is redacted when logged or marshalled to JSON.
+// It is still decoded from request bodies and stored as a plain string.
+type Secret string
+
+// String redacts the secret for fmt and loggers
+func (s Secret) String() string {
+	return Redacted
+}
+
+// GoString redacts the secret for %#v
+func (s Secret) GoString() string {
+	return Redacted
+}
+
+// MarshalJSON redacts the secret in JSON outputs
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + Redacted + `"`), nil
+}
@@ -6,33 +6,65 @@
 package models
 
 import (
-	"errors"
-
-	"../common"
-	"gopkg.in/mgo.v2/bson"
+	"time"
 )
 
 // User information
 type User struct {
-	ID       bson.ObjectId `bson:"_id" json:"id" example:"5bbdadf782ebac06a695a8e7"`
-	Name     string        `bson:"name" json:"name" example:"raycad"`
-	Password string        `bson:"password" json:"password" example:"raycad"`
+	ID        ObjectID  `bson:"_id" json:"id" example:"5bbdadf782ebac06a695a8e7"`
+	Name      string    `bson:"name" json:"name" example:"raycad"`
+	Password  Secret    `bson:"password" json:"-"`
+	Role      string    `bson:"role" json:"role" example:"viewer"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
 }
 
-// AddUser information
-type AddUser struct {
-	Name     string `json:"name" example:"User Name"`
-	Password string `json:"password" example:"User Password"`
-}
-
-// Validate user
-func (a AddUser) Validate() error {
-	switch {
-	case len(a.Name) == 0:
-		return errors.New(common.ErrNameEmpty)
-	case len(a.Password) == 0:
-		return errors.New(common.ErrPasswordEmpty)
-	default:
-		return nil
+// Info returns the public information of the user
+func (u User) Info() UserInfo {
+	return UserInfo{u.ID, u.Name, u.Role, u.CreatedAt, u.UpdatedAt}
+}
+
+// UserInfo defines the user information will be returned to the clients
+type UserInfo struct {
+	ID        ObjectID  `json:"id" example:"5bbdadf782ebac06a695a8e7"`
+	Name      string    `json:"name" example:"raycad"`
+	Role      string    `json:"role" example:"viewer"`
+	CreatedAt time.Time `json:"createdAt" example:"2018-10-26T10:35:17Z"`
+	UpdatedAt time.Time `json:"updatedAt" example:"2018-10-26T10:35:17Z"`
+}
+
+// NewUserInfos returns the public information of the given users
+func NewUserInfos(users []User) []UserInfo {
+	infos := make([]UserInfo, 0, len(users))
+	for _, user := range users {
+		infos = append(infos, user.Info())
 	}
+
+	return infos
+}
+
+// UserFilter selects the users of the list API, the zero value selects all of them
+type UserFilter struct {
+	Name string // part of the name, the case is ignored
+	Role string // role of the users, any role when empty
+}
+
+// UserQuery selects a user by its id in the query
+type UserQuery struct {
+	ID string `form:"id" binding:"required"`
+}
+
+// AddUser information, the role is viewer by default
+type AddUser struct {
+	Name     string `json:"name" binding:"required,notblank,max=64" example:"User Name"`
+	Password Secret `json:"password" binding:"required,password" example:"User Password 1"`
+	Role     string `json:"role" binding:"omitempty,role" example:"viewer"`
+}
+
+// UpdateUser information, empty fields are left unchanged
+type UpdateUser struct {
+	ID       ObjectID `json:"id" binding:"required" example:"5bbdadf782ebac06a695a8e7"`
+	Name     string   `json:"name" binding:"omitempty,notblank,max=64" example:"User Name"`
+	Password Secret   `json:"password" binding:"omitempty,password" example:"User Password 1"`
+	Role     string   `json:"role" binding:"omitempty,role" example:"editor"`
 }
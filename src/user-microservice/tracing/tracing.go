@@ -0,0 +1,141 @@
+/*
+ * @File: tracing.tracing.go
+ * @Description: Traces the requests of the service with OpenTelemetry
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"../common"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName names the service in the traces
+const ServiceName = "usermanagement"
+
+// tracer creates the spans of the service
+var tracer = otel.Tracer(ServiceName)
+
+// provider exports the spans, it is nil when the tracing is disabled
+var provider *sdktrace.TracerProvider
+
+// Init exports the spans with the configured exporter and propagates the traces in the
+// W3C traceparent and baggage headers. The traces are propagated even when the tracing is disabled.
+func Init() error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	config := common.Config()
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch config.TracingExporter {
+	case common.TracingNone:
+		return nil
+	case common.TracingStdout:
+		exporter, err = stdouttrace.New()
+	case common.TracingFile:
+		var file *os.File
+		file, err = os.OpenFile(config.TracingFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err == nil {
+			exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+		}
+	case common.TracingOTLP:
+		var options []otlptracehttp.Option
+		if len(config.TracingEndpoint) > 0 {
+			options = append(options, otlptracehttp.WithEndpointURL(config.TracingEndpoint))
+		}
+		exporter, err = otlptracehttp.New(context.Background(), options...)
+	default:
+		err = errors.New(common.ErrTracingExporterUnsupported)
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", ServiceName)))
+	if err != nil {
+		return err
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// The traces started by the clients are sampled like they decided
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return nil
+}
+
+// Shutdown exports the remaining spans within shutdownTimeout seconds
+func Shutdown() error {
+	if provider == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(common.Config().ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	return provider.Shutdown(ctx)
+}
+
+// Start starts a span of the service, it must be ended
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, opts...)
+}
+
+// End ends a span, the span fails with the error when it isn't nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Transport traces the outbound requests, the trace is propagated in their headers
+type Transport struct {
+	Base http.RoundTripper // http.DefaultTransport when nil
+}
+
+// RoundTrip sends a request in a client span
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Start(req.Context(), req.Method+" "+req.URL.Host, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.request.method", req.Method), attribute.String("url.full", req.URL.Redacted())))
+
+	// The request must not be modified, its headers are copied before the injection
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err == nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(resp.StatusCode))
+		}
+	}
+	End(span, err)
+
+	return resp, err
+}
@@ -0,0 +1,205 @@
+/*
+ * @File: utils.keys.go
+ * @Description: Loads the asymmetric keys signing the tokens and publishes them as a JWKS
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"../common"
+	"../models"
+	jwt_lib "github.com/dgrijalva/jwt-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// SigningKey is a private key identified by its kid
+type SigningKey struct {
+	Kid     string
+	Method  jwt_lib.SigningMethod
+	Private crypto.Signer
+}
+
+// KeyStore holds the signing keys of the service.
+// Only the active key signs new tokens, the other ones still verify the
+// tokens they have signed so that keys can be rotated with an overlap.
+// The keys are swapped when the configuration is reloaded.
+type KeyStore struct {
+	current atomic.Value
+}
+
+// keySet is a consistent set of signing keys and its active key
+type keySet struct {
+	keys   map[string]SigningKey
+	active SigningKey
+}
+
+// Keys shares the global key store
+var (
+	Keys = &KeyStore{}
+)
+
+// LoadKeys loads the signing keys of the configuration, missing key files are generated
+func LoadKeys() error {
+	return ReloadKeys(common.Config())
+}
+
+// ReloadKeys loads the signing keys of the given configuration and swaps them in,
+// the current keys are kept when one of the new keys can't be loaded
+func ReloadKeys(config *common.Configuration) error {
+	set := &keySet{keys: make(map[string]SigningKey)}
+	for _, keyConfig := range config.JwtSigningKeys {
+		key, err := loadSigningKey(keyConfig.Kid, keyConfig.PrivateKeyFile)
+		if err != nil {
+			return err
+		}
+		set.keys[key.Kid] = key
+	}
+
+	active, ok := set.keys[config.JwtActiveKid]
+	if !ok {
+		return errors.New(common.ErrSigningKeyMissing)
+	}
+	set.active = active
+
+	Keys.current.Store(set)
+	return nil
+}
+
+// set returns the current signing keys
+func (k *KeyStore) set() *keySet {
+	set, _ := k.current.Load().(*keySet)
+	return set
+}
+
+// loadSigningKey reads a PEM encoded RSA or EC private key, an RSA key is generated if the file doesn't exist
+func loadSigningKey(kid string, filename string) (SigningKey, error) {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		log.Info("Generating signing key ", kid, " to ", filename)
+		data, err = generateSigningKey(filename)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, errors.New(common.ErrSigningKeyInvalid)
+	}
+
+	var private interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		return SigningKey{kid, jwt_lib.SigningMethodRS256, key}, nil
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return SigningKey{}, errors.New(common.ErrSigningKeyInvalid)
+		}
+		return SigningKey{kid, jwt_lib.SigningMethodES256, key}, nil
+	default:
+		return SigningKey{}, errors.New(common.ErrSigningKeyInvalid)
+	}
+}
+
+// generateSigningKey writes a new RSA private key to the given file
+func generateSigningKey(filename string) ([]byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err = os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return nil, err
+	}
+
+	return data, ioutil.WriteFile(filename, data, 0600)
+}
+
+// Sign signs the claims with the active key
+func (k *KeyStore) Sign(claims jwt_lib.Claims) (string, error) {
+	active := k.set().active
+	token := jwt_lib.NewWithClaims(active.Method, claims)
+	token.Header["kid"] = active.Kid
+	return token.SignedString(active.Private)
+}
+
+// Keyfunc returns the public key verifying the given token
+func (k *KeyStore) Keyfunc(token *jwt_lib.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.set().keys[kid]
+	if !ok || token.Method.Alg() != key.Method.Alg() {
+		return nil, jwt_lib.ErrSignatureInvalid
+	}
+
+	return key.Private.Public(), nil
+}
+
+// JWKS returns the public keys as a JSON Web Key Set
+func (k *KeyStore) JWKS() models.JWKSet {
+	set := models.JWKSet{Keys: []models.JWK{}}
+	for _, key := range k.set().keys {
+		jwk := models.JWK{Kty: "RSA", Use: "sig", Alg: key.Method.Alg(), Kid: key.Kid}
+		switch public := key.Private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.N = encodeBase64URL(public.N.Bytes())
+			jwk.E = encodeBase64URL(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = public.Curve.Params().Name
+			jwk.X = encodeBase64URL(padBytes(public.X.Bytes(), size))
+			jwk.Y = encodeBase64URL(padBytes(public.Y.Bytes(), size))
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+func encodeBase64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// padBytes left pads the coordinates of EC keys to the size of the curve
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
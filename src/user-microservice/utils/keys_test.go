@@ -0,0 +1,196 @@
+/*
+ * @File: utils.keys_test.go
+ * @Description: Tests the signing keys, their rotation and their JWKS
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"../common"
+	jwt_lib "github.com/dgrijalva/jwt-go"
+)
+
+// writeECKey writes a PEM encoded EC private key of the given curve
+func writeECKey(t *testing.T, filename string, curve elliptic.Curve) {
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	if err = ioutil.WriteFile(filename, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// keysConfig returns a configuration of the given keys, signing with the active one
+func keysConfig(active string, keys ...common.SigningKeyConfig) *common.Configuration {
+	return &common.Configuration{JwtSigningKeys: keys, JwtActiveKid: active}
+}
+
+func TestReloadKeys(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := common.SigningKeyConfig{Kid: "rsa", PrivateKeyFile: filepath.Join(dir, "keys", "rsa.pem")}
+	ecKey := common.SigningKeyConfig{Kid: "ec", PrivateKeyFile: filepath.Join(dir, "ec.pem")}
+	p384Key := common.SigningKeyConfig{Kid: "p384", PrivateKeyFile: filepath.Join(dir, "p384.pem")}
+	textKey := common.SigningKeyConfig{Kid: "text", PrivateKeyFile: filepath.Join(dir, "text.pem")}
+	writeECKey(t, ecKey.PrivateKeyFile, elliptic.P256())
+	writeECKey(t, p384Key.PrivateKeyFile, elliptic.P384())
+	if err := ioutil.WriteFile(textKey.PrivateKeyFile, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config *common.Configuration
+		err    string
+		active string
+		method jwt_lib.SigningMethod
+	}{
+		{"generated RSA key", keysConfig("rsa", rsaKey), "", "rsa", jwt_lib.SigningMethodRS256},
+		{"EC key", keysConfig("ec", rsaKey, ecKey), "", "ec", jwt_lib.SigningMethodES256},
+		{"unsupported curve", keysConfig("p384", rsaKey, p384Key), common.ErrSigningKeyInvalid, "ec", jwt_lib.SigningMethodES256},
+		{"not PEM encoded", keysConfig("rsa", rsaKey, textKey), common.ErrSigningKeyInvalid, "ec", jwt_lib.SigningMethodES256},
+		{"missing active key", keysConfig("other", rsaKey, ecKey), common.ErrSigningKeyMissing, "ec", jwt_lib.SigningMethodES256},
+		{"rotated key", keysConfig("rsa", rsaKey, ecKey), "", "rsa", jwt_lib.SigningMethodRS256},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ReloadKeys(test.config)
+			if len(test.err) > 0 {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("error = %v, want %s", err, test.err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			// The current keys are kept when the new ones can't be loaded
+			active := Keys.set().active
+			if active.Kid != test.active || active.Method != test.method {
+				t.Errorf("active key = %s %s, want %s %s", active.Kid, active.Method.Alg(), test.active, test.method.Alg())
+			}
+		})
+	}
+
+	// The generated key is kept for the next starts
+	if _, err := loadSigningKey("rsa", rsaKey.PrivateKeyFile); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := common.SigningKeyConfig{Kid: "rsa", PrivateKeyFile: filepath.Join(dir, "rsa.pem")}
+	ecKey := common.SigningKeyConfig{Kid: "ec", PrivateKeyFile: filepath.Join(dir, "ec.pem")}
+	writeECKey(t, ecKey.PrivateKeyFile, elliptic.P256())
+
+	sign := func() string {
+		token, err := Keys.Sign(jwt_lib.StandardClaims{Subject: "user"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	if err := ReloadKeys(keysConfig("rsa", rsaKey)); err != nil {
+		t.Fatal(err)
+	}
+	rsaToken := sign()
+	if err := ReloadKeys(keysConfig("ec", rsaKey, ecKey)); err != nil {
+		t.Fatal(err)
+	}
+	ecToken := sign()
+
+	// A token claiming a kid with the algorithm of another key
+	forged := jwt_lib.NewWithClaims(jwt_lib.SigningMethodHS256, jwt_lib.StandardClaims{Subject: "user"})
+	forged.Header["kid"] = "rsa"
+	forgedToken, err := forged.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		keys  *common.Configuration
+		token string
+		valid bool
+	}{
+		{"active key", keysConfig("ec", rsaKey, ecKey), ecToken, true},
+		{"previous key", keysConfig("ec", rsaKey, ecKey), rsaToken, true},
+		{"removed key", keysConfig("ec", ecKey), rsaToken, false},
+		{"algorithm of another key", keysConfig("ec", rsaKey, ecKey), forgedToken, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ReloadKeys(test.keys); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := jwt_lib.Parse(test.token, Keys.Keyfunc)
+			if (err == nil) != test.valid {
+				t.Errorf("valid = %v, want %v", err == nil, test.valid)
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := common.SigningKeyConfig{Kid: "rsa", PrivateKeyFile: filepath.Join(dir, "rsa.pem")}
+	ecKey := common.SigningKeyConfig{Kid: "ec", PrivateKeyFile: filepath.Join(dir, "ec.pem")}
+	writeECKey(t, ecKey.PrivateKeyFile, elliptic.P256())
+	if err := ReloadKeys(keysConfig("ec", rsaKey, ecKey)); err != nil {
+		t.Fatal(err)
+	}
+
+	decode := func(s string) *big.Int {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return new(big.Int).SetBytes(b)
+	}
+
+	jwks := Keys.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("keys = %d, want 2", len(jwks.Keys))
+	}
+	for _, jwk := range jwks.Keys {
+		key := Keys.set().keys[jwk.Kid]
+		if jwk.Use != "sig" || jwk.Alg != key.Method.Alg() {
+			t.Errorf("%s: use = %s, alg = %s", jwk.Kid, jwk.Use, jwk.Alg)
+		}
+
+		switch public := key.Private.Public().(type) {
+		case *ecdsa.PublicKey:
+			// The coordinates are padded to the size of the curve
+			if jwk.Kty != "EC" || jwk.Crv != "P-256" || len(jwk.X) != 43 || len(jwk.Y) != 43 ||
+				decode(jwk.X).Cmp(public.X) != 0 || decode(jwk.Y).Cmp(public.Y) != 0 {
+				t.Errorf("%s: JWK = %+v", jwk.Kid, jwk)
+			}
+		case *rsa.PublicKey:
+			if jwk.Kty != "RSA" || decode(jwk.N).Cmp(public.N) != 0 || decode(jwk.E).Int64() != int64(public.E) {
+				t.Errorf("%s: JWK = %+v", jwk.Kid, jwk)
+			}
+		}
+	}
+}
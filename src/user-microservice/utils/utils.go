@@ -6,12 +6,20 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"strings"
 	"time"
 
 	"../common"
+	"../models"
 	jwt_lib "github.com/dgrijalva/jwt-go"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // SdtClaims defines the custom claims
@@ -24,28 +32,109 @@ type SdtClaims struct {
 type Utils struct {
 }
 
-// GenerateJWT generates token from the given information
-func (u *Utils) GenerateJWT(name string, role string) (string, error) {
+// GenerateJWT generates token from the given information.
+// Every token has its own id (jti) so that it can be revoked.
+func (u *Utils) GenerateJWT(id string, name string, role string) (string, error) {
 	claims := SdtClaims{
 		name,
 		role,
 		jwt_lib.StandardClaims{
+			Id:        models.NewObjectID().Hex(),
+			Subject:   id,
 			ExpiresAt: time.Now().Add(time.Hour * 1).Unix(),
-			Issuer:    common.Config.Issuer,
+			Issuer:    common.Config().Issuer,
 		},
 	}
 
-	token := jwt_lib.NewWithClaims(jwt_lib.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(common.Config.JwtSecretPassword))
+	return Keys.Sign(claims)
+}
+
+// GenerateRefreshToken generates an opaque refresh token and the hash to store it with
+func (u *Utils) GenerateRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, u.HashRefreshToken(token), nil
+}
 
-	return tokenString, err
+// HashRefreshToken hashes a refresh token, only the hashes are stored in database
+func (u *Utils) HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // ValidateObjectID checks the given ID if it's an object id or not
 func (u *Utils) ValidateObjectID(id string) error {
-	if bson.IsObjectIdHex(id) != true {
-		return errors.New(common.ErrNotObjectIDHex)
+	if primitive.IsValidObjectID(id) != true {
+		return models.NewError(models.KindValidationFailed, common.ErrNotObjectIDHex)
+	}
+
+	return nil
+}
+
+// ObjectIDHex returns the ObjectID of the given hex representation.
+// It panics when the hex representation is not valid, check it with ValidateObjectID first.
+func ObjectIDHex(id string) models.ObjectID {
+	objectID, err := models.ObjectIDFromHex(id)
+	if err != nil {
+		panic(err)
+	}
+
+	return objectID
+}
+
+// HashPassword hashes the given password using bcrypt, a random salt is generated for every hash
+func (u *Utils) HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// IsPasswordHashed checks if the stored password is a bcrypt hash or a legacy plaintext one
+func (u *Utils) IsPasswordHashed(password string) bool {
+	_, err := bcrypt.Cost([]byte(password))
+	return err == nil
+}
+
+// ComparePassword checks the given password against the stored one.
+// Legacy plaintext passwords are compared in constant time.
+func (u *Utils) ComparePassword(stored string, password string) bool {
+	if u.IsPasswordHashed(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	}
+
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+}
+
+// roleLevels ranks the roles, a higher level includes the permissions of the lower ones
+var roleLevels = map[string]int{
+	common.RoleViewer: 1,
+	common.RoleEditor: 2,
+	common.RoleAdmin:  3,
+}
+
+// ValidateRole checks the given role if it's a known role or not
+func (u *Utils) ValidateRole(role string) error {
+	if _, ok := roleLevels[role]; !ok {
+		return errors.New(common.ErrRoleInvalid)
 	}
 
 	return nil
 }
+
+// NormalizeUserName returns the stored form of a user name, the names are unique ignoring the case
+func (u *Utils) NormalizeUserName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// HasRole checks if the granted role includes the permissions of the required role
+func (u *Utils) HasRole(granted string, required string) bool {
+	level, ok := roleLevels[granted]
+	return ok && level >= roleLevels[required]
+}
@@ -0,0 +1,108 @@
+/*
+ * @File: utils.validation.go
+ * @Description: Registers the validation rules of the binding tags of the models
+ * @Author: Nguyen Truong Duong (seedotech@gmail.com)
+ */
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"../common"
+	"../models"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var registerValidations sync.Once
+
+// RegisterValidations registers the custom rules of the binding tags, the fields of the
+// validation errors are named like in the requests. It must be called before the requests are bound.
+func RegisterValidations() {
+	registerValidations.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+
+		v.RegisterTagNameFunc(fieldName)
+		v.RegisterValidation("notblank", notBlank)
+		v.RegisterValidation("password", password)
+		v.RegisterValidation("role", role)
+	})
+}
+
+// FieldErrors describes the invalid fields of a validation error
+func FieldErrors(errs validator.ValidationErrors) []models.FieldError {
+	fields := make([]models.FieldError, 0, len(errs))
+	for _, err := range errs {
+		fields = append(fields, models.FieldError{err.Field(), err.Tag(), fieldMessage(err)})
+	}
+
+	return fields
+}
+
+// fieldName returns the name of a field in the JSON bodies or in the queries
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name := strings.Split(field.Tag.Get(key), ",")[0]
+		if len(name) > 0 && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// fieldMessage explains the rule broken by a field
+func fieldMessage(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "min":
+		if err.Kind() == reflect.String {
+			return "must have at least " + err.Param() + " characters"
+		}
+		return "must be at least " + err.Param()
+	case "max":
+		if err.Kind() == reflect.String {
+			return "must not be longer than " + err.Param() + " characters"
+		}
+		return "must be at most " + err.Param()
+	case "excluded_with":
+		return "can't be used with " + strings.ToLower(err.Param())
+	case "password":
+		return fmt.Sprintf("must have at least %d characters and %d bytes at most, with a letter and a digit", common.MinPasswordLength, common.MaxPasswordLength)
+	case "role":
+		return "must be " + common.RoleAdmin + ", " + common.RoleEditor + " or " + common.RoleViewer
+	default:
+		return "is not valid"
+	}
+}
+
+// notBlank checks that a text has other characters than spaces
+func notBlank(fl validator.FieldLevel) bool {
+	return len(strings.TrimSpace(fl.Field().String())) > 0
+}
+
+// password checks the password policy: common.MinPasswordLength characters and common.MaxPasswordLength
+// bytes at most, with a letter and a digit
+func password(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	return utf8.RuneCountInString(value) >= common.MinPasswordLength && len(value) <= common.MaxPasswordLength &&
+		strings.IndexFunc(value, unicode.IsLetter) >= 0 &&
+		strings.IndexFunc(value, unicode.IsDigit) >= 0
+}
+
+// role checks that a role is known
+func role(fl validator.FieldLevel) bool {
+	u := Utils{}
+	return u.ValidateRole(fl.Field().String()) == nil
+}